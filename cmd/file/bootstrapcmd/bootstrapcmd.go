@@ -0,0 +1,272 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bootstrapcmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+	ledgercommon "github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+)
+
+const (
+	use      = "bootstrap"
+	desc     = "Create a file index document and set its ID in the file handler configuration of peers"
+	longDesc = `
+The bootstrap command creates a new file index document in Sidetree (in the same way as 'file createidx') and then updates the file handler configuration of the given peers (in the same way as 'ledgerconfig fileidxupdate') with the ID of the new document.
+The peers may be specified explicitly (--peers) or, using the --all-peers option, all peers in the MSP that have a file handler for the given path are updated.
+
+The file handler configuration of each peer is loaded before the document is created so that the document is not created if a peer doesn't have a file handler for the path. If the ledger configuration cannot be updated after the document was created then the command reports the ID of the document along with the command that completes the update.
+`
+	examples = `
+- Create a file index document for '/content' and set its ID in the file handler configuration of two peers in Org1MSP:
+    $ ./fabric file bootstrap --path /content --url http://localhost:48326/file --recoverykeyfile ./keys/recovery_public.key --updatekeyfile ./keys/update_public.key --msp Org1MSP --peers peer0.org1.example.com;peer1.org1.example.com --noprompt
+
+	Response:
+		File index document [file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==] was created
+		File index successfully updated!
+		Updated peers: [peer0.org1.example.com peer1.org1.example.com]
+		Skipped peers (file index ID already set): []
+		Failed peers: []
+`
+)
+
+const (
+	mspIDFlag  = "msp"
+	mspIDUsage = `The ID of the MSP. Example: --msp Org1MSP`
+
+	peersFlag  = "peers"
+	peersUsage = "A semi-colon-separated list of peers. Example: --peers peer0.org1.com;peer1.org1.com"
+
+	allPeersFlag  = "all-peers"
+	allPeersUsage = "If specified then all peers in the MSP that have a file handler for the given path are updated. Example: --all-peers"
+
+	msgCreated       = "File index document [%s] was created"
+	msgConfigUpdated = "File index successfully updated!"
+
+	msgContinueOrAbort = "Enter Y to continue or N to abort "
+
+	msgPartialState = `the file index document [%s] was created but the file handler configuration of the peers was not completely updated: %s
+To complete the bootstrap, update the remaining peers using:
+    ledgerconfig fileidxupdate --msp %s --peers '%s' --path %s --idxid %s
+Sidetree documents cannot be deleted. If the file index document is no longer needed then deactivate it using:
+    file deactivateidx --idxurl %s --recoverykeyfile <recovery private key file>`
+)
+
+var (
+	errMSPRequired      = errors.New("msp (--msp) is required")
+	errPeersRequired    = errors.New("either peers (--peers) or all peers (--all-peers) is required")
+	errPeersAndAllPeers = errors.New("only one of peers (--peers) or all peers (--all-peers) may be specified")
+)
+
+// New returns the file bootstrap sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	return newCmd(settings, nil, httpclient.New())
+}
+
+func newCmd(settings *environment.Settings, p basecmd.FactoryProvider, client common.CreateHTTPClient) *cobra.Command {
+	c := &command{}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   desc,
+		Long:    longDesc,
+		Example: examples,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return c.validate()
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	c.CreateBaseCommand = common.NewCreateBaseCommand(settings, p, client, cmd)
+
+	cmd.Flags().StringVar(&c.mspID, mspIDFlag, "", mspIDUsage)
+	cmd.Flags().StringVar(&c.peers, peersFlag, "", peersUsage)
+	cmd.Flags().BoolVar(&c.allPeers, allPeersFlag, false, allPeersUsage)
+
+	return cmd
+}
+
+// command implements the bootstrap command
+type command struct {
+	*common.CreateBaseCommand
+
+	// Flags
+	mspID    string
+	peers    string
+	allPeers bool
+}
+
+func (c *command) validate() error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if c.mspID == "" {
+		return errMSPRequired
+	}
+
+	if c.peers == "" && !c.allPeers {
+		return errPeersRequired
+	}
+
+	if c.peers != "" && c.allPeers {
+		return errPeersAndAllPeers
+	}
+
+	return nil
+}
+
+func (c *command) run() error {
+	ch, err := c.Channel()
+	if err != nil {
+		return err
+	}
+
+	updater := ledgercommon.NewFileIndexUpdater(ch, c.mspID, c.Path)
+
+	peers, err := updater.GetPeers(c.peers, c.allPeers)
+	if err != nil {
+		return err
+	}
+
+	// Load the file handler configuration of the peers first so that the document isn't created if
+	// the peers can't be updated
+	configs := updater.LoadPeerConfigs(peers)
+	if err := checkPeerConfigs(configs); err != nil {
+		return err
+	}
+
+	req, err := c.NewCreateRequest()
+	if err != nil {
+		return err
+	}
+
+	if !c.NoPrompt {
+		confirmed, e := c.confirm(peers)
+		if e != nil {
+			return e
+		}
+
+		if !confirmed {
+			return c.Fprintln(common.MsgAborted)
+		}
+	}
+
+	fileIndexID, err := c.createFileIndex(req)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Fprintln(fmt.Sprintf(msgCreated, fileIndexID)); err != nil {
+		return err
+	}
+
+	return c.updatePeers(updater, peers, configs, fileIndexID)
+}
+
+func (c *command) createFileIndex(req []byte) (string, error) {
+	didDocBytes, err := c.CreateFileIndex(req)
+	if err != nil {
+		return "", errors.WithMessage(err, "the file handler configuration of the peers was not updated")
+	}
+
+	fileIdxDoc := &model.FileIndexDoc{}
+	if err := json.Unmarshal(didDocBytes, fileIdxDoc); err != nil {
+		return "", errors.WithMessage(err, "invalid file index document returned from Sidetree")
+	}
+
+	if fileIdxDoc.ID == "" {
+		return "", errors.New("the ID of the created file index document was not returned - the file handler configuration of the peers was not updated")
+	}
+
+	return fileIdxDoc.ID, nil
+}
+
+// updatePeers sets the given file index ID in the file handler configuration of the peers. If any of the
+// peers can't be updated then the returned error reports the partial state along with the steps to complete it.
+func (c *command) updatePeers(updater *ledgercommon.FileIndexUpdater, peers []string, configs []*ledgercommon.PeerFileHandlerConfig, fileIndexID string) error {
+	results := ledgercommon.NewFileIndexUpdateResults(peers, configs, fileIndexID)
+
+	if len(results.Updated) > 0 {
+		configBytes, err := updater.GetConfigBytes(results, fileIndexID)
+		if err == nil {
+			err = updater.Save(configBytes)
+		}
+
+		if err != nil {
+			return c.partialStateError(fileIndexID, append(results.UpdatedPeers(), failedPeers(results)...), err)
+		}
+
+		if err := c.Fprintln(msgConfigUpdated); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Fprint(results.String()); err != nil {
+		return err
+	}
+
+	if len(results.Failed) > 0 {
+		return c.partialStateError(fileIndexID, failedPeers(results),
+			errors.Errorf("failed to update the file index ID on %d peer(s): %s", len(results.Failed), results.FailedString()))
+	}
+
+	return nil
+}
+
+func (c *command) partialStateError(fileIndexID string, peers []string, err error) error {
+	return errors.Errorf(msgPartialState, fileIndexID, err, c.mspID, strings.Join(peers, ";"), c.Path, fileIndexID, c.FileIndexURL(fileIndexID))
+}
+
+// confirm prompts the user for confirmation
+func (c *command) confirm(peers []string) (bool, error) {
+	prompt := fmt.Sprintf("Creating file index document for path [%s] and updating the file handler configuration of peers %s\n%s", c.Path, peers, msgContinueOrAbort)
+
+	if err := c.Fprintln(prompt); err != nil {
+		return false, err
+	}
+
+	return strings.ToLower(c.Prompt()) == "y", nil
+}
+
+// checkPeerConfigs returns an error if the file handler configuration of any of the peers could not be loaded
+func checkPeerConfigs(configs []*ledgercommon.PeerFileHandlerConfig) error {
+	var errs []string
+
+	for _, cfg := range configs {
+		if cfg.Err != nil {
+			errs = append(errs, fmt.Sprintf("[%s: %s]", cfg.PeerID, cfg.Err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Errorf("unable to load the file handler configuration of %d peer(s) - the file index document was not created: %s", len(errs), strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func failedPeers(results *ledgercommon.FileIndexUpdateResults) []string {
+	var peers []string
+	for _, f := range results.Failed {
+		peers = append(peers, f.PeerID)
+	}
+
+	return peers
+}
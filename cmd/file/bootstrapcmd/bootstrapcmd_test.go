@@ -0,0 +1,264 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bootstrapcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/hyperledger/fabric-cli/pkg/fabric"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+	ledgercommon "github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+const (
+	msp   = "Org1MSP"
+	peer0 = "peer0.org1.example.com"
+	peer1 = "peer1.org1.example.com"
+	path  = "/content"
+	idxID = "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+
+	handlerCfg           = `{"BasePath":"/content","ChaincodeName":"files","Collection":"consortium","IndexNamespace":"file:idx"}`
+	mismatchedHandlerCfg = `{"BasePath":"/content","ChaincodeName":"files","Collection":"consortium","IndexNamespace":"file:xxx"}`
+
+	recoveryPublicKey = `
+-----BEGIN PUBLIC KEY-----
+MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEbENaETENCgl8+qgls5JBgogX8Vp1
+G8qXPRBB6W9pzfiphvbPl52B9PLZAWFLcHsP3jsdhag9KNSeVKrQtRshPw==
+-----END PUBLIC KEY-----`
+
+	updatePublicKey = `
+-----BEGIN PUBLIC KEY-----
+MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEbT4kKzrPhR/YFWdHHjxtRHUdsOlt
+gCw04H3xwMXlHY8fIQwQdrKXsNrG482lIFu2tVkKoj51EGiMZUP7jcqp0w==
+-----END PUBLIC KEY-----`
+)
+
+func TestNew(t *testing.T) {
+	require.NotNil(t, New(environment.NewDefaultSettings()))
+}
+
+func TestBootstrapCmd_InvalidOptions(t *testing.T) {
+	createArgs := []string{"--url", "http://localhost:80/file", "--path", path, "--recoverykey", recoveryPublicKey, "--updatekey", updatePublicKey}
+
+	t.Run("No URL", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, nil).Execute(), common.ErrURLRequired.Error())
+	})
+
+	t.Run("No MSP", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, nil, createArgs...).Execute(), errMSPRequired.Error())
+	})
+
+	t.Run("No peers", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, nil, append(createArgs, "--msp", msp)...).Execute(), errPeersRequired.Error())
+	})
+
+	t.Run("Peers and all peers", func(t *testing.T) {
+		err := newMockCmd(t, nil, nil, append(createArgs, "--msp", msp, "--peers", peer0, "--all-peers")...).Execute()
+		require.EqualError(t, err, errPeersAndAllPeers.Error())
+	})
+}
+
+func TestBootstrapCmd(t *testing.T) {
+	fileIndexBytes, err := json.Marshal(&model.FileIndexDoc{
+		ID:        idxID,
+		FileIndex: model.FileIndex{BasePath: path},
+	})
+	require.NoError(t, err)
+
+	didResolutionBytes, err := json.Marshal(model.DIDResolution{DIDDocument: fileIndexBytes})
+	require.NoError(t, err)
+
+	newTransport := func() *mocks.MockTransport {
+		return mocks.NewTransport().WithPostResponse(
+			&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody(didResolutionBytes)},
+		)
+	}
+
+	args := []string{"--url", "http://localhost:80/file", "--path", path, "--recoverykey", recoveryPublicKey,
+		"--updatekey", updatePublicKey, "--msp", msp}
+
+	t.Run("With --all-peers", func(t *testing.T) {
+		ch := newChannel(t, map[string]string{peer0: handlerCfg, peer1: handlerCfg})
+		transport := newTransport()
+		w := &mocks.Writer{}
+
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, newProvider(ch), transport, append(args, "--all-peers", "--noprompt")...)
+		require.NoError(t, c.Execute())
+		require.Len(t, transport.PostRequests, 1)
+		require.Contains(t, w.Written(), "File index document ["+idxID+"] was created")
+		require.Contains(t, w.Written(), msgConfigUpdated)
+		require.Contains(t, w.Written(), "Updated peers: ["+peer0+" "+peer1+"]")
+		require.Equal(t, 1, ch.ExecuteCallCount())
+
+		req, _ := ch.ExecuteArgsForCall(0)
+		cfg := &ledgercommon.Config{}
+		require.NoError(t, json.Unmarshal(req.Args[0], cfg))
+		require.Len(t, cfg.Peers, 2)
+	})
+
+	t.Run("With prompt - Y", func(t *testing.T) {
+		ch := newChannel(t, map[string]string{peer0: handlerCfg})
+		w := &mocks.Writer{}
+
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("Y\n")}, w, newProvider(ch), newTransport(), append(args, "--peers", peer0)...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgContinueOrAbort)
+		require.Contains(t, w.Written(), msgConfigUpdated)
+		require.Equal(t, 1, ch.ExecuteCallCount())
+	})
+
+	t.Run("With prompt - N", func(t *testing.T) {
+		ch := newChannel(t, map[string]string{peer0: handlerCfg})
+		transport := newTransport()
+		w := &mocks.Writer{}
+
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("N\n")}, w, newProvider(ch), transport, append(args, "--peers", peer0)...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), common.MsgAborted)
+		require.Empty(t, transport.PostRequests)
+		require.Equal(t, 0, ch.ExecuteCallCount())
+	})
+
+	t.Run("Peer without file handler -> document not created", func(t *testing.T) {
+		ch := newChannel(t, map[string]string{peer0: handlerCfg})
+		transport := newTransport()
+
+		err := newMockCmd(t, newProvider(ch), transport, append(args, "--peers", peer0+";"+peer1, "--noprompt")...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "the file index document was not created")
+		require.Contains(t, err.Error(), peer1+": config not found for file handler [/content]")
+		require.Empty(t, transport.PostRequests)
+		require.Equal(t, 0, ch.ExecuteCallCount())
+	})
+
+	t.Run("Sidetree error -> peers not updated", func(t *testing.T) {
+		ch := newChannel(t, map[string]string{peer0: handlerCfg})
+		transport := mocks.NewTransport().WithPostResponse(
+			&http.Response{StatusCode: http.StatusInternalServerError, Body: mocks.NewResponseBody([]byte("server error"))},
+		)
+
+		err := newMockCmd(t, newProvider(ch), transport, append(args, "--peers", peer0, "--noprompt")...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "server error")
+		require.Contains(t, err.Error(), "the file handler configuration of the peers was not updated")
+		require.Equal(t, 0, ch.ExecuteCallCount())
+	})
+
+	t.Run("Save error -> partial state", func(t *testing.T) {
+		ch := newChannel(t, map[string]string{peer0: handlerCfg, peer1: handlerCfg})
+		ch.ExecuteReturns(channel.Response{}, errors.New("injected execute error"))
+
+		err := newMockCmd(t, newProvider(ch), newTransport(), append(args, "--peers", peer0+";"+peer1, "--noprompt")...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "the file index document ["+idxID+"] was created")
+		require.Contains(t, err.Error(), "injected execute error")
+		// The peers are quoted since the shell would otherwise split the command at the semi-colon
+		require.Contains(t, err.Error(), "ledgerconfig fileidxupdate --msp Org1MSP --peers '"+peer0+";"+peer1+"' --path /content --idxid "+idxID)
+		require.Contains(t, err.Error(), "file deactivateidx --idxurl http://localhost:80/file/identifiers/"+idxID)
+	})
+
+	t.Run("Namespace mismatch -> partial state", func(t *testing.T) {
+		ch := newChannel(t, map[string]string{peer0: handlerCfg, peer1: mismatchedHandlerCfg})
+		w := &mocks.Writer{}
+
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, newProvider(ch), newTransport(), append(args, "--peers", peer0+";"+peer1, "--noprompt")...)
+		err := c.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "file index ID must begin with [file:xxx:]")
+		require.Contains(t, err.Error(), "--peers '"+peer1+"' ")
+		require.Contains(t, w.Written(), "Updated peers: ["+peer0+"]")
+		require.Equal(t, 1, ch.ExecuteCallCount())
+	})
+
+	t.Run("Channel error", func(t *testing.T) {
+		errExpected := errors.New("channel error")
+		p := func(config *environment.Config) (fabric.Factory, error) { return nil, errExpected }
+
+		err := newMockCmd(t, p, newTransport(), append(args, "--peers", peer0, "--noprompt")...).Execute()
+		require.EqualError(t, err, errExpected.Error())
+	})
+}
+
+// newChannel returns a mock channel that returns the given file handler configuration for each peer
+func newChannel(t *testing.T, configs map[string]string) *mocks.Channel {
+	newKV := func(peerID, cfg string) *ledgercommon.KeyValue {
+		return &ledgercommon.KeyValue{
+			Key: &ledgercommon.Key{
+				MspID:            msp,
+				PeerID:           peerID,
+				AppName:          ledgercommon.FileHandlerAppName,
+				AppVersion:       ledgercommon.FileHandlerAppVersion,
+				ComponentName:    path,
+				ComponentVersion: ledgercommon.FileHandlerComponentVersion,
+			},
+			Value: &ledgercommon.Value{TxID: "tx1", Format: ledgercommon.JSONFormat, Config: cfg},
+		}
+	}
+
+	ch := &mocks.Channel{}
+	ch.QueryStub = func(req channel.Request, _ ...channel.RequestOption) (channel.Response, error) {
+		criteria := &ledgercommon.Criteria{}
+		if err := json.Unmarshal(req.Args[0], criteria); err != nil {
+			return channel.Response{}, err
+		}
+
+		var kvs []*ledgercommon.KeyValue
+
+		for peerID, cfg := range configs {
+			if criteria.PeerID == "" || criteria.PeerID == peerID {
+				kvs = append(kvs, newKV(peerID, cfg))
+			}
+		}
+
+		payload, err := json.Marshal(kvs)
+		require.NoError(t, err)
+
+		return channel.Response{Payload: payload}, nil
+	}
+
+	return ch
+}
+
+func newProvider(ch *mocks.Channel) basecmd.FactoryProvider {
+	factory := &mocks.Factory{}
+	factory.ChannelReturns(ch, nil)
+
+	return func(config *environment.Config) (fabric.Factory, error) { return factory, nil }
+}
+
+func newMockCmd(t *testing.T, p basecmd.FactoryProvider, transport http.RoundTripper, args ...string) *cobra.Command {
+	return newMockCmdWithReaderWriter(t, &mocks.Reader{}, &mocks.Writer{}, p, transport, args...)
+}
+
+func newMockCmdWithReaderWriter(t *testing.T, in io.Reader, w io.Writer, p basecmd.FactoryProvider, transport http.RoundTripper, args ...string) *cobra.Command {
+	settings := environment.NewDefaultSettings()
+	settings.Streams.Out = w
+	settings.Streams.In = in
+
+	settings.Config.CurrentContext = "testctx"
+	settings.Config.Contexts[settings.Config.CurrentContext] = &environment.Context{}
+
+	c := newCmd(settings, p, httpclient.New(httpclient.WithTransport(transport)))
+	require.NotNil(t, c)
+
+	c.SetArgs(args)
+
+	return c
+}
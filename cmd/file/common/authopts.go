@@ -0,0 +1,208 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+)
+
+const (
+	// AuthTokenFlag is the flag that provides the bearer authorization token
+	AuthTokenFlag  = "authtoken"
+	authTokenUsage = "The bearer authorization token that may be required to access the file index and the other HTTP endpoints. Example: --authtoken mytoken" //nolint: gosec
+
+	authTokenFileFlag  = "authtoken-file"
+	authTokenFileUsage = "A file that contains the bearer authorization token. This may be used instead of --authtoken so that the token doesn't appear in the shell history or the process list. Example: --authtoken-file ./secrets/token" //nolint: gosec
+
+	authTokenEnvFlag  = "authtoken-env"
+	authTokenEnvUsage = "The name of the environment variable that contains the bearer authorization token. This may be used instead of --authtoken. Example: --authtoken-env FILE_AUTH_TOKEN" //nolint: gosec
+
+	// ContentAuthTokenFlag is the flag that provides the bearer authorization token of the content endpoint
+	ContentAuthTokenFlag  = "contentauthtoken"
+	contentAuthTokenUsage = "The bearer authorization token to upload files to and retrieve files from the content endpoint. This is only required if it is different from --authtoken. Example: --contentauthtoken mytoken" //nolint: gosec
+
+	contentAuthTokenFileFlag  = "contentauthtoken-file"
+	contentAuthTokenFileUsage = "A file that contains the bearer authorization token of the content endpoint. This may be used instead of --contentauthtoken. Example: --contentauthtoken-file ./secrets/content-token" //nolint: gosec
+
+	contentAuthTokenEnvFlag  = "contentauthtoken-env"
+	contentAuthTokenEnvUsage = "The name of the environment variable that contains the bearer authorization token of the content endpoint. This may be used instead of --contentauthtoken. Example: --contentauthtoken-env FILE_CONTENT_AUTH_TOKEN" //nolint: gosec
+
+	oauth2TokenURLFlag  = "oauth2-token-url"
+	oauth2TokenURLUsage = "The URL of the OAuth2 token endpoint from which bearer tokens are obtained using the client credentials grant. The token is used for all HTTP requests for which an authorization token isn't provided and a new token is obtained if a request is rejected with HTTP status 401. Example: --oauth2-token-url https://auth.example.com/oauth2/token" //nolint: gosec
+
+	oauth2ClientIDFlag  = "oauth2-client-id"
+	oauth2ClientIDUsage = "The OAuth2 client ID (see --oauth2-token-url). Example: --oauth2-client-id fabric-cli"
+
+	oauth2ClientSecretFileFlag  = "oauth2-client-secret-file"
+	oauth2ClientSecretFileUsage = "A file that contains the OAuth2 client secret (see --oauth2-token-url). Example: --oauth2-client-secret-file ./secrets/client-secret" //nolint: gosec
+
+	oauth2ScopesFlag  = "oauth2-scopes"
+	oauth2ScopesUsage = "The comma-separated scopes that are requested for the OAuth2 token (see --oauth2-token-url). Example: --oauth2-scopes file.read,file.write"
+)
+
+// AuthOptions holds the flags that provide the authorization tokens (directly, from a file or from an
+// environment variable) and the flags that configure the OAuth2 client credentials grant
+type AuthOptions struct {
+	Token                  string
+	TokenFile              string
+	TokenEnv               string
+	ContentToken           string
+	ContentTokenFile       string
+	ContentTokenEnv        string
+	OAuth2TokenURL         string
+	OAuth2ClientID         string
+	OAuth2ClientSecretFile string
+	OAuth2Scopes           []string
+}
+
+// AddFlags registers the authorization flags with the given command
+func (o *AuthOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Token, AuthTokenFlag, "", authTokenUsage)
+	cmd.Flags().StringVar(&o.TokenFile, authTokenFileFlag, "", authTokenFileUsage)
+	cmd.Flags().StringVar(&o.TokenEnv, authTokenEnvFlag, "", authTokenEnvUsage)
+	cmd.Flags().StringVar(&o.OAuth2TokenURL, oauth2TokenURLFlag, "", oauth2TokenURLUsage)
+	cmd.Flags().StringVar(&o.OAuth2ClientID, oauth2ClientIDFlag, "", oauth2ClientIDUsage)
+	cmd.Flags().StringVar(&o.OAuth2ClientSecretFile, oauth2ClientSecretFileFlag, "", oauth2ClientSecretFileUsage)
+	cmd.Flags().StringSliceVar(&o.OAuth2Scopes, oauth2ScopesFlag, nil, oauth2ScopesUsage)
+}
+
+// AddContentFlags registers the flags that provide the authorization token of the content endpoint with the
+// given command. This is only required for commands that access the content endpoint.
+func (o *AuthOptions) AddContentFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.ContentToken, ContentAuthTokenFlag, "", contentAuthTokenUsage)
+	cmd.Flags().StringVar(&o.ContentTokenFile, contentAuthTokenFileFlag, "", contentAuthTokenFileUsage)
+	cmd.Flags().StringVar(&o.ContentTokenEnv, contentAuthTokenEnvFlag, "", contentAuthTokenEnvUsage)
+}
+
+// Configure validates the flags and resolves Token and ContentToken from the flags, files or environment
+// variables that provide them. ContentToken defaults to Token. If OAuth2 client credentials are provided then
+// the client obtains tokens from the token endpoint. Clients that cannot be configured (for example, mocks)
+// are left unchanged.
+func (o *AuthOptions) Configure(client interface{}) error {
+	token, err := resolveToken(AuthTokenFlag, o.Token, o.TokenFile, o.TokenEnv)
+	if err != nil {
+		return err
+	}
+
+	contentToken, err := resolveToken(ContentAuthTokenFlag, o.ContentToken, o.ContentTokenFile, o.ContentTokenEnv)
+	if err != nil {
+		return err
+	}
+
+	creds, err := o.clientCredentials()
+	if err != nil {
+		return err
+	}
+
+	if creds != nil && token != "" {
+		return errors.Errorf("an authorization token (--%s, --%s or --%s) must not be provided along with OAuth2 client credentials (--%s)",
+			AuthTokenFlag, authTokenFileFlag, authTokenEnvFlag, oauth2TokenURLFlag)
+	}
+
+	if contentToken == "" {
+		contentToken = token
+	}
+
+	o.Token = token
+	o.ContentToken = contentToken
+
+	if c, ok := client.(ConfigurableHTTPClient); ok && creds != nil {
+		c.Configure(httpclient.WithClientCredentials(*creds))
+	}
+
+	return nil
+}
+
+// resolveToken returns the authorization token, which is taken from the given flag (--<flag>), the file
+// specified by --<flag>-file or the environment variable specified by --<flag>-env
+func resolveToken(flag, token, file, env string) (string, error) {
+	fileFlag := flag + "-file"
+	envFlag := flag + "-env"
+
+	n := 0
+	for _, v := range []string{token, file, env} {
+		if v != "" {
+			n++
+		}
+	}
+
+	if n > 1 {
+		return "", errors.Errorf("only one of --%s, --%s or --%s may be specified", flag, fileFlag, envFlag)
+	}
+
+	switch {
+	case file != "":
+		t, err := readSecretFile(file)
+		if err != nil {
+			return "", errors.WithMessagef(err, "error reading authorization token file (--%s)", fileFlag)
+		}
+
+		return t, nil
+	case env != "":
+		t := strings.TrimSpace(os.Getenv(env))
+		if t == "" {
+			return "", errors.Errorf("the environment variable [%s] (--%s) is not set", env, envFlag)
+		}
+
+		return t, nil
+	default:
+		return token, nil
+	}
+}
+
+func (o *AuthOptions) clientCredentials() (*httpclient.ClientCredentials, error) {
+	if o.OAuth2TokenURL == "" && o.OAuth2ClientID == "" && o.OAuth2ClientSecretFile == "" && len(o.OAuth2Scopes) == 0 {
+		return nil, nil
+	}
+
+	if o.OAuth2TokenURL == "" || o.OAuth2ClientID == "" || o.OAuth2ClientSecretFile == "" {
+		return nil, errors.Errorf("the OAuth2 token URL (--%s), client ID (--%s) and client secret file (--%s) are required for OAuth2",
+			oauth2TokenURLFlag, oauth2ClientIDFlag, oauth2ClientSecretFileFlag)
+	}
+
+	u, err := url.Parse(o.OAuth2TokenURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, errors.Errorf("invalid OAuth2 token URL (--%s) [%s]", oauth2TokenURLFlag, o.OAuth2TokenURL)
+	}
+
+	secret, err := readSecretFile(o.OAuth2ClientSecretFile)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error reading OAuth2 client secret file (--%s)", oauth2ClientSecretFileFlag)
+	}
+
+	return &httpclient.ClientCredentials{
+		TokenURL:     o.OAuth2TokenURL,
+		ClientID:     o.OAuth2ClientID,
+		ClientSecret: secret,
+		Scopes:       o.OAuth2Scopes,
+	}, nil
+}
+
+// readSecretFile reads a secret from the given file. Leading and trailing white space (such as the newline at
+// the end of the file) is removed.
+func readSecretFile(file string) (string, error) {
+	content, err := ioutil.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return "", err
+	}
+
+	secret := strings.TrimSpace(string(content))
+	if secret == "" {
+		return "", errors.Errorf("file [%s] is empty", file)
+	}
+
+	return secret, nil
+}
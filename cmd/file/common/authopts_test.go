@@ -0,0 +1,154 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+)
+
+func TestAuthOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "authopts")
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	writeFile := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(p, []byte(content), 0600))
+
+		return p
+	}
+
+	tokenFile := writeFile("token", "filetoken\n")
+	secretFile := writeFile("secret", "secret1\n")
+	emptyFile := writeFile("empty", "\n")
+
+	const tokenEnv = "AUTHOPTS_TEST_TOKEN"
+
+	require.NoError(t, os.Setenv(tokenEnv, "envtoken"))
+
+	defer func() { require.NoError(t, os.Unsetenv(tokenEnv)) }()
+
+	t.Run("Flags", func(t *testing.T) {
+		o := &AuthOptions{}
+
+		cmd := &cobra.Command{}
+		o.AddFlags(cmd)
+		o.AddContentFlags(cmd)
+
+		require.NoError(t, cmd.ParseFlags([]string{
+			"--authtoken", "mytoken", "--authtoken-file", tokenFile, "--authtoken-env", tokenEnv,
+			"--contentauthtoken", "contenttoken", "--contentauthtoken-file", tokenFile, "--contentauthtoken-env", tokenEnv,
+			"--oauth2-token-url", "https://auth.example.com/token", "--oauth2-client-id", "client1",
+			"--oauth2-client-secret-file", secretFile, "--oauth2-scopes", "file.read,file.write",
+		}))
+
+		require.Equal(t, "mytoken", o.Token)
+		require.Equal(t, tokenFile, o.TokenFile)
+		require.Equal(t, tokenEnv, o.TokenEnv)
+		require.Equal(t, "contenttoken", o.ContentToken)
+		require.Equal(t, tokenFile, o.ContentTokenFile)
+		require.Equal(t, tokenEnv, o.ContentTokenEnv)
+		require.Equal(t, "https://auth.example.com/token", o.OAuth2TokenURL)
+		require.Equal(t, "client1", o.OAuth2ClientID)
+		require.Equal(t, secretFile, o.OAuth2ClientSecretFile)
+		require.Equal(t, []string{"file.read", "file.write"}, o.OAuth2Scopes)
+	})
+
+	t.Run("Token", func(t *testing.T) {
+		tests := []struct {
+			opts                 *AuthOptions
+			expectedToken        string
+			expectedContentToken string
+		}{
+			{&AuthOptions{}, "", ""},
+			{&AuthOptions{Token: "mytoken"}, "mytoken", "mytoken"},
+			{&AuthOptions{TokenFile: tokenFile}, "filetoken", "filetoken"},
+			{&AuthOptions{TokenEnv: tokenEnv}, "envtoken", "envtoken"},
+			{&AuthOptions{Token: "mytoken", ContentToken: "contenttoken"}, "mytoken", "contenttoken"},
+			{&AuthOptions{Token: "mytoken", ContentTokenFile: tokenFile}, "mytoken", "filetoken"},
+			{&AuthOptions{ContentTokenEnv: tokenEnv}, "", "envtoken"},
+		}
+
+		for _, test := range tests {
+			require.NoError(t, test.opts.Configure(httpclient.New()))
+			require.Equal(t, test.expectedToken, test.opts.Token)
+			require.Equal(t, test.expectedContentToken, test.opts.ContentToken)
+		}
+	})
+
+	t.Run("OAuth2", func(t *testing.T) {
+		authServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clientID, secret, ok := r.BasicAuth()
+			require.True(t, ok)
+			require.Equal(t, "client1", clientID)
+			require.Equal(t, "secret1", secret)
+
+			_, err := fmt.Fprint(w, `{"access_token":"oauthtoken","token_type":"bearer"}`)
+			require.NoError(t, err)
+		}))
+		defer authServer.Close()
+
+		var authHeader string
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader = r.Header.Get("Authorization")
+		}))
+		defer server.Close()
+
+		o := &AuthOptions{OAuth2TokenURL: authServer.URL, OAuth2ClientID: "client1", OAuth2ClientSecretFile: secretFile}
+
+		client := httpclient.New()
+
+		require.NoError(t, o.Configure(client))
+		require.Empty(t, o.Token)
+
+		_, err := client.Get(server.URL)
+		require.NoError(t, err)
+		require.Equal(t, "Bearer oauthtoken", authHeader)
+
+		// Clients that can't be configured are ignored
+		require.NoError(t, o.Configure(&struct{}{}))
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		tests := []struct {
+			opts        *AuthOptions
+			expectedErr string
+		}{
+			{&AuthOptions{Token: "mytoken", TokenFile: tokenFile}, "only one of --authtoken, --authtoken-file or --authtoken-env may be specified"},
+			{&AuthOptions{TokenFile: tokenFile, TokenEnv: tokenEnv}, "only one of --authtoken, --authtoken-file or --authtoken-env may be specified"},
+			{&AuthOptions{TokenFile: filepath.Join(dir, "xxx")}, "error reading authorization token file (--authtoken-file)"},
+			{&AuthOptions{TokenFile: emptyFile}, "is empty"},
+			{&AuthOptions{TokenEnv: "AUTHOPTS_TEST_XXX"}, "the environment variable [AUTHOPTS_TEST_XXX] (--authtoken-env) is not set"},
+			{&AuthOptions{ContentToken: "contenttoken", ContentTokenEnv: tokenEnv}, "only one of --contentauthtoken, --contentauthtoken-file or --contentauthtoken-env may be specified"},
+			{&AuthOptions{ContentTokenFile: filepath.Join(dir, "xxx")}, "error reading authorization token file (--contentauthtoken-file)"},
+			{&AuthOptions{ContentTokenEnv: "AUTHOPTS_TEST_XXX"}, "the environment variable [AUTHOPTS_TEST_XXX] (--contentauthtoken-env) is not set"},
+			{&AuthOptions{OAuth2ClientID: "client1"}, "the OAuth2 token URL (--oauth2-token-url), client ID (--oauth2-client-id) and client secret file (--oauth2-client-secret-file) are required"},
+			{&AuthOptions{OAuth2TokenURL: "auth.example.com", OAuth2ClientID: "client1", OAuth2ClientSecretFile: secretFile}, "invalid OAuth2 token URL (--oauth2-token-url) [auth.example.com]"},
+			{&AuthOptions{OAuth2TokenURL: "https://auth.example.com", OAuth2ClientID: "client1", OAuth2ClientSecretFile: filepath.Join(dir, "xxx")}, "error reading OAuth2 client secret file (--oauth2-client-secret-file)"},
+			{&AuthOptions{Token: "mytoken", OAuth2TokenURL: "https://auth.example.com", OAuth2ClientID: "client1", OAuth2ClientSecretFile: secretFile}, "must not be provided along with OAuth2 client credentials"},
+		}
+
+		for _, test := range tests {
+			err := test.opts.Configure(httpclient.New())
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.expectedErr)
+		}
+	})
+}
@@ -0,0 +1,279 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+var (
+	// ErrNoFileExtension indicates that the content type of a file cannot be deduced since it has no extension
+	ErrNoFileExtension = errors.New("content type cannot be deduced since no file extension provided")
+
+	// ErrUnknownExtension indicates that the content type of a file cannot be deduced from its extension
+	ErrUnknownExtension = errors.New("content type cannot be deduced from extension")
+)
+
+// HTTPPoster performs an HTTP POST
+type HTTPPoster interface {
+	Post(url string, req []byte, opts ...httpclient.RequestOpt) (*httpclient.HTTPResponse, error)
+}
+
+// HTTPStreamPoster performs an HTTP POST whose body is streamed from a reader
+type HTTPStreamPoster interface {
+	PostStream(url, contentType string, body io.Reader, opts ...httpclient.RequestOpt) (*httpclient.HTTPResponse, error)
+}
+
+// HTTPHeader performs an HTTP HEAD
+type HTTPHeader interface {
+	Head(url string, opts ...httpclient.RequestOpt) (*httpclient.HTTPResponse, error)
+}
+
+// ContentGetter retrieves files from the content endpoint
+type ContentGetter interface {
+	HTTPGetter
+	HTTPHeader
+}
+
+// File contains the content of a local file along with the name under which it is indexed. If Content is
+// nil then the content is read from Path when the file is uploaded.
+type File struct {
+	Name        string `json:",omitempty"`
+	ID          string `json:",omitempty"`
+	ContentType string `json:",omitempty"`
+	Content     []byte `json:",omitempty"`
+
+	// Path is the path of the local file
+	Path string `json:"-"`
+
+	// Chunks contains the DCAS IDs of the chunks of a file that was uploaded in chunks
+	Chunks []string `json:"-"`
+}
+
+// Files contains a list of files
+type Files []*File
+
+// String returns the files (without their content) as a JSON string
+func (f Files) String() string {
+	ff := make([]*File, len(f))
+	for i, info := range f {
+		ff[i] = &File{
+			Name:        info.Name,
+			ID:          info.ID,
+			ContentType: info.ContentType,
+		}
+	}
+
+	bytes, err := json.Marshal(ff)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(bytes)
+}
+
+// NewFiles returns the given local files with their content type resolved by the given resolver. The content
+// of the files is only read if the content type has to be detected from the content.
+func NewFiles(local []*LocalFile, resolver *ContentTypeResolver) (Files, error) {
+	var files Files
+	for _, l := range local {
+		contentType, err := resolver.Resolve(l.Name, l.Path, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, &File{
+			Name:        l.Name,
+			ContentType: contentType,
+			Path:        l.Path,
+		})
+	}
+
+	return files, nil
+}
+
+// ReadFiles reads the content of the given local files and resolves their content type
+func ReadFiles(local []*LocalFile, resolver *ContentTypeResolver) (Files, error) {
+	var files Files
+	for _, l := range local {
+		f, err := ReadFile(l.Name, l.Path, resolver)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+// ReadFile reads the content of the file at the given path and resolves its content type using the given resolver
+func ReadFile(name, filePath string, resolver *ContentTypeResolver) (*File, error) {
+	content, err := ioutil.ReadFile(filepath.Clean(filePath))
+	if err != nil {
+		return nil, err
+	}
+
+	contentType, err := resolver.Resolve(name, filePath, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{
+		Name:        name,
+		Content:     content,
+		ContentType: contentType,
+		Path:        filePath,
+	}, nil
+}
+
+// ContentTypeFromFileName deduces the content type of a file from its extension
+func ContentTypeFromFileName(fileName string) (string, error) {
+	p := strings.LastIndex(fileName, ".")
+	if p == -1 {
+		return "", ErrNoFileExtension
+	}
+
+	contentType := mime.TypeByExtension(fileName[p:])
+	if contentType == "" {
+		return "", errors.WithMessagef(ErrUnknownExtension, fileName)
+	}
+
+	return contentType, nil
+}
+
+// GetBasePath returns the base path of the given content endpoint URL
+func GetBasePath(contentURL string) (string, error) {
+	u, err := url.Parse(contentURL)
+	if err != nil {
+		return "", errors.WithMessagef(err, "invalid URL [%s]", contentURL)
+	}
+
+	if u.Path == "" {
+		return "", errors.New("invalid URL - no base path found")
+	}
+
+	return u.Path, nil
+}
+
+// UploadContent uploads the given content to the DCAS content endpoint and returns the DCAS ID of the content
+func UploadContent(client HTTPPoster, contentURL, contentType string, content []byte, authToken string) (string, error) {
+	reqBytes, err := json.Marshal(&model.UploadFile{
+		ContentType: contentType,
+		Content:     content,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Post(contentURL, reqBytes, contentRequestOpts(authToken)...)
+	if err != nil {
+		return "", err
+	}
+
+	return getContentID(contentURL, resp)
+}
+
+// UploadStream uploads the content read from the given reader to the DCAS content endpoint as a raw request
+// body with the given content type and returns the DCAS ID of the content. The content is not loaded into memory.
+func UploadStream(client HTTPStreamPoster, contentURL, contentType string, content io.Reader, authToken string) (string, error) {
+	resp, err := client.PostStream(contentURL, contentType, content, contentRequestOpts(authToken)...)
+	if err != nil {
+		return "", err
+	}
+
+	return getContentID(contentURL, resp)
+}
+
+// contentRequestOpts returns the options of a request that uploads content. DCAS content is addressed by its
+// hash so uploading the same content more than once has the same effect, which means that the request may be
+// retried after a transient error.
+func contentRequestOpts(authToken string) []httpclient.RequestOpt {
+	reqOpts := []httpclient.RequestOpt{httpclient.WithIdempotent()}
+	if authToken != "" {
+		reqOpts = append(reqOpts, httpclient.WithAuthToken(authToken))
+	}
+
+	return reqOpts
+}
+
+func getContentID(contentURL string, resp *httpclient.HTTPResponse) (string, error) {
+	if resp.StatusCode != http.StatusOK {
+		return "", NewStatusError("", contentURL, resp, ContentAuthTokenFlag)
+	}
+
+	var fileID string
+	err := json.Unmarshal(resp.Payload, &fileID)
+	if err != nil {
+		return "", err
+	}
+
+	return fileID, nil
+}
+
+// GetManifest returns the manifest of the file with the given name and DCAS ID if the file was uploaded in chunks,
+// otherwise nil. The content type of the file is checked with a HEAD request so that only a manifest is retrieved
+// from the content endpoint.
+func GetManifest(client ContentGetter, contentURL, name, id, authToken string) (*model.FileManifest, error) {
+	var reqOpts []httpclient.RequestOpt
+	if authToken != "" {
+		reqOpts = append(reqOpts, httpclient.WithAuthToken(authToken))
+	}
+
+	fileURL := GetFileURL(contentURL, name)
+
+	resp, err := client.Head(fileURL, reqOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, NewStatusError(fmt.Sprintf("retrieving file [%s]", fileURL), fileURL, resp, ContentAuthTokenFlag)
+	}
+
+	if resp.ContentType != model.ManifestContentType {
+		return nil, nil
+	}
+
+	resp, err = client.Get(fileURL, reqOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, NewStatusError(fmt.Sprintf("retrieving file [%s]", fileURL), fileURL, resp, ContentAuthTokenFlag)
+	}
+
+	computedID, err := GetDCASID(resp.ContentType, resp.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if computedID != id {
+		return nil, errors.Errorf("the DCAS ID of the manifest of file [%s] does not match the ID in the file index: [%s] != [%s]", name, computedID, id)
+	}
+
+	manifest := &model.FileManifest{}
+	if err := json.Unmarshal(resp.Payload, manifest); err != nil {
+		return nil, errors.WithMessagef(err, "invalid manifest for file [%s]", name)
+	}
+
+	return manifest, nil
+}
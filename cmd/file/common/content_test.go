@@ -0,0 +1,79 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+func TestContentTypeFromFileName(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		contentType, err := ContentTypeFromFileName("file.json")
+		require.NoError(t, err)
+		require.Equal(t, "application/json", contentType)
+	})
+
+	t.Run("No extension -> error", func(t *testing.T) {
+		contentType, err := ContentTypeFromFileName("file")
+		require.EqualError(t, err, ErrNoFileExtension.Error())
+		require.Empty(t, contentType)
+	})
+
+	t.Run("No extension -> error", func(t *testing.T) {
+		contentType, err := ContentTypeFromFileName("file.xxx")
+		require.EqualError(t, err, "file.xxx: content type cannot be deduced from extension")
+		require.Empty(t, contentType)
+	})
+}
+
+func TestUploadContent(t *testing.T) {
+	const contentURL = "http://localhost:48326/content"
+
+	newClient := func(status int, payload []byte) *httpclient.Client {
+		return httpclient.New(httpclient.WithTransport(mocks.NewTransport().WithPostResponse(
+			&http.Response{StatusCode: status, Body: mocks.NewResponseBody(payload)},
+		)))
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		id, err := UploadContent(newClient(http.StatusOK, []byte(`"id1"`)), contentURL, "application/json", []byte(`{}`), "mytoken")
+		require.NoError(t, err)
+		require.Equal(t, "id1", id)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		_, err := UploadContent(newClient(http.StatusUnauthorized, []byte("unauthorized")), contentURL, "application/json", []byte(`{}`), "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Did you provide an authorization token (--contentauthtoken)?")
+	})
+
+	t.Run("POST error", func(t *testing.T) {
+		errExpected := errors.New("injected POST error")
+
+		client := httpclient.New(httpclient.WithTransport(mocks.NewTransport().WithPostError(errExpected)))
+
+		_, err := UploadContent(client, contentURL, "application/json", []byte(`{}`), "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+	})
+}
+
+func TestGetBasePath(t *testing.T) {
+	basePath, err := GetBasePath("http://localhost:48326/content")
+	require.NoError(t, err)
+	require.Equal(t, "/content", basePath)
+
+	_, err = GetBasePath("http://localhost:48326")
+	require.EqualError(t, err, "invalid URL - no base path found")
+}
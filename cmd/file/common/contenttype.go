@@ -0,0 +1,261 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// ContentTypeFlag is the flag that overrides the content type of the files whose name matches a pattern
+	ContentTypeFlag  = "content-type"
+	contentTypeUsage = "Sets the content type of the files whose name matches the given pattern, in the form pattern=type. A pattern that contains a slash is matched against the full (relative) name of the file; otherwise it is matched against the file name. The flag may be repeated and the first matching pattern is used. Example: --content-type Dockerfile=text/plain --content-type v1/*.data=application/json"
+
+	// MimeMapFlag is the flag that specifies a local file that maps file extensions to content types
+	MimeMapFlag  = "mime-map"
+	mimeMapUsage = "A local file that maps file extensions to content types. Each line of the file contains a content type followed by one or more extensions (in the same format as /etc/mime.types). Example: --mime-map ./mime.types"
+
+	// JSONContentType is the content type of JSON files
+	JSONContentType = "application/json"
+
+	// JSONLDContentType is the content type of JSON-LD files
+	JSONLDContentType = "application/ld+json"
+
+	// maxJSONSniffSize is the maximum size of a file whose content is parsed in order to detect JSON or JSON-LD.
+	// The content type of larger files is detected from the first 512 bytes only.
+	maxJSONSniffSize = 1024 * 1024
+
+	// sniffLen is the number of bytes that are considered by http.DetectContentType
+	sniffLen = 512
+)
+
+// builtInContentTypes contains the content types of extensions that may not be known to the mime package
+var builtInContentTypes = map[string]string{
+	".json":   JSONContentType,
+	".jsonld": JSONLDContentType,
+}
+
+// ContentTypeOptions holds the flags that control how the content type of a file is resolved
+type ContentTypeOptions struct {
+	Overrides   []string
+	MimeMapFile string
+}
+
+// AddFlags registers the --content-type and --mime-map flags with the given command
+func (o *ContentTypeOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&o.Overrides, ContentTypeFlag, nil, contentTypeUsage)
+	cmd.Flags().StringVar(&o.MimeMapFile, MimeMapFlag, "", mimeMapUsage)
+}
+
+// Resolver returns a content type resolver for the options
+func (o *ContentTypeOptions) Resolver() (*ContentTypeResolver, error) {
+	return NewContentTypeResolver(o.Overrides, o.MimeMapFile)
+}
+
+type contentTypeOverride struct {
+	pattern     string
+	contentType string
+}
+
+// ContentTypeResolver resolves the content type of a file. The content type is taken from (in order):
+// the first override whose pattern matches the name of the file, the mime map file (by extension), the
+// extension of the file and, finally, the content of the file.
+type ContentTypeResolver struct {
+	overrides  []*contentTypeOverride
+	extensions map[string]string
+}
+
+// NewContentTypeResolver returns a content type resolver for the given overrides (in the form pattern=type)
+// and the given (optional) mime map file
+func NewContentTypeResolver(overrides []string, mimeMapFile string) (*ContentTypeResolver, error) {
+	r := &ContentTypeResolver{
+		extensions: make(map[string]string),
+	}
+
+	for _, o := range overrides {
+		override, err := parseContentTypeOverride(o)
+		if err != nil {
+			return nil, err
+		}
+
+		r.overrides = append(r.overrides, override)
+	}
+
+	if mimeMapFile != "" {
+		extensions, err := readMimeMap(mimeMapFile)
+		if err != nil {
+			return nil, err
+		}
+
+		r.extensions = extensions
+	}
+
+	return r, nil
+}
+
+// Resolve returns the content type of the file with the given name. If the content type cannot be deduced from
+// the name then it's detected from the given content or, if the content is nil, from the file at the given path.
+func (r *ContentTypeResolver) Resolve(name, filePath string, content []byte) (string, error) {
+	if contentType, ok := r.fromName(name); ok {
+		return contentType, nil
+	}
+
+	if content != nil {
+		return SniffContentType(content), nil
+	}
+
+	return sniffFile(filePath)
+}
+
+func (r *ContentTypeResolver) fromName(name string) (string, bool) {
+	for _, o := range r.overrides {
+		if matchesAny([]string{o.pattern}, name) {
+			return o.contentType, true
+		}
+	}
+
+	ext := strings.ToLower(path.Ext(name))
+	if ext == "" {
+		return "", false
+	}
+
+	if contentType, ok := r.extensions[ext]; ok {
+		return contentType, true
+	}
+
+	if contentType, ok := builtInContentTypes[ext]; ok {
+		return contentType, true
+	}
+
+	contentType, err := ContentTypeFromFileName(path.Base(name))
+	if err != nil {
+		return "", false
+	}
+
+	return contentType, true
+}
+
+// SniffContentType detects the content type of the given content. JSON content is detected as
+// application/json or, if it's an object with an @context property, as application/ld+json. The content
+// type of any other content is detected using http.DetectContentType.
+func SniffContentType(content []byte) string {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		if trimmed[0] == '{' {
+			var obj map[string]json.RawMessage
+			if err := json.Unmarshal(trimmed, &obj); err == nil {
+				if _, ok := obj["@context"]; ok {
+					return JSONLDContentType
+				}
+			}
+		}
+
+		return JSONContentType
+	}
+
+	return http.DetectContentType(content)
+}
+
+// sniffFile detects the content type of the file at the given path without loading large files into memory
+func sniffFile(filePath string) (contentType string, err error) {
+	file, err := os.Open(filepath.Clean(filePath))
+	if err != nil {
+		return "", err
+	}
+
+	defer closeReadOnly(file, &err)
+
+	content, err := ioutil.ReadAll(io.LimitReader(file, maxJSONSniffSize+1))
+	if err != nil {
+		return "", err
+	}
+
+	if len(content) > maxJSONSniffSize {
+		return http.DetectContentType(content[:sniffLen]), nil
+	}
+
+	return SniffContentType(content), nil
+}
+
+func parseContentTypeOverride(o string) (*contentTypeOverride, error) {
+	p := strings.Index(o, "=")
+	if p <= 0 || p == len(o)-1 {
+		return nil, errors.Errorf("invalid content type (--%s) [%s] - expecting pattern=type", ContentTypeFlag, o)
+	}
+
+	override := &contentTypeOverride{
+		pattern:     strings.TrimSpace(o[:p]),
+		contentType: strings.TrimSpace(o[p+1:]),
+	}
+
+	if _, err := path.Match(override.pattern, ""); err != nil {
+		return nil, errors.WithMessagef(err, "invalid pattern in content type (--%s) [%s]", ContentTypeFlag, o)
+	}
+
+	if _, _, err := mime.ParseMediaType(override.contentType); err != nil {
+		return nil, errors.WithMessagef(err, "invalid type in content type (--%s) [%s]", ContentTypeFlag, o)
+	}
+
+	return override, nil
+}
+
+// readMimeMap reads a file in which each line contains a content type followed by one or more extensions.
+// Empty lines and lines beginning with '#' are ignored.
+func readMimeMap(mimeMapFile string) (extensions map[string]string, err error) {
+	file, err := os.Open(filepath.Clean(mimeMapFile))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error reading mime map (--%s)", MimeMapFlag)
+	}
+
+	defer closeReadOnly(file, &err)
+
+	extensions = make(map[string]string)
+
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, errors.Errorf("invalid mime map (--%s) [%s] at line %d - expecting a content type followed by one or more extensions", MimeMapFlag, mimeMapFile, lineNum)
+		}
+
+		for _, ext := range fields[1:] {
+			extensions["."+strings.ToLower(strings.TrimPrefix(ext, "."))] = fields[0]
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, errors.WithMessagef(err, "error reading mime map (--%s)", MimeMapFlag)
+	}
+
+	return extensions, nil
+}
+
+// closeReadOnly closes a file that was opened for reading and, if no other error occurred, returns the
+// error from closing the file in err
+func closeReadOnly(f *os.File, err *error) {
+	if e := f.Close(); e != nil && *err == nil {
+		*err = e
+	}
+}
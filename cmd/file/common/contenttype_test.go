@@ -0,0 +1,141 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffContentType(t *testing.T) {
+	require.Equal(t, JSONContentType, SniffContentType([]byte(` {"name":"file1"}`)))
+	require.Equal(t, JSONContentType, SniffContentType([]byte(`[{"@context":"https://www.w3.org/ns/did/v1"}]`)))
+	require.Equal(t, JSONLDContentType, SniffContentType([]byte(`{"@context":"https://www.w3.org/ns/did/v1","id":"did:ex:123"}`)))
+	require.Equal(t, "text/plain; charset=utf-8", SniffContentType([]byte(`{"name":`)))
+	require.Equal(t, "text/plain; charset=utf-8", SniffContentType([]byte("FROM golang:1.14\n")))
+	require.Equal(t, "image/png", SniffContentType([]byte("\x89PNG\x0D\x0A\x1A\x0A")))
+}
+
+func TestContentTypeResolver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "contenttype")
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	writeFile := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(p, []byte(content), 0600))
+
+		return p
+	}
+
+	dockerfile := writeFile("Dockerfile", "FROM golang:1.14\n")
+	didDoc := writeFile("did", `{"@context":"https://www.w3.org/ns/did/v1"}`)
+	mimeMap := writeFile("mime.types", "# Custom types\n\ntext/x-dockerfile dockerfile\napplication/yaml .yml YAML\n")
+
+	t.Run("Defaults", func(t *testing.T) {
+		r, err := NewContentTypeResolver(nil, "")
+		require.NoError(t, err)
+
+		contentType, err := r.Resolve("v1/person.schema.json", "", nil)
+		require.NoError(t, err)
+		require.Equal(t, JSONContentType, contentType)
+
+		contentType, err = r.Resolve("context.jsonld", "", nil)
+		require.NoError(t, err)
+		require.Equal(t, JSONLDContentType, contentType)
+
+		contentType, err = r.Resolve("Dockerfile", dockerfile, nil)
+		require.NoError(t, err)
+		require.Equal(t, "text/plain; charset=utf-8", contentType)
+
+		contentType, err = r.Resolve("did", didDoc, nil)
+		require.NoError(t, err)
+		require.Equal(t, JSONLDContentType, contentType)
+
+		contentType, err = r.Resolve("file.xxx", "", []byte(`{}`))
+		require.NoError(t, err)
+		require.Equal(t, JSONContentType, contentType)
+
+		_, err = r.Resolve("file.xxx", filepath.Join(dir, "file.xxx"), nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no such file or directory")
+	})
+
+	t.Run("Overrides", func(t *testing.T) {
+		r, err := NewContentTypeResolver([]string{"Dockerfile=text/x-dockerfile", "v1/*.json=application/schema+json"}, "")
+		require.NoError(t, err)
+
+		contentType, err := r.Resolve("build/Dockerfile", dockerfile, nil)
+		require.NoError(t, err)
+		require.Equal(t, "text/x-dockerfile", contentType)
+
+		contentType, err = r.Resolve("v1/person.schema.json", "", nil)
+		require.NoError(t, err)
+		require.Equal(t, "application/schema+json", contentType)
+
+		contentType, err = r.Resolve("v2/person.schema.json", "", nil)
+		require.NoError(t, err)
+		require.Equal(t, JSONContentType, contentType)
+	})
+
+	t.Run("Mime map", func(t *testing.T) {
+		r, err := NewContentTypeResolver(nil, mimeMap)
+		require.NoError(t, err)
+
+		contentType, err := r.Resolve("config.yml", "", nil)
+		require.NoError(t, err)
+		require.Equal(t, "application/yaml", contentType)
+
+		contentType, err = r.Resolve("config.YAML", "", nil)
+		require.NoError(t, err)
+		require.Equal(t, "application/yaml", contentType)
+
+		contentType, err = r.Resolve("build.dockerfile", "", nil)
+		require.NoError(t, err)
+		require.Equal(t, "text/x-dockerfile", contentType)
+	})
+
+	t.Run("Invalid override", func(t *testing.T) {
+		for _, o := range []string{"Dockerfile", "=text/plain", "Dockerfile=", "[=text/plain", "Dockerfile=text/"} {
+			_, err := NewContentTypeResolver([]string{o}, "")
+			require.Error(t, err, o)
+			require.Contains(t, err.Error(), "--content-type")
+		}
+	})
+
+	t.Run("Invalid mime map", func(t *testing.T) {
+		_, err := NewContentTypeResolver(nil, filepath.Join(dir, "xxx.types"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error reading mime map (--mime-map)")
+
+		_, err = NewContentTypeResolver(nil, writeFile("invalid.types", "text/plain txt\napplication/yaml\n"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "at line 2")
+	})
+}
+
+func TestSniffFile_Large(t *testing.T) {
+	file, err := ioutil.TempFile("", "sniff")
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, os.Remove(file.Name())) }()
+
+	// A large JSON array is not parsed so it's detected as text
+	_, err = file.WriteString("[" + strings.Repeat(`"0123456789",`, maxJSONSniffSize/13+1) + `"0"]`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	contentType, err := sniffFile(file.Name())
+	require.NoError(t, err)
+	require.Equal(t, "text/plain; charset=utf-8", contentType)
+}
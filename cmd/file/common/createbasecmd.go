@@ -0,0 +1,301 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+	"github.com/trustbloc/sidetree-core-go/pkg/versions/1_0/client"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+const (
+	createURLFlag  = "url"
+	createURLUsage = "The URL of the file index Sidetree endpoint. Example: --url http://localhost:48326/file"
+
+	createPathFlag  = "path"
+	createPathUsage = "The base path of the endpoint that will be indexed by this document. Example: --path /schema"
+
+	createRecoveryKeyUsage     = "The public key PEM used for recovery of the document. Example: --recoverykey 'MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEXlp4fWF5rgLthKr20tsJ0tBIE6UmrGuAC8iVG/DaedkSt7HihCx/t2BGjooduaKwEIOmPjx2zBsbkbFrYhhnVw'"
+	createRecoveryKeyFileUsage = "The file that contains the public key PEM used for recovery of the document. Example: --recoverykeyfile ./recovery_public.key"
+
+	createUpdateKeyFlag  = "updatekey"
+	createUpdateKeyUsage = "The public key PEM used for validating the signature of the next update of the document. Example: --updatekey 'MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEFMy2n9jYZChYSjdhK9vUWvPjz9tzBcEa13Ye33haxFsT//3kGxOQhI7yb3MJsDvwLtdfLL6txM3RdOrmLABBvw'"
+
+	createUpdateKeyFileFlag  = "updatekeyfile"
+	createUpdateKeyFileUsage = "The file that contains the public key PEM used for validating the signature of the next update of the document. Example: --updatekeyfile ./update_public.key"
+)
+
+var (
+	// ErrURLRequired indicates that the URL of the file index Sidetree endpoint was not provided
+	ErrURLRequired = errors.New("URL (--url) is required")
+	// ErrPathRequired indicates that the base path was not provided
+	ErrPathRequired = errors.New("path (--path) is required")
+	// ErrInvalidPath indicates that the base path is invalid
+	ErrInvalidPath = errors.New("path (--path) must begin with '/'")
+	// ErrUpdateKeyOrFileRequired indicates that the update key was not provided
+	ErrUpdateKeyOrFileRequired = errors.New("either update key (--updatekey) or key file (--updatekeyfile) is required")
+	// ErrOnlyOneOfUpdateKeyOrFileRequired indicates that the update key was provided more than once
+	ErrOnlyOneOfUpdateKeyOrFileRequired = errors.New("only one of update key (--updatekey) or key file (--updatekeyfile) may be specified")
+)
+
+// CreateHTTPClient performs the HTTP requests that are required to create a file index document
+type CreateHTTPClient interface {
+	HTTPGetter
+	HTTPPoster
+}
+
+// CreateBaseCommand may be used as a base command for commands that create a file index document. It
+// registers the flags for the Sidetree endpoint, the base path, the recovery and update keys, the
+// publish wait options, the authorization and the HTTP client.
+type CreateBaseCommand struct {
+	*basecmd.Command
+	client CreateHTTPClient
+
+	// Flags
+	URL      string
+	Path     string
+	NoPrompt bool
+
+	// Authorization token (resolved by Validate)
+	AuthToken string
+
+	recoveryKeyFile   string
+	recoveryKeyString string
+	updateKeyFile     string
+	updateKeyString   string
+	publishWait       PublishWaitOptions
+	httpOpts          HTTPClientOptions
+	authOpts          AuthOptions
+}
+
+// NewCreateBaseCommand returns a CreateBaseCommand. The factory provider may be nil if the command
+// doesn't access the Fabric network.
+func NewCreateBaseCommand(settings *environment.Settings, p basecmd.FactoryProvider, client CreateHTTPClient, cmd *cobra.Command) *CreateBaseCommand {
+	c := &CreateBaseCommand{
+		Command: basecmd.New(settings, p),
+		client:  client,
+	}
+
+	c.Settings = settings
+	cmd.SetOutput(c.Settings.Streams.Out)
+	cmd.SilenceUsage = true
+
+	cmd.Flags().StringVar(&c.URL, createURLFlag, "", createURLUsage)
+	cmd.Flags().StringVar(&c.Path, createPathFlag, "", createPathUsage)
+	cmd.Flags().StringVar(&c.recoveryKeyString, recoveryKeyFlag, "", createRecoveryKeyUsage)
+	cmd.Flags().StringVar(&c.recoveryKeyFile, recoveryKeyFileFlag, "", createRecoveryKeyFileUsage)
+	cmd.Flags().StringVar(&c.updateKeyString, createUpdateKeyFlag, "", createUpdateKeyUsage)
+	cmd.Flags().StringVar(&c.updateKeyFile, createUpdateKeyFileFlag, "", createUpdateKeyFileUsage)
+	cmd.Flags().BoolVar(&c.NoPrompt, noPromptFlag, false, noPromptUsage)
+	c.publishWait.AddFlags(cmd)
+	c.authOpts.AddFlags(cmd)
+	c.httpOpts.AddFlags(cmd)
+
+	return c
+}
+
+// Validate validates the URL, the base path, the keys, the publish wait options, the HTTP client flags and the authorization flags
+func (c *CreateBaseCommand) Validate() error {
+	if c.URL == "" {
+		return ErrURLRequired
+	}
+
+	if c.Path == "" {
+		return ErrPathRequired
+	}
+
+	if c.Path[0:1] != "/" {
+		return ErrInvalidPath
+	}
+
+	if c.recoveryKeyFile == "" && c.recoveryKeyString == "" {
+		return ErrRecoveryKeyOrFileRequired
+	}
+
+	if c.recoveryKeyFile != "" && c.recoveryKeyString != "" {
+		return ErrOnlyOneOfRecoveryKeyOrFileRequired
+	}
+
+	if c.updateKeyFile == "" && c.updateKeyString == "" {
+		return ErrUpdateKeyOrFileRequired
+	}
+
+	if c.updateKeyFile != "" && c.updateKeyString != "" {
+		return ErrOnlyOneOfUpdateKeyOrFileRequired
+	}
+
+	if err := c.publishWait.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.httpOpts.Configure(c.client, c.Settings.Streams.Err); err != nil {
+		return err
+	}
+
+	if err := c.authOpts.Configure(c.client); err != nil {
+		return err
+	}
+
+	c.AuthToken = c.authOpts.Token
+
+	return nil
+}
+
+// NewCreateRequest returns a Sidetree create request for a file index document with a single entry whose
+// name is '.' and value is the base path. This ensures the uniqueness of the initial document.
+func (c *CreateBaseCommand) NewCreateRequest() ([]byte, error) {
+	docBytes, err := json.Marshal(&model.FileIndexDoc{
+		FileIndex: model.FileIndex{
+			BasePath: c.Path,
+			Mappings: map[string]string{
+				BasePathMapping: c.Path,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := document.FromBytes(docBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	opaqueDoc, err := doc.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryKey, err := c.recoveryPublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCommitment, err := getCommitment(recoveryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	updateKey, err := c.updatePublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	updateCommitment, err := getCommitment(updateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.NewCreateRequest(
+		&client.CreateRequestInfo{
+			OpaqueDocument:     string(opaqueDoc),
+			RecoveryCommitment: recoveryCommitment,
+			UpdateCommitment:   updateCommitment,
+			MultihashCode:      sha2_256,
+		},
+	)
+}
+
+// CreateFileIndex submits the given create request and returns the created document. If --wait-published
+// was specified then the function returns once the document is published.
+func (c *CreateBaseCommand) CreateFileIndex(req []byte) ([]byte, error) {
+	var reqOpts []httpclient.RequestOpt
+	if c.AuthToken != "" {
+		reqOpts = append(reqOpts, httpclient.WithAuthToken(c.AuthToken))
+	}
+
+	resp, err := c.client.Post(c.URL, req, reqOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, NewStatusError("creating file index document", c.URL, resp, AuthTokenFlag)
+	}
+
+	didDocBytes, err := getDIDDocument(resp.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.publishWait.WaitPublished {
+		if err := c.waitForPublished(didDocBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	return didDocBytes, nil
+}
+
+// FileIndexURL returns the URL at which the file index document with the given ID is resolved. The document
+// is resolved at <base>/identifiers/<id> whereas it may have been created at <base>/operations.
+func (c *CreateBaseCommand) FileIndexURL(id string) string {
+	baseURL := strings.TrimSuffix(strings.TrimSuffix(c.URL, "/"), "/operations")
+
+	return fmt.Sprintf("%s/identifiers/%s", baseURL, id)
+}
+
+// waitForPublished waits until the created document can be resolved as a published document
+func (c *CreateBaseCommand) waitForPublished(didDocBytes []byte) error {
+	fileIdxDoc := &model.FileIndexDoc{}
+	if err := json.Unmarshal(didDocBytes, fileIdxDoc); err != nil {
+		return err
+	}
+
+	if fileIdxDoc.ID == "" {
+		return errors.New("the ID of the created file index document was not returned - unable to wait until it is published")
+	}
+
+	_, err := WaitForPublished(c.client, c.FileIndexURL(fileIdxDoc.ID), c.AuthToken, IsPublished, c.publishWait.Timeout, PublishPollInterval)
+
+	return err
+}
+
+func (c *CreateBaseCommand) recoveryPublicKey() (crypto.PublicKey, error) {
+	if c.recoveryKeyFile != "" {
+		return PublicKeyFromFile(c.recoveryKeyFile)
+	}
+
+	return PublicKeyFromPEM([]byte(c.recoveryKeyString))
+}
+
+func (c *CreateBaseCommand) updatePublicKey() (crypto.PublicKey, error) {
+	if c.updateKeyFile != "" {
+		return PublicKeyFromFile(c.updateKeyFile)
+	}
+
+	return PublicKeyFromPEM([]byte(c.updateKeyString))
+}
+
+// getDIDDocument returns the DID document from the given payload which may either be the document
+// itself or a DID resolution result that contains the document
+func getDIDDocument(payload []byte) ([]byte, error) {
+	var r model.DIDResolution
+	if errUnmarshal := json.Unmarshal(payload, &r); errUnmarshal != nil {
+		return nil, fmt.Errorf("unmarshal data return from sidtree %w", errUnmarshal)
+	}
+
+	didDocBytes := payload
+	// check if data is did resolution
+	if len(r.DIDDocument) != 0 {
+		didDocBytes = r.DIDDocument
+	}
+
+	return didDocBytes, nil
+}
@@ -0,0 +1,236 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+// GetDCASID returns the ID under which DCAS stores a file with the given content type and content. The
+// uploaded file (content type and content) is stored as a JSON document and DCAS normalizes JSON documents
+// (by sorting the fields) before hashing, so the same is done here.
+func GetDCASID(contentType string, content []byte) (string, error) {
+	fileBytes, err := json.Marshal(&model.UploadFile{
+		ContentType: contentType,
+		Content:     content,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var m map[string]interface{}
+	if err = json.Unmarshal(fileBytes, &m); err != nil {
+		return "", err
+	}
+
+	normalizedBytes, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(normalizedBytes)
+
+	return base64.URLEncoding.EncodeToString(hash[:]), nil
+}
+
+// DCASHash computes the DCAS ID of content that is written to it, so that the ID of large content may be
+// computed without loading the content into memory. The ID is the same as the ID returned by GetDCASID.
+type DCASHash struct {
+	hash        hash.Hash
+	encoder     io.WriteCloser
+	contentType string
+}
+
+// NewDCASHash returns a DCASHash for content with the given content type
+func NewDCASHash(contentType string) *DCASHash {
+	h := sha256.New()
+
+	// The normalized JSON document has the fields in alphabetical order: {"content":"<base64>","contentType":"<type>"}
+	writeString(h, `{"content":"`)
+
+	return &DCASHash{
+		hash:        h,
+		encoder:     base64.NewEncoder(base64.StdEncoding, h),
+		contentType: contentType,
+	}
+}
+
+// Write adds the given content to the hash
+func (h *DCASHash) Write(p []byte) (int, error) {
+	return h.encoder.Write(p)
+}
+
+// ID returns the DCAS ID of the content that was written. No more content may be written once ID is called.
+func (h *DCASHash) ID() (string, error) {
+	if err := h.encoder.Close(); err != nil {
+		return "", err
+	}
+
+	contentTypeBytes, err := json.Marshal(h.contentType)
+	if err != nil {
+		return "", err
+	}
+
+	writeString(h.hash, `","contentType":`)
+	writeString(h.hash, string(contentTypeBytes))
+	writeString(h.hash, `}`)
+
+	return base64.URLEncoding.EncodeToString(h.hash.Sum(nil)), nil
+}
+
+// FileIDs contains the DCAS IDs computed for a local file
+type FileIDs struct {
+	// ID is the DCAS ID of the file when it is uploaded as a single object
+	ID string
+
+	// Size is the size of the file
+	Size int64
+
+	// ManifestID is the DCAS ID of the manifest that is uploaded in place of the file when the file is
+	// uploaded in chunks. ManifestID is empty if the file is not larger than the chunk size, since such a
+	// file is uploaded as a single object.
+	ManifestID string
+}
+
+// GetFileIDs computes the DCAS IDs of the given file in a single pass, without loading the file into memory
+// (unless the content of the file was already loaded). The manifest ID is computed in the same way as when the
+// file is uploaded in chunks of the given size. If the chunk size is zero then no manifest ID is computed.
+func GetFileIDs(f *File, chunkSize int64) (ids *FileIDs, err error) {
+	var r io.Reader
+	if f.Content != nil {
+		r = bytes.NewReader(f.Content)
+	} else {
+		file, e := os.Open(filepath.Clean(f.Path))
+		if e != nil {
+			return nil, e
+		}
+
+		defer closeReadOnly(file, &err)
+
+		r = file
+	}
+
+	h := NewDCASHash(f.ContentType)
+	chunks := &chunkHash{chunkSize: chunkSize}
+
+	var w io.Writer = h
+	if chunkSize > 0 {
+		w = io.MultiWriter(h, chunks)
+	}
+
+	size, err := io.Copy(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := h.ID()
+	if err != nil {
+		return nil, err
+	}
+
+	if chunkSize <= 0 || size <= chunkSize {
+		return &FileIDs{ID: id, Size: size}, nil
+	}
+
+	chunkIDs, err := chunks.IDs()
+	if err != nil {
+		return nil, err
+	}
+
+	manifestBytes, err := json.Marshal(&model.FileManifest{
+		ContentType: f.ContentType,
+		Size:        size,
+		Chunks:      chunkIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	manifestID, err := GetDCASID(model.ManifestContentType, manifestBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileIDs{ID: id, Size: size, ManifestID: manifestID}, nil
+}
+
+// chunkHash computes the DCAS ID of each chunk of the content that is written to it
+type chunkHash struct {
+	chunkSize int64
+	current   *DCASHash
+	written   int64
+	ids       []string
+}
+
+// Write adds the given content to the current chunk, starting a new chunk whenever the chunk size is reached
+func (h *chunkHash) Write(p []byte) (int, error) {
+	n := len(p)
+
+	for len(p) > 0 {
+		if h.current == nil {
+			h.current = NewDCASHash(ChunkContentType)
+			h.written = 0
+		}
+
+		l := h.chunkSize - h.written
+		if int64(len(p)) < l {
+			l = int64(len(p))
+		}
+
+		if _, err := h.current.Write(p[:l]); err != nil {
+			return 0, err
+		}
+
+		h.written += l
+		p = p[l:]
+
+		if h.written == h.chunkSize {
+			if err := h.endChunk(); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// IDs returns the DCAS IDs of the chunks. No more content may be written once IDs is called.
+func (h *chunkHash) IDs() ([]string, error) {
+	if h.current != nil {
+		if err := h.endChunk(); err != nil {
+			return nil, err
+		}
+	}
+
+	return h.ids, nil
+}
+
+func (h *chunkHash) endChunk() error {
+	id, err := h.current.ID()
+	if err != nil {
+		return err
+	}
+
+	h.ids = append(h.ids, id)
+	h.current = nil
+
+	return nil
+}
+
+// writeString writes the given string to a hash. (Writing to a hash never returns an error.)
+func writeString(h hash.Hash, s string) {
+	_, _ = io.WriteString(h, s)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+func TestGetDCASID(t *testing.T) {
+	content, err := ioutil.ReadFile("../uploadcmd/testdata/person.schema.json")
+	require.NoError(t, err)
+
+	id, err := GetDCASID("application/json", content)
+	require.NoError(t, err)
+	require.Equal(t, "TbVyraOqG00TacPQH5WwWGnxkszpYSEhBKRyX_f25JI=", id)
+
+	id2, err := GetDCASID("text/plain", content)
+	require.NoError(t, err)
+	require.NotEqual(t, id, id2)
+}
+
+func TestDCASHash(t *testing.T) {
+	content, err := ioutil.ReadFile("../uploadcmd/testdata/person.schema.json")
+	require.NoError(t, err)
+
+	for _, contentType := range []string{"application/json", "text/plain", "application/x-<special>&type"} {
+		expectedID, err := GetDCASID(contentType, content)
+		require.NoError(t, err)
+
+		// Write the content in small pieces to exercise the base64 encoder's buffering
+		h := NewDCASHash(contentType)
+		_, err = io.CopyBuffer(h, bytes.NewReader(content), make([]byte, 7))
+		require.NoError(t, err)
+
+		id, err := h.ID()
+		require.NoError(t, err)
+		require.Equal(t, expectedID, id)
+	}
+
+	// Empty content
+	expectedID, err := GetDCASID("text/plain", []byte{})
+	require.NoError(t, err)
+
+	id, err := NewDCASHash("text/plain").ID()
+	require.NoError(t, err)
+	require.Equal(t, expectedID, id)
+}
+
+func TestGetFileIDs(t *testing.T) {
+	const (
+		filePath    = "../uploadcmd/testdata/person.schema.json"
+		contentType = "application/json"
+		chunkSize   = 100
+	)
+
+	content, err := ioutil.ReadFile(filePath)
+	require.NoError(t, err)
+
+	expectedID, err := GetDCASID(contentType, content)
+	require.NoError(t, err)
+
+	manifest := &model.FileManifest{ContentType: contentType, Size: int64(len(content))}
+
+	for i := 0; i < len(content); i += chunkSize {
+		end := i + chunkSize
+		if end > len(content) {
+			end = len(content)
+		}
+
+		chunkID, err := GetDCASID(ChunkContentType, content[i:end])
+		require.NoError(t, err)
+
+		manifest.Chunks = append(manifest.Chunks, chunkID)
+	}
+
+	manifestBytes, err := json.Marshal(manifest)
+	require.NoError(t, err)
+
+	expectedManifestID, err := GetDCASID(model.ManifestContentType, manifestBytes)
+	require.NoError(t, err)
+
+	t.Run("From path", func(t *testing.T) {
+		ids, err := GetFileIDs(&File{Path: filePath, ContentType: contentType}, chunkSize)
+		require.NoError(t, err)
+		require.Equal(t, expectedID, ids.ID)
+		require.Equal(t, expectedManifestID, ids.ManifestID)
+	})
+
+	t.Run("From content", func(t *testing.T) {
+		ids, err := GetFileIDs(&File{Content: content, ContentType: contentType}, chunkSize)
+		require.NoError(t, err)
+		require.Equal(t, expectedID, ids.ID)
+		require.Equal(t, expectedManifestID, ids.ManifestID)
+	})
+
+	t.Run("Not larger than chunk size", func(t *testing.T) {
+		ids, err := GetFileIDs(&File{Path: filePath, ContentType: contentType}, int64(len(content)))
+		require.NoError(t, err)
+		require.Equal(t, expectedID, ids.ID)
+		require.Empty(t, ids.ManifestID)
+	})
+
+	t.Run("File not found", func(t *testing.T) {
+		_, err := GetFileIDs(&File{Path: "./testdata/missing.json", ContentType: contentType}, chunkSize)
+		require.Error(t, err)
+	})
+}
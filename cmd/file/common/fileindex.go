@@ -0,0 +1,164 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+const (
+	// BasePathMapping is the name of the mapping that is added to every file index document when it is
+	// created. It maps to the base path of the file index and does not refer to a file.
+	BasePathMapping = "."
+
+	// ChunkMappingPrefix is the prefix of the mappings of the chunks of files that were uploaded in chunks.
+	// The chunks are indexed so that they may be retrieved from the content endpoint.
+	ChunkMappingPrefix = ".chunks/"
+)
+
+// ChunkMappingName returns the name under which the chunk with the given DCAS ID is indexed
+func ChunkMappingName(id string) string {
+	return ChunkMappingPrefix + id
+}
+
+// IsReservedMapping returns true if the given mapping name does not refer to a file that was uploaded by
+// the user, i.e. it is either the base path mapping or the mapping of a chunk
+func IsReservedMapping(name string) bool {
+	return name == BasePathMapping || strings.HasPrefix(name, ChunkMappingPrefix)
+}
+
+// HTTPGetter performs an HTTP GET
+type HTTPGetter interface {
+	Get(url string, opts ...httpclient.RequestOpt) (*httpclient.HTTPResponse, error)
+}
+
+// GetFileIndexDoc retrieves the file index document at the given URL. The response may either be the
+// document itself or a DID resolution result that contains the document.
+func GetFileIndexDoc(client HTTPGetter, fileIndexURL, authToken string) (*model.FileIndexDoc, error) {
+	fileIdxDoc, _, err := ResolveFileIndexDoc(client, fileIndexURL, authToken)
+
+	return fileIdxDoc, err
+}
+
+// ResolveFileIndexDoc retrieves the file index document at the given URL along with the DID resolution result.
+// If the response is the document itself (and not a DID resolution result) then the returned resolution is nil.
+func ResolveFileIndexDoc(client HTTPGetter, fileIndexURL, authToken string) (*model.FileIndexDoc, *model.DIDResolution, error) {
+	var reqOpts []httpclient.RequestOpt
+	if authToken != "" {
+		reqOpts = append(reqOpts, httpclient.WithAuthToken(authToken))
+	}
+
+	resp, err := client.Get(fileIndexURL, reqOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err := NewStatusError(fmt.Sprintf("retrieving file index document [%s]", fileIndexURL), fileIndexURL, resp, AuthTokenFlag)
+
+		if errors.Is(err, httpclient.ErrNotFound) {
+			return nil, nil, &docNotFoundError{url: fileIndexURL, cause: err}
+		}
+
+		return nil, nil, err
+	}
+
+	var r model.DIDResolution
+	if errUnmarshal := json.Unmarshal(resp.Payload, &r); errUnmarshal != nil {
+		return nil, nil, fmt.Errorf("unmarshal data return from sidtree %w", errUnmarshal)
+	}
+
+	didDocBytes := resp.Payload
+	resolution := &r
+
+	// check if data is did resolution
+	if len(r.DIDDocument) != 0 {
+		didDocBytes = r.DIDDocument
+	} else {
+		resolution = nil
+	}
+
+	fileIdxDoc := &model.FileIndexDoc{}
+	err = json.Unmarshal(didDocBytes, fileIdxDoc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return fileIdxDoc, resolution, nil
+}
+
+// docNotFoundError indicates that the file index document at the given URL was not found
+type docNotFoundError struct {
+	url   string
+	cause error
+}
+
+func (e *docNotFoundError) Error() string {
+	return fmt.Sprintf("file index document [%s] not found", e.url)
+}
+
+// Unwrap returns the *httpclient.StatusError so that the error matches httpclient.ErrNotFound
+func (e *docNotFoundError) Unwrap() error {
+	return e.cause
+}
+
+// IsFileIndexDocNotFound returns true if the given error indicates that the file index document was not found
+func IsFileIndexDocNotFound(err error) bool {
+	_, ok := errors.Cause(err).(*docNotFoundError)
+
+	return ok
+}
+
+// GetMethodMetadata returns the method metadata from the given DID resolution. Nil is returned if
+// the resolution doesn't contain method metadata.
+func GetMethodMetadata(r *model.DIDResolution) (*model.MethodMetadata, error) {
+	if r == nil || len(r.MethodMetadata) == 0 {
+		return nil, nil
+	}
+
+	metadata := &model.MethodMetadata{}
+	if err := json.Unmarshal(r.MethodMetadata, metadata); err != nil {
+		return nil, errors.WithMessage(err, "invalid method metadata")
+	}
+
+	return metadata, nil
+}
+
+// GetContentURL returns the URL of the content endpoint that serves the files of a file index. The URL is
+// derived from the scheme and host of the file index URL along with the base path of the file index.
+func GetContentURL(fileIndexURL, basePath string) (string, error) {
+	u, err := url.Parse(fileIndexURL)
+	if err != nil {
+		return "", errors.WithMessagef(err, "invalid file index URL [%s]", fileIndexURL)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return "", errors.Errorf("unable to derive the content URL from file index URL [%s] - please provide the content URL (--url)", fileIndexURL)
+	}
+
+	return fmt.Sprintf("%s://%s%s", u.Scheme, u.Host, basePath), nil
+}
+
+// GetFileURL returns the URL of the file with the given name at the given content endpoint. The name may
+// contain slashes, in which case each segment of the name is escaped separately.
+func GetFileURL(contentURL, name string) string {
+	segments := strings.Split(name, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(contentURL, "/"), strings.Join(segments, "/"))
+}
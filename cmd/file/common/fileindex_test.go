@@ -0,0 +1,166 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+const idxURL = "http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+
+func TestGetFileIndexDoc(t *testing.T) {
+	header := map[string][]string{"Content-Type": {"application/json"}}
+
+	fileIdxDoc := &model.FileIndexDoc{
+		ID:        "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==",
+		FileIndex: model.FileIndex{BasePath: "/content", Mappings: map[string]string{"file1.json": "id1"}},
+	}
+
+	fileIdxDocBytes, err := json.Marshal(fileIdxDoc)
+	require.NoError(t, err)
+
+	didResolutionBytes, err := json.Marshal(model.DIDResolution{DIDDocument: fileIdxDocBytes})
+	require.NoError(t, err)
+
+	newClient := func(status int, payload []byte) *httpclient.Client {
+		return httpclient.New(httpclient.WithTransport(mocks.NewTransport().WithGetResponse(
+			&http.Response{StatusCode: status, Header: header, Body: mocks.NewResponseBody(payload)},
+		)))
+	}
+
+	t.Run("DID resolution", func(t *testing.T) {
+		doc, err := GetFileIndexDoc(newClient(http.StatusOK, didResolutionBytes), idxURL, "mytoken")
+		require.NoError(t, err)
+		require.Equal(t, fileIdxDoc, doc)
+	})
+
+	t.Run("Document", func(t *testing.T) {
+		doc, err := GetFileIndexDoc(newClient(http.StatusOK, fileIdxDocBytes), idxURL, "")
+		require.NoError(t, err)
+		require.Equal(t, fileIdxDoc, doc)
+	})
+
+	t.Run("Invalid response", func(t *testing.T) {
+		_, err := GetFileIndexDoc(newClient(http.StatusOK, []byte("{")), idxURL, "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal data return from sidtree")
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		_, err := GetFileIndexDoc(newClient(http.StatusNotFound, []byte("not found")), idxURL, "")
+		require.EqualError(t, err, "file index document ["+idxURL+"] not found")
+		require.True(t, IsFileIndexDocNotFound(err))
+		require.True(t, errors.Is(err, httpclient.ErrNotFound))
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		_, err := GetFileIndexDoc(newClient(http.StatusUnauthorized, []byte("unauthorized")), idxURL, "")
+		require.Error(t, err)
+		require.EqualError(t, err, "error retrieving file index document ["+idxURL+"]. Status code 401: unauthorized - Did you provide an authorization token (--authtoken)?")
+		require.True(t, errors.Is(err, httpclient.ErrUnauthorized))
+		require.False(t, errors.Is(err, httpclient.ErrNotFound))
+
+		statusErr := &httpclient.StatusError{}
+		require.True(t, errors.As(err, &statusErr))
+		require.Equal(t, http.StatusUnauthorized, statusErr.StatusCode)
+		require.Equal(t, idxURL, statusErr.Endpoint)
+		require.Equal(t, "unauthorized", statusErr.Body)
+	})
+
+	t.Run("Server error", func(t *testing.T) {
+		_, err := GetFileIndexDoc(newClient(http.StatusInternalServerError, []byte("server error")), idxURL, "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Status code 500: server error")
+	})
+
+	t.Run("GET error", func(t *testing.T) {
+		errExpected := errors.New("injected GET error")
+		client := httpclient.New(httpclient.WithTransport(mocks.NewTransport().WithGetError(errExpected)))
+
+		_, err := GetFileIndexDoc(client, idxURL, "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+	})
+}
+
+func TestResolveFileIndexDoc(t *testing.T) {
+	fileIdxDocBytes, err := json.Marshal(&model.FileIndexDoc{ID: "file:idx:1234"})
+	require.NoError(t, err)
+
+	didResolutionBytes, err := json.Marshal(model.DIDResolution{
+		DIDDocument:    fileIdxDocBytes,
+		MethodMetadata: []byte(`{"published":true,"updateCommitment":"uc1","recoveryCommitment":"rc1"}`),
+	})
+	require.NoError(t, err)
+
+	newClient := func(payload []byte) *httpclient.Client {
+		return httpclient.New(httpclient.WithTransport(mocks.NewTransport().WithGetResponse(
+			&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody(payload)},
+		)))
+	}
+
+	t.Run("DID resolution", func(t *testing.T) {
+		doc, r, err := ResolveFileIndexDoc(newClient(didResolutionBytes), idxURL, "")
+		require.NoError(t, err)
+		require.Equal(t, "file:idx:1234", doc.ID)
+		require.NotNil(t, r)
+
+		metadata, err := GetMethodMetadata(r)
+		require.NoError(t, err)
+		require.Equal(t, &model.MethodMetadata{Published: true, UpdateCommitment: "uc1", RecoveryCommitment: "rc1"}, metadata)
+	})
+
+	t.Run("Document", func(t *testing.T) {
+		doc, r, err := ResolveFileIndexDoc(newClient(fileIdxDocBytes), idxURL, "")
+		require.NoError(t, err)
+		require.Equal(t, "file:idx:1234", doc.ID)
+		require.Nil(t, r)
+
+		metadata, err := GetMethodMetadata(r)
+		require.NoError(t, err)
+		require.Nil(t, metadata)
+	})
+
+	t.Run("Invalid method metadata", func(t *testing.T) {
+		_, err := GetMethodMetadata(&model.DIDResolution{MethodMetadata: []byte(`{"published":"x"}`)})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid method metadata")
+	})
+}
+
+func TestGetContentURL(t *testing.T) {
+	u, err := GetContentURL(idxURL, "/content")
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:48326/content", u)
+
+	_, err = GetContentURL("file:idx:1234", "/content")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "please provide the content URL (--url)")
+
+	_, err = GetContentURL("http://local host", "/content")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid file index URL")
+}
+
+func TestGetFileURL(t *testing.T) {
+	require.Equal(t, "http://localhost:48326/content/v1/my%20file.json", GetFileURL("http://localhost:48326/content/", "v1/my file.json"))
+}
+
+func TestIsReservedMapping(t *testing.T) {
+	require.True(t, IsReservedMapping(BasePathMapping))
+	require.True(t, IsReservedMapping(ChunkMappingName("id")))
+	require.False(t, IsReservedMapping("v1/file.json"))
+}
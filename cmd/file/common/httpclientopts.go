@@ -0,0 +1,176 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"io"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+)
+
+const (
+	tlsCACertFlag  = "tls-cacert"
+	tlsCACertUsage = "The PEM file(s) that contain the CA certificates used to verify the certificate of the HTTP endpoints (in addition to the system CAs). The flag may be repeated or contain a comma-separated list of files. Example: --tls-cacert ./tls/ca.crt"
+
+	tlsCertFlag  = "tls-cert"
+	tlsCertUsage = "The PEM file that contains the client certificate that is presented to HTTP endpoints that require mutual TLS. Example: --tls-cert ./tls/client.crt"
+
+	tlsKeyFlag  = "tls-key"
+	tlsKeyUsage = "The PEM file that contains the private key of the client certificate (--tls-cert). Example: --tls-key ./tls/client.key"
+
+	tlsInsecureSkipVerifyFlag  = "tls-insecure-skip-verify"
+	tlsInsecureSkipVerifyUsage = "If specified then the certificates of the HTTP endpoints are not verified. This should only be used for development. Example: --tls-insecure-skip-verify"
+
+	httpTimeoutFlag  = "http-timeout"
+	httpTimeoutUsage = "The time limit for each HTTP request, including reading the response. Zero means no limit. Example: --http-timeout 30s"
+
+	proxyFlag  = "proxy"
+	proxyUsage = "The URL of the proxy through which HTTP requests are sent. If not specified then the proxy is taken from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables. Example: --proxy http://proxy.example.com:3128"
+
+	httpRetriesFlag  = "http-retries"
+	httpRetriesUsage = "The number of times that an HTTP request is retried after a transient error (HTTP status 429, 502, 503 or 504 or a connection reset). Only requests that may safely be sent more than once are retried; Sidetree operations are only retried if the connection to the server could not be established. Example: --http-retries 5"
+
+	httpRetryBackoffFlag  = "http-retry-backoff"
+	httpRetryBackoffUsage = "The time to wait before the first retry of an HTTP request. The time is doubled (with a random jitter) for each subsequent retry. Example: --http-retry-backoff 1s"
+
+	httpRetryMaxBackoffFlag  = "http-retry-max-backoff"
+	httpRetryMaxBackoffUsage = "The maximum time to wait between retries of an HTTP request. A request is not retried if the server asks (using the Retry-After header) to wait for longer than this. Example: --http-retry-max-backoff 1m"
+
+	defaultHTTPRetries = 3
+
+	debugFlag  = "debug"
+	debugUsage = "If specified then the method, URL, status and headers of every HTTP request and response are written to stderr. Authorization headers and other values that may contain secrets are redacted. Example: --debug"
+
+	headerFlag  = "header"
+	headerUsage = "A header, in the form 'Name: value', that is sent with every HTTP request. The flag may be repeated. Example: --header 'X-Api-Key: mykey'"
+)
+
+// ConfigurableHTTPClient is an HTTP client that may be configured after it was created
+type ConfigurableHTTPClient interface {
+	Configure(opts ...httpclient.Opt)
+}
+
+// HTTPClientOptions holds the flags that configure the TLS settings, timeout, retries, proxy, headers and debug output of the HTTP client
+type HTTPClientOptions struct {
+	CACertFiles        []string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+	Retries            int
+	RetryBackoff       time.Duration
+	RetryMaxBackoff    time.Duration
+	Proxy              string
+	Headers            []string
+	Debug              bool
+}
+
+// AddFlags registers the HTTP client flags with the given command
+func (o *HTTPClientOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&o.CACertFiles, tlsCACertFlag, nil, tlsCACertUsage)
+	cmd.Flags().StringVar(&o.CertFile, tlsCertFlag, "", tlsCertUsage)
+	cmd.Flags().StringVar(&o.KeyFile, tlsKeyFlag, "", tlsKeyUsage)
+	cmd.Flags().BoolVar(&o.InsecureSkipVerify, tlsInsecureSkipVerifyFlag, false, tlsInsecureSkipVerifyUsage)
+	cmd.Flags().DurationVar(&o.Timeout, httpTimeoutFlag, 0, httpTimeoutUsage)
+	cmd.Flags().IntVar(&o.Retries, httpRetriesFlag, defaultHTTPRetries, httpRetriesUsage)
+	cmd.Flags().DurationVar(&o.RetryBackoff, httpRetryBackoffFlag, httpclient.DefaultInitialBackoff, httpRetryBackoffUsage)
+	cmd.Flags().DurationVar(&o.RetryMaxBackoff, httpRetryMaxBackoffFlag, httpclient.DefaultMaxBackoff, httpRetryMaxBackoffUsage)
+	cmd.Flags().StringVar(&o.Proxy, proxyFlag, "", proxyUsage)
+	cmd.Flags().StringArrayVar(&o.Headers, headerFlag, nil, headerUsage)
+	cmd.Flags().BoolVar(&o.Debug, debugFlag, false, debugUsage)
+}
+
+// Configure validates the flags and applies them to the given client. Retries (and, if --debug is specified,
+// the metadata of all requests and responses) are logged to the given writer. Clients that cannot be configured
+// (for example, mocks) are left unchanged.
+func (o *HTTPClientOptions) Configure(client interface{}, log io.Writer) error {
+	opts, err := o.ClientOpts()
+	if err != nil {
+		return err
+	}
+
+	if o.Debug {
+		opts = append(opts, httpclient.WithDebug(log))
+	}
+
+	if c, ok := client.(ConfigurableHTTPClient); ok && len(opts) > 0 {
+		c.Configure(append(opts, httpclient.WithLogger(log))...)
+	}
+
+	return nil
+}
+
+// ClientOpts validates the flags and returns the corresponding HTTP client options
+func (o *HTTPClientOptions) ClientOpts() ([]httpclient.Opt, error) {
+	if o.Timeout < 0 {
+		return nil, errors.Errorf("invalid HTTP timeout (--%s) [%s] - the timeout must not be negative", httpTimeoutFlag, o.Timeout)
+	}
+
+	if o.Retries < 0 {
+		return nil, errors.Errorf("invalid number of HTTP retries (--%s) [%d] - the number of retries must not be negative", httpRetriesFlag, o.Retries)
+	}
+
+	if o.Retries > 0 && (o.RetryBackoff <= 0 || o.RetryMaxBackoff < o.RetryBackoff) {
+		return nil, errors.Errorf("invalid HTTP retry backoff (--%s, --%s) [%s, %s] - the backoff must be positive and must not exceed the maximum backoff",
+			httpRetryBackoffFlag, httpRetryMaxBackoffFlag, o.RetryBackoff, o.RetryMaxBackoff)
+	}
+
+	var opts []httpclient.Opt
+
+	if o.Retries > 0 {
+		opts = append(opts, httpclient.WithRetryPolicy(httpclient.RetryPolicy{
+			MaxAttempts:    o.Retries + 1,
+			InitialBackoff: o.RetryBackoff,
+			MaxBackoff:     o.RetryMaxBackoff,
+		}))
+	}
+
+	if len(o.CACertFiles) > 0 || o.CertFile != "" || o.KeyFile != "" || o.InsecureSkipVerify {
+		tlsConfig, err := httpclient.NewTLSConfig(o.CACertFiles, o.CertFile, o.KeyFile, o.InsecureSkipVerify)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid TLS options (--%s, --%s, --%s)", tlsCACertFlag, tlsCertFlag, tlsKeyFlag)
+		}
+
+		opts = append(opts, httpclient.WithTLSConfig(tlsConfig))
+	}
+
+	if o.Timeout > 0 {
+		opts = append(opts, httpclient.WithTimeout(o.Timeout))
+	}
+
+	if o.Proxy != "" {
+		proxyURL, err := url.Parse(o.Proxy)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, errors.Errorf("invalid proxy URL (--%s) [%s]", proxyFlag, o.Proxy)
+		}
+
+		opts = append(opts, httpclient.WithProxy(proxyURL))
+	}
+
+	for _, h := range o.Headers {
+		p := strings.Index(h, ":")
+		if p < 0 {
+			return nil, errors.Errorf("invalid header (--%s) [%s] - expecting 'Name: value'", headerFlag, h)
+		}
+
+		name := strings.TrimSpace(h[:p])
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return nil, errors.Errorf("invalid header (--%s) [%s] - expecting 'Name: value'", headerFlag, h)
+		}
+
+		opts = append(opts, httpclient.WithHeader(textproto.CanonicalMIMEHeaderKey(name), strings.TrimSpace(h[p+1:])))
+	}
+
+	return opts, nil
+}
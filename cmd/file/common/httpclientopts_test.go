@@ -0,0 +1,117 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+func TestHTTPClientOptions(t *testing.T) {
+	t.Run("Flags", func(t *testing.T) {
+		o := &HTTPClientOptions{}
+
+		cmd := &cobra.Command{}
+		o.AddFlags(cmd)
+
+		require.NoError(t, cmd.ParseFlags([]string{
+			"--tls-cacert", "./ca1.crt,./ca2.crt", "--tls-cacert", "./ca3.crt", "--tls-insecure-skip-verify",
+			"--http-timeout", "30s", "--http-retries", "5", "--http-retry-backoff", "1s", "--proxy", "http://proxy.example.com:3128",
+			"--header", "X-Api-Key: mykey", "--header", "x-gateway-id:gw1", "--debug",
+		}))
+
+		require.Equal(t, []string{"./ca1.crt", "./ca2.crt", "./ca3.crt"}, o.CACertFiles)
+		require.True(t, o.InsecureSkipVerify)
+		require.Equal(t, 30*time.Second, o.Timeout)
+		require.Equal(t, 5, o.Retries)
+		require.Equal(t, time.Second, o.RetryBackoff)
+		require.Equal(t, httpclient.DefaultMaxBackoff, o.RetryMaxBackoff)
+		require.Equal(t, "http://proxy.example.com:3128", o.Proxy)
+		require.Equal(t, []string{"X-Api-Key: mykey", "x-gateway-id:gw1"}, o.Headers)
+		require.True(t, o.Debug)
+	})
+
+	t.Run("No options", func(t *testing.T) {
+		opts, err := (&HTTPClientOptions{}).ClientOpts()
+		require.NoError(t, err)
+		require.Empty(t, opts)
+	})
+
+	t.Run("Valid options", func(t *testing.T) {
+		o := &HTTPClientOptions{
+			InsecureSkipVerify: true,
+			Timeout:            time.Second,
+			Retries:            2,
+			RetryBackoff:       time.Second,
+			RetryMaxBackoff:    time.Minute,
+			Proxy:              "http://proxy.example.com:3128",
+			Headers:            []string{"X-Api-Key: mykey", "x-gateway-id:gw1"},
+		}
+
+		opts, err := o.ClientOpts()
+		require.NoError(t, err)
+		require.Len(t, opts, 6)
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		tests := []struct {
+			opts        *HTTPClientOptions
+			expectedErr string
+		}{
+			{&HTTPClientOptions{Timeout: -time.Second}, "invalid HTTP timeout (--http-timeout) [-1s]"},
+			{&HTTPClientOptions{Retries: -1}, "invalid number of HTTP retries (--http-retries) [-1]"},
+			{&HTTPClientOptions{Retries: 1}, "invalid HTTP retry backoff (--http-retry-backoff, --http-retry-max-backoff) [0s, 0s]"},
+			{&HTTPClientOptions{Retries: 1, RetryBackoff: time.Minute, RetryMaxBackoff: time.Second}, "invalid HTTP retry backoff"},
+			{&HTTPClientOptions{CACertFiles: []string{"./xxx.crt"}}, "invalid TLS options"},
+			{&HTTPClientOptions{KeyFile: "./client.key"}, "both the client certificate and the client key must be provided"},
+			{&HTTPClientOptions{Proxy: "proxy.example.com"}, "invalid proxy URL (--proxy) [proxy.example.com]"},
+			{&HTTPClientOptions{Headers: []string{"X-Api-Key"}}, "invalid header (--header) [X-Api-Key]"},
+			{&HTTPClientOptions{Headers: []string{": mykey"}}, "invalid header (--header) [: mykey]"},
+			{&HTTPClientOptions{Headers: []string{"X Api Key: mykey"}}, "invalid header (--header) [X Api Key: mykey]"},
+		}
+
+		for _, test := range tests {
+			_, err := test.opts.ClientOpts()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.expectedErr)
+
+			require.Error(t, test.opts.Configure(httpclient.New(), ioutil.Discard))
+		}
+	})
+
+	t.Run("Configure", func(t *testing.T) {
+		transport := mocks.NewTransport().WithGetResponse(&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody([]byte("{}"))})
+		client := httpclient.New(httpclient.WithTransport(transport))
+
+		o := &HTTPClientOptions{Timeout: time.Second, Headers: []string{"X-Api-Key: mykey"}}
+		require.NoError(t, o.Configure(client, ioutil.Discard))
+
+		w := &mocks.Writer{}
+
+		o = &HTTPClientOptions{Headers: []string{"X-Api-Key: mykey"}, Debug: true}
+		require.NoError(t, o.Configure(client, w))
+
+		_, err := client.Get("http://localhost:80/file", httpclient.WithAuthToken("mytoken"))
+		require.NoError(t, err)
+		require.Contains(t, w.Written(), "> GET http://localhost:80/file")
+		require.Contains(t, w.Written(), "> Authorization: Bearer <redacted>")
+		require.Contains(t, w.Written(), "> X-Api-Key: <redacted>")
+		require.NotContains(t, w.Written(), "mytoken")
+		require.NotContains(t, w.Written(), "mykey")
+
+		// Clients that can't be configured are ignored
+		require.NoError(t, o.Configure(&struct{}{}, ioutil.Discard))
+	})
+}
@@ -0,0 +1,416 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/pkg/errors"
+)
+
+const (
+	privateKeyPerm os.FileMode = 0600
+	publicKeyPerm  os.FileMode = 0644
+)
+
+var (
+	// ErrPrivateKeyNotFoundInPEM indicates that the PEM does not contain a private key
+	ErrPrivateKeyNotFoundInPEM = errors.New("private key not found in PEM")
+
+	// ErrPublicKeyNotFoundInPEM indicates that the PEM does not contain a public key
+	ErrPublicKeyNotFoundInPEM = errors.New("public key not found in PEM")
+)
+
+// KeyType is the type of a key that is used to sign Sidetree operations
+type KeyType string
+
+const (
+	// KeyTypeP256 is an EC key on the P-256 curve (ES256)
+	KeyTypeP256 KeyType = "P-256"
+	// KeyTypeSecp256k1 is an EC key on the secp256k1 curve (ES256K)
+	KeyTypeSecp256k1 KeyType = "secp256k1"
+	// KeyTypeEd25519 is an Ed25519 key (EdDSA)
+	KeyTypeEd25519 KeyType = "Ed25519"
+)
+
+var (
+	oidPublicKeyECDSA      = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidNamedCurveSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// ecPrivateKey is the SEC 1 (RFC 5915) structure of an EC private key
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// pkcs8 is the PKCS #8 (RFC 5208) structure of a private key
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// publicKeyInfo is the PKIX (RFC 5280) structure of a public key
+type publicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// KeyTypes returns the supported key types
+func KeyTypes() []KeyType {
+	return []KeyType{KeyTypeP256, KeyTypeSecp256k1, KeyTypeEd25519}
+}
+
+// GetKeyType returns the type of the given private or public key
+func GetKeyType(key interface{}) (KeyType, error) {
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		return GetKeyType(&k.PublicKey)
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return KeyTypeP256, nil
+		case btcec.S256():
+			return KeyTypeSecp256k1, nil
+		default:
+			return "", errors.Errorf("unsupported elliptic curve [%s]", k.Curve.Params().Name)
+		}
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return KeyTypeEd25519, nil
+	default:
+		return "", errors.Errorf("unsupported key type [%T]", key)
+	}
+}
+
+// PublicKeyFromFile reads the public key PEM from the given file
+func PublicKeyFromFile(file string) (crypto.PublicKey, error) {
+	keyBytes, err := ioutil.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, err
+	}
+
+	return PublicKeyFromPEM(keyBytes)
+}
+
+// PublicKeyFromPEM parses the public key from the given PEM. The PEM must contain a PKIX public key
+// of one of the supported key types.
+func PublicKeyFromPEM(pubKeyPEM []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(pubKeyPEM)
+	if block == nil {
+		return nil, ErrPublicKeyNotFoundInPEM
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		// The x509 package doesn't support the secp256k1 curve
+		secp256k1Key, e := parseSecp256k1PublicKey(block.Bytes)
+		if e != nil {
+			return nil, err
+		}
+
+		return secp256k1Key, nil
+	}
+
+	if _, err := GetKeyType(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// PrivateKeyFromFile reads the private key PEM from the given file
+func PrivateKeyFromFile(file string) (crypto.PrivateKey, error) {
+	keyBytes, err := ioutil.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, err
+	}
+
+	return PrivateKeyFromPEM(keyBytes)
+}
+
+// PrivateKeyFromPEM parses the private key from the given PEM. The PEM may contain either a PKCS #8 or
+// a SEC 1 private key of one of the supported key types.
+func PrivateKeyFromPEM(privateKeyPEM []byte) (crypto.PrivateKey, error) {
+	privBlock, _ := pem.Decode(privateKeyPEM)
+	if privBlock == nil {
+		return nil, ErrPrivateKeyNotFoundInPEM
+	}
+
+	privKey, err := x509.ParsePKCS8PrivateKey(privBlock.Bytes)
+	if err == nil {
+		if _, err := GetKeyType(privKey); err != nil {
+			return nil, err
+		}
+
+		return privKey, nil
+	}
+
+	ecKey, err := x509.ParseECPrivateKey(privBlock.Bytes)
+	if err != nil {
+		// The x509 package doesn't support the secp256k1 curve
+		secp256k1Key, e := parseSecp256k1PrivateKey(privBlock.Bytes)
+		if e != nil {
+			return nil, err
+		}
+
+		return secp256k1Key, nil
+	}
+
+	return ecKey, nil
+}
+
+// GenerateKey generates a new EC P-256 private key
+func GenerateKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+// GenerateKeyOfType generates a new private key of the given type
+func GenerateKeyOfType(keyType KeyType) (crypto.PrivateKey, error) {
+	switch keyType {
+	case KeyTypeP256:
+		return GenerateKey()
+	case KeyTypeSecp256k1:
+		key, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			return nil, err
+		}
+
+		return key.ToECDSA(), nil
+	case KeyTypeEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		return key, nil
+	default:
+		return nil, errors.Errorf("unsupported key type [%s]", keyType)
+	}
+}
+
+// PublicKey returns the public key of the given private key
+func PublicKey(privateKey crypto.PrivateKey) (crypto.PublicKey, error) {
+	switch key := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		return &key.PublicKey, nil
+	case ed25519.PrivateKey:
+		return key.Public(), nil
+	default:
+		return nil, errors.Errorf("unsupported key type [%T]", privateKey)
+	}
+}
+
+// PrivateKeyToPEM encodes the given private key as a PEM. EC keys are encoded as SEC 1 private keys
+// and Ed25519 keys are encoded as PKCS #8 private keys.
+func PrivateKeyToPEM(privateKey crypto.PrivateKey) ([]byte, error) {
+	keyType, err := GetKeyType(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyBytes []byte
+
+	switch keyType {
+	case KeyTypeP256:
+		keyBytes, err = x509.MarshalECPrivateKey(privateKey.(*ecdsa.PrivateKey))
+	case KeyTypeSecp256k1:
+		keyBytes, err = marshalSecp256k1PrivateKey(privateKey.(*ecdsa.PrivateKey))
+	default:
+		keyBytes, err = x509.MarshalPKCS8PrivateKey(privateKey)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}), nil
+}
+
+// PublicKeyToPEM encodes the given public key as a PEM
+func PublicKeyToPEM(publicKey crypto.PublicKey) ([]byte, error) {
+	keyType, err := GetKeyType(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyBytes []byte
+
+	if keyType == KeyTypeSecp256k1 {
+		keyBytes, err = marshalSecp256k1PublicKey(publicKey.(*ecdsa.PublicKey))
+	} else {
+		keyBytes, err = x509.MarshalPKIXPublicKey(publicKey)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: keyBytes}), nil
+}
+
+// WriteKeyPair writes the PEMs of the given private key and its public key to the given files. The private key
+// file is only readable by the owner.
+func WriteKeyPair(privateKey crypto.PrivateKey, privateKeyFile, publicKeyFile string) error {
+	privateKeyPEM, err := PrivateKeyToPEM(privateKey)
+	if err != nil {
+		return err
+	}
+
+	publicKey, err := PublicKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	publicKeyPEM, err := PublicKeyToPEM(publicKey)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(privateKeyFile, privateKeyPEM, privateKeyPerm); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(publicKeyFile, publicKeyPEM, publicKeyPerm)
+}
+
+// writeFileAtomic writes the given data to a temporary file in the same directory as the given file and then
+// renames the temporary file so that the file is either completely written or left untouched
+func writeFileAtomic(file string, data []byte, perm os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	tmpName := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(perm)
+	}
+
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+
+	if err == nil {
+		err = os.Rename(tmpName, file)
+	}
+
+	if err != nil {
+		if e := os.Remove(tmpName); e != nil && !os.IsNotExist(e) {
+			return errors.WithMessagef(err, "error removing temporary file [%s]: %s", tmpName, e)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func parseSecp256k1PrivateKey(der []byte) (*ecdsa.PrivateKey, error) {
+	var p8 pkcs8
+	if _, err := asn1.Unmarshal(der, &p8); err == nil {
+		if !p8.Algo.Algorithm.Equal(oidPublicKeyECDSA) {
+			return nil, errors.New("not an EC private key")
+		}
+
+		var curveOID asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(p8.Algo.Parameters.FullBytes, &curveOID); err != nil {
+			return nil, err
+		}
+
+		if !curveOID.Equal(oidNamedCurveSecp256k1) {
+			return nil, errors.New("not a secp256k1 private key")
+		}
+
+		der = p8.PrivateKey
+	}
+
+	var key ecPrivateKey
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, err
+	}
+
+	// The curve is omitted from the SEC 1 structure if it is embedded in a PKCS #8 structure
+	if len(key.NamedCurveOID) > 0 && !key.NamedCurveOID.Equal(oidNamedCurveSecp256k1) {
+		return nil, errors.New("not a secp256k1 private key")
+	}
+
+	privateKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), key.PrivateKey)
+
+	return privateKey.ToECDSA(), nil
+}
+
+func parseSecp256k1PublicKey(der []byte) (*ecdsa.PublicKey, error) {
+	var info publicKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+
+	if !info.Algorithm.Algorithm.Equal(oidPublicKeyECDSA) {
+		return nil, errors.New("not an EC public key")
+	}
+
+	var curveOID asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &curveOID); err != nil {
+		return nil, err
+	}
+
+	if !curveOID.Equal(oidNamedCurveSecp256k1) {
+		return nil, errors.New("not a secp256k1 public key")
+	}
+
+	publicKey, err := btcec.ParsePubKey(info.PublicKey.RightAlign(), btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+
+	return publicKey.ToECDSA(), nil
+}
+
+func marshalSecp256k1PrivateKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	publicKeyBytes := (*btcec.PublicKey)(&key.PublicKey).SerializeUncompressed()
+
+	return asn1.Marshal(ecPrivateKey{
+		Version:       1,
+		PrivateKey:    (*btcec.PrivateKey)(key).Serialize(),
+		NamedCurveOID: oidNamedCurveSecp256k1,
+		PublicKey:     asn1.BitString{Bytes: publicKeyBytes, BitLength: 8 * len(publicKeyBytes)},
+	})
+}
+
+func marshalSecp256k1PublicKey(key *ecdsa.PublicKey) ([]byte, error) {
+	curveOID, err := asn1.Marshal(oidNamedCurveSecp256k1)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKeyBytes := (*btcec.PublicKey)(key).SerializeUncompressed()
+
+	return asn1.Marshal(publicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPublicKeyECDSA,
+			Parameters: asn1.RawValue{FullBytes: curveOID},
+		},
+		PublicKey: asn1.BitString{Bytes: publicKeyBytes, BitLength: 8 * len(publicKeyBytes)},
+	})
+}
@@ -0,0 +1,168 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyTypes(t *testing.T) {
+	for _, keyType := range KeyTypes() {
+		t.Run(string(keyType), func(t *testing.T) {
+			privateKey, err := GenerateKeyOfType(keyType)
+			require.NoError(t, err)
+
+			kt, err := GetKeyType(privateKey)
+			require.NoError(t, err)
+			require.Equal(t, keyType, kt)
+
+			publicKey, err := PublicKey(privateKey)
+			require.NoError(t, err)
+
+			kt, err = GetKeyType(publicKey)
+			require.NoError(t, err)
+			require.Equal(t, keyType, kt)
+
+			privateKeyPEM, err := PrivateKeyToPEM(privateKey)
+			require.NoError(t, err)
+
+			pk, err := PrivateKeyFromPEM(privateKeyPEM)
+			require.NoError(t, err)
+			require.Equal(t, privateKey, pk)
+
+			publicKeyPEM, err := PublicKeyToPEM(publicKey)
+			require.NoError(t, err)
+
+			pub, err := PublicKeyFromPEM(publicKeyPEM)
+			require.NoError(t, err)
+			require.Equal(t, publicKey, pub)
+		})
+	}
+
+	t.Run("Unsupported key type", func(t *testing.T) {
+		_, err := GenerateKeyOfType("RSA")
+		require.EqualError(t, err, "unsupported key type [RSA]")
+
+		p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err)
+
+		_, err = GetKeyType(p384Key)
+		require.EqualError(t, err, "unsupported elliptic curve [P-384]")
+
+		_, err = PrivateKeyToPEM(p384Key)
+		require.Error(t, err)
+
+		_, err = NewSigner(p384Key)
+		require.Error(t, err)
+
+		_, err = PublicKey("key")
+		require.EqualError(t, err, "unsupported key type [string]")
+
+		keyBytes, err := x509.MarshalPKIXPublicKey(&p384Key.PublicKey)
+		require.NoError(t, err)
+
+		_, err = PublicKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: keyBytes}))
+		require.EqualError(t, err, "unsupported elliptic curve [P-384]")
+	})
+}
+
+func TestPrivateKeyFromPEM_PKCS8(t *testing.T) {
+	t.Run("P-256", func(t *testing.T) {
+		key, err := GenerateKey()
+		require.NoError(t, err)
+
+		keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+
+		pk, err := PrivateKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}))
+		require.NoError(t, err)
+		require.Equal(t, key, pk)
+	})
+
+	t.Run("secp256k1", func(t *testing.T) {
+		key, err := GenerateKeyOfType(KeyTypeSecp256k1)
+		require.NoError(t, err)
+
+		ecKeyBytes, err := asn1.Marshal(ecPrivateKey{
+			Version:    1,
+			PrivateKey: (*btcec.PrivateKey)(key.(*ecdsa.PrivateKey)).Serialize(),
+		})
+		require.NoError(t, err)
+
+		curveOID, err := asn1.Marshal(oidNamedCurveSecp256k1)
+		require.NoError(t, err)
+
+		keyBytes, err := asn1.Marshal(pkcs8{
+			Algo: pkix.AlgorithmIdentifier{
+				Algorithm:  oidPublicKeyECDSA,
+				Parameters: asn1.RawValue{FullBytes: curveOID},
+			},
+			PrivateKey: ecKeyBytes,
+		})
+		require.NoError(t, err)
+
+		pk, err := PrivateKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}))
+		require.NoError(t, err)
+		require.Equal(t, key, pk)
+	})
+
+	t.Run("Invalid key", func(t *testing.T) {
+		_, err := PrivateKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("invalid")}))
+		require.Error(t, err)
+	})
+}
+
+func TestNewSigner(t *testing.T) {
+	nextKey, err := PublicKeyFromPEM([]byte(nextUpdateKey))
+	require.NoError(t, err)
+
+	for keyType, alg := range map[KeyType]string{
+		KeyTypeP256:      AlgorithmES256,
+		KeyTypeSecp256k1: AlgorithmES256K,
+		KeyTypeEd25519:   AlgorithmEdDSA,
+	} {
+		t.Run(string(keyType), func(t *testing.T) {
+			key, err := GenerateKeyOfType(keyType)
+			require.NoError(t, err)
+
+			signer, err := NewSigner(key)
+			require.NoError(t, err)
+			require.Equal(t, alg, signer.Headers()["alg"])
+
+			reqBytes, err := NewUpdateRequest(idxURL, []JSONPatch{{Op: JSONPatchRemoveOp, Path: MappingPath("file1.json")}}, key, nextKey)
+			require.NoError(t, err)
+
+			req := make(map[string]interface{})
+			require.NoError(t, json.Unmarshal(reqBytes, &req))
+
+			signedData, ok := req["signedData"].(string)
+			require.True(t, ok)
+
+			headerBytes, err := base64.RawURLEncoding.DecodeString(strings.Split(signedData, ".")[0])
+			require.NoError(t, err)
+			require.Contains(t, string(headerBytes), `"alg":"`+alg+`"`)
+		})
+	}
+
+	t.Run("Unsupported key type", func(t *testing.T) {
+		_, err := SigningAlgorithm("RSA")
+		require.EqualError(t, err, "unsupported key type [RSA]")
+	})
+}
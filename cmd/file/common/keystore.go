@@ -0,0 +1,216 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	updateKeyFile           = "update.key"
+	updatePublicKeyFile     = "update_public.key"
+	nextUpdateKeyFile       = "next_update.key"
+	nextUpdatePublicKeyFile = "next_update_public.key"
+
+	pendingUpdateKeyFile       = "pending_update.key"
+	pendingUpdatePublicKeyFile = "pending_update_public.key"
+
+	keyStoreDirPerm os.FileMode = 0700
+)
+
+// ErrKeysNotFound indicates that the key store does not contain the keys of a file index document
+var ErrKeysNotFound = errors.New("keys not found in key store")
+
+// KeyStore is a local directory that holds the keys used to update file index documents. The keys of each
+// document are stored in a sub-directory that is named after the unique suffix of the document. The
+// sub-directory contains the current update key (update.key) and the pre-generated next update key
+// (next_update.key) along with their public keys. A key that is committed to by an operation that is being
+// submitted (for example, a recover) is held as the pending update key (pending_update.key) until the operation
+// succeeds.
+type KeyStore struct {
+	dir string
+}
+
+// NewKeyStore returns a key store that is backed by the given directory
+func NewKeyStore(dir string) *KeyStore {
+	return &KeyStore{dir: dir}
+}
+
+// Dir returns the directory of the keys of the given document
+func (s *KeyStore) Dir(uniqueSuffix string) (string, error) {
+	if uniqueSuffix == "" || uniqueSuffix == "." || uniqueSuffix == ".." || strings.ContainsAny(uniqueSuffix, `/\`) {
+		return "", errors.Errorf("invalid unique suffix [%s]", uniqueSuffix)
+	}
+
+	return filepath.Join(s.dir, uniqueSuffix), nil
+}
+
+// Import stores the given key as the current update key of the given document and generates
+// the next update key. Any keys that were previously stored for the document are replaced.
+func (s *KeyStore) Import(uniqueSuffix string, updateKey crypto.PrivateKey) error {
+	dir, err := s.Dir(uniqueSuffix)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, keyStoreDirPerm); err != nil {
+		return err
+	}
+
+	if err := WriteKeyPair(updateKey, filepath.Join(dir, updateKeyFile), filepath.Join(dir, updatePublicKeyFile)); err != nil {
+		return err
+	}
+
+	_, err = s.generateNextUpdateKey(dir, updateKey)
+
+	return err
+}
+
+// Get returns the current update key and the next update key of the given document. ErrKeysNotFound is
+// returned if the key store does not contain the keys of the document. If the next update key is missing
+// (for example, if a previous rotation was interrupted) then a new next update key is generated.
+func (s *KeyStore) Get(uniqueSuffix string) (updateKey, nextUpdateKey crypto.PrivateKey, err error) {
+	dir, err := s.Dir(uniqueSuffix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updateKey, err = PrivateKeyFromFile(filepath.Join(dir, updateKeyFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, errors.WithMessagef(ErrKeysNotFound, "file index [%s]", uniqueSuffix)
+		}
+
+		return nil, nil, err
+	}
+
+	nextUpdateKey, err = PrivateKeyFromFile(filepath.Join(dir, nextUpdateKeyFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, nil, err
+		}
+
+		nextUpdateKey, err = s.generateNextUpdateKey(dir, updateKey)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return updateKey, nextUpdateKey, nil
+}
+
+// Rotate promotes the next update key of the given document to the current update key and generates a
+// new next update key. This function should be called after the document was successfully updated with
+// a commitment to the next update key.
+func (s *KeyStore) Rotate(uniqueSuffix string) error {
+	return s.promote(uniqueSuffix, nextUpdateKeyFile, nextUpdatePublicKeyFile, "next update key")
+}
+
+// SetPendingUpdateKey stores the given key as the pending update key of the given document. The key must be
+// stored before an operation that commits to it is submitted so that the key isn't lost if the operation
+// succeeds but the key can't be promoted. An error is returned if the document already has a pending update
+// key since the operation that committed to that key may have succeeded.
+func (s *KeyStore) SetPendingUpdateKey(uniqueSuffix string, updateKey crypto.PrivateKey) error {
+	dir, err := s.Dir(uniqueSuffix)
+	if err != nil {
+		return err
+	}
+
+	keyFile := filepath.Join(dir, pendingUpdateKeyFile)
+
+	if _, err := os.Stat(keyFile); err == nil {
+		return errors.Errorf("file index [%s] already has a pending update key [%s] - a previous operation may have been interrupted. If the operation succeeded then import the key using 'file keys import', otherwise remove the key", uniqueSuffix, keyFile)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, keyStoreDirPerm); err != nil {
+		return err
+	}
+
+	return WriteKeyPair(updateKey, keyFile, filepath.Join(dir, pendingUpdatePublicKeyFile))
+}
+
+// PromotePendingUpdateKey promotes the pending update key of the given document to the current update key and
+// generates a new next update key. This function should be called after the operation that commits to the
+// pending update key succeeded.
+func (s *KeyStore) PromotePendingUpdateKey(uniqueSuffix string) error {
+	return s.promote(uniqueSuffix, pendingUpdateKeyFile, pendingUpdatePublicKeyFile, "pending update key")
+}
+
+// DiscardPendingUpdateKey removes the pending update key of the given document. This function should be called
+// if the operation that commits to the pending update key was rejected.
+func (s *KeyStore) DiscardPendingUpdateKey(uniqueSuffix string) error {
+	dir, err := s.Dir(uniqueSuffix)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range []string{pendingUpdateKeyFile, pendingUpdatePublicKeyFile} {
+		if err := os.Remove(filepath.Join(dir, f)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// promote moves the given key (and its public key) to the current update key of the given document and
+// generates a new next update key
+func (s *KeyStore) promote(uniqueSuffix, keyFile, publicKeyFile, desc string) error {
+	dir, err := s.Dir(uniqueSuffix)
+	if err != nil {
+		return err
+	}
+
+	// The rename is atomic so the current update key is either the old key or the promoted key
+	err = os.Rename(filepath.Join(dir, keyFile), filepath.Join(dir, updateKeyFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.WithMessagef(ErrKeysNotFound, "%s of file index [%s]", desc, uniqueSuffix)
+		}
+
+		return err
+	}
+
+	if err := os.Rename(filepath.Join(dir, publicKeyFile), filepath.Join(dir, updatePublicKeyFile)); err != nil {
+		return err
+	}
+
+	updateKey, err := PrivateKeyFromFile(filepath.Join(dir, updateKeyFile))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.generateNextUpdateKey(dir, updateKey)
+
+	return err
+}
+
+// generateNextUpdateKey generates a next update key of the same type as the given update key
+func (s *KeyStore) generateNextUpdateKey(dir string, updateKey crypto.PrivateKey) (crypto.PrivateKey, error) {
+	keyType, err := GetKeyType(updateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nextUpdateKey, err := GenerateKeyOfType(keyType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := WriteKeyPair(nextUpdateKey, filepath.Join(dir, nextUpdateKeyFile), filepath.Join(dir, nextUpdatePublicKeyFile)); err != nil {
+		return nil, err
+	}
+
+	return nextUpdateKey, nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+const uniqueSuffix = "EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+
+func TestWriteKeyPair(t *testing.T) {
+	dir := newTempDir(t)
+	defer removeTempDir(t, dir)
+
+	key, err := GenerateKey()
+	require.NoError(t, err)
+
+	privateKeyFile := filepath.Join(dir, "update.key")
+	publicKeyFile := filepath.Join(dir, "update_public.key")
+
+	require.NoError(t, WriteKeyPair(key, privateKeyFile, publicKeyFile))
+
+	info, err := os.Stat(privateKeyFile)
+	require.NoError(t, err)
+	require.Equal(t, privateKeyPerm, info.Mode().Perm())
+
+	privateKey, err := PrivateKeyFromFile(privateKeyFile)
+	require.NoError(t, err)
+	require.Equal(t, key, privateKey)
+
+	publicKey, err := PublicKeyFromFile(publicKeyFile)
+	require.NoError(t, err)
+	require.Equal(t, &key.PublicKey, publicKey)
+
+	err = WriteKeyPair(key, filepath.Join(dir, "xxx", "update.key"), publicKeyFile)
+	require.Error(t, err)
+}
+
+func TestKeyStore(t *testing.T) {
+	dir := newTempDir(t)
+	defer removeTempDir(t, dir)
+
+	s := NewKeyStore(dir)
+
+	t.Run("Keys not found", func(t *testing.T) {
+		_, _, err := s.Get(uniqueSuffix)
+		require.True(t, errors.Cause(err) == ErrKeysNotFound)
+
+		err = s.Rotate(uniqueSuffix)
+		require.True(t, errors.Cause(err) == ErrKeysNotFound)
+	})
+
+	t.Run("Invalid unique suffix", func(t *testing.T) {
+		_, _, err := s.Get("../x")
+		require.EqualError(t, err, "invalid unique suffix [../x]")
+
+		require.Error(t, s.Import("..", nil))
+		require.Error(t, s.Rotate(""))
+	})
+
+	updateKey, err := GenerateKey()
+	require.NoError(t, err)
+
+	require.NoError(t, s.Import(uniqueSuffix, updateKey))
+
+	current, next, err := s.Get(uniqueSuffix)
+	require.NoError(t, err)
+	require.Equal(t, updateKey, current)
+	require.NotEqual(t, updateKey, next)
+
+	t.Run("Rotate", func(t *testing.T) {
+		require.NoError(t, s.Rotate(uniqueSuffix))
+
+		rotatedCurrent, rotatedNext, err := s.Get(uniqueSuffix)
+		require.NoError(t, err)
+		require.Equal(t, next, rotatedCurrent)
+		require.NotEqual(t, next, rotatedNext)
+
+		publicKey, err := PublicKeyFromFile(filepath.Join(dir, uniqueSuffix, updatePublicKeyFile))
+		require.NoError(t, err)
+		require.Equal(t, &next.(*ecdsa.PrivateKey).PublicKey, publicKey)
+
+		current = rotatedCurrent
+	})
+
+	t.Run("Pending update key", func(t *testing.T) {
+		pendingKey, err := GenerateKeyOfType(KeyTypeP256)
+		require.NoError(t, err)
+
+		require.NoError(t, s.SetPendingUpdateKey(uniqueSuffix, pendingKey))
+
+		// The current and next update keys are unchanged until the pending key is promoted
+		c, _, err := s.Get(uniqueSuffix)
+		require.NoError(t, err)
+		require.Equal(t, current, c)
+
+		err = s.SetPendingUpdateKey(uniqueSuffix, pendingKey)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "already has a pending update key")
+
+		require.NoError(t, s.PromotePendingUpdateKey(uniqueSuffix))
+
+		c, n, err := s.Get(uniqueSuffix)
+		require.NoError(t, err)
+		require.Equal(t, pendingKey, c)
+		require.NotEqual(t, pendingKey, n)
+
+		err = s.PromotePendingUpdateKey(uniqueSuffix)
+		require.True(t, errors.Cause(err) == ErrKeysNotFound)
+
+		current = c
+	})
+
+	t.Run("Discard pending update key", func(t *testing.T) {
+		pendingKey, err := GenerateKeyOfType(KeyTypeP256)
+		require.NoError(t, err)
+
+		require.NoError(t, s.SetPendingUpdateKey(uniqueSuffix, pendingKey))
+		require.NoError(t, s.DiscardPendingUpdateKey(uniqueSuffix))
+		require.NoError(t, s.DiscardPendingUpdateKey(uniqueSuffix))
+
+		_, err = os.Stat(filepath.Join(dir, uniqueSuffix, pendingUpdateKeyFile))
+		require.True(t, os.IsNotExist(err))
+
+		c, _, err := s.Get(uniqueSuffix)
+		require.NoError(t, err)
+		require.Equal(t, current, c)
+	})
+
+	t.Run("Next update key missing", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(dir, uniqueSuffix, nextUpdateKeyFile)))
+
+		c, n, err := s.Get(uniqueSuffix)
+		require.NoError(t, err)
+		require.Equal(t, current, c)
+		require.NotNil(t, n)
+	})
+
+	t.Run("Ed25519 update key", func(t *testing.T) {
+		const edSuffix = "ed25519"
+
+		edKey, err := GenerateKeyOfType(KeyTypeEd25519)
+		require.NoError(t, err)
+
+		require.NoError(t, s.Import(edSuffix, edKey))
+		require.NoError(t, s.Rotate(edSuffix))
+
+		c, n, err := s.Get(edSuffix)
+		require.NoError(t, err)
+		require.NotEqual(t, edKey, c)
+
+		// The next update keys are generated with the same type as the imported key
+		for _, key := range []interface{}{c, n} {
+			keyType, err := GetKeyType(key)
+			require.NoError(t, err)
+			require.Equal(t, KeyTypeEd25519, keyType)
+		}
+	})
+}
+
+func newTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+
+	return dir
+}
+
+func removeTempDir(t *testing.T, dir string) {
+	require.NoError(t, os.RemoveAll(dir))
+}
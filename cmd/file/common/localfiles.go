@@ -0,0 +1,206 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoFilesMatched indicates that none of the local files matched the given paths and patterns
+var ErrNoFilesMatched = errors.New("no files to upload")
+
+// LocalFile contains the path of a local file along with the name under which it is indexed
+type LocalFile struct {
+	Name string
+	Path string
+}
+
+// GetLocalFiles returns the files specified by the given semicolon-separated paths (each of which may be a
+// glob pattern) and the files in the given directory. Only files that match the comma-separated include
+// patterns and do not match the exclude patterns are returned. An error is returned if two files would be
+// indexed under the same name.
+func GetLocalFiles(paths, dir, include, exclude string) ([]*LocalFile, error) {
+	filter, err := NewFileFilter(include, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*LocalFile
+
+	if paths != "" {
+		expanded, e := expandFiles(paths)
+		if e != nil {
+			return nil, e
+		}
+
+		candidates = append(candidates, expanded...)
+	}
+
+	if dir != "" {
+		walked, e := walkDir(dir)
+		if e != nil {
+			return nil, e
+		}
+
+		candidates = append(candidates, walked...)
+	}
+
+	var files []*LocalFile
+	names := make(map[string]string)
+
+	for _, f := range candidates {
+		if !filter.Matches(f.Name) {
+			continue
+		}
+
+		if p, ok := names[f.Name]; ok {
+			return nil, errors.Errorf("files [%s] and [%s] would both be indexed as [%s]", p, f.Path, f.Name)
+		}
+
+		names[f.Name] = f.Path
+		files = append(files, f)
+	}
+
+	if len(files) == 0 {
+		return nil, ErrNoFilesMatched
+	}
+
+	return files, nil
+}
+
+// FileFilter includes or excludes files according to their name in the file index
+type FileFilter struct {
+	include []string
+	exclude []string
+}
+
+// NewFileFilter returns a filter for the given comma-separated include and exclude patterns
+func NewFileFilter(include, exclude string) (*FileFilter, error) {
+	f := &FileFilter{
+		include: splitPatterns(include),
+		exclude: splitPatterns(exclude),
+	}
+
+	// Validate the patterns up front so that a bad pattern isn't silently treated as a non-match
+	for _, pattern := range append(append([]string{}, f.include...), f.exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.WithMessagef(err, "invalid pattern [%s]", pattern)
+		}
+	}
+
+	return f, nil
+}
+
+// Matches returns true if the file with the given name should be uploaded. A pattern that contains
+// a slash is matched against the full (relative) name; otherwise it is matched against the base name.
+func (f *FileFilter) Matches(name string) bool {
+	if len(f.include) > 0 && !matchesAny(f.include, name) {
+		return false
+	}
+
+	return !matchesAny(f.exclude, name)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		target := name
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(name)
+		}
+
+		if ok, _ := path.Match(pattern, target); ok { //nolint: errcheck
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitPatterns(patterns string) []string {
+	var result []string
+	for _, p := range strings.Split(patterns, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			result = append(result, p)
+		}
+	}
+
+	return result
+}
+
+// expandFiles returns the files for the given semicolon-separated list of paths, each of which may be a
+// glob pattern. Files specified in this way are indexed by their base name.
+func expandFiles(paths string) ([]*LocalFile, error) {
+	var files []*LocalFile
+
+	for _, p := range strings.Split(paths, ";") {
+		if p == "" {
+			continue
+		}
+
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid file pattern [%s]", p)
+		}
+
+		if len(matches) == 0 {
+			if isPattern(p) {
+				return nil, errors.Errorf("no files match pattern [%s]", p)
+			}
+
+			// Not a pattern so let the read of the file report the error
+			matches = []string{p}
+		}
+
+		for _, m := range matches {
+			files = append(files, &LocalFile{Name: filepath.Base(m), Path: m})
+		}
+	}
+
+	return files, nil
+}
+
+// walkDir returns all of the regular files under the given directory. The files are indexed by their path
+// relative to the directory, using forward slashes as the separator.
+func walkDir(dir string) ([]*LocalFile, error) {
+	var files []*LocalFile
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, &LocalFile{Name: filepath.ToSlash(rel), Path: p})
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error reading directory [%s]", dir)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	return files, nil
+}
+
+func isPattern(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
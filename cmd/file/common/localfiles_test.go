@@ -0,0 +1,60 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileFilter(t *testing.T) {
+	f, err := NewFileFilter("*.json, v1/*", "v2/*")
+	require.NoError(t, err)
+
+	require.True(t, f.Matches("person.schema.json"))
+	require.True(t, f.Matches("v1/person.schema.json"))
+	require.True(t, f.Matches("v1/README.md"))
+	require.False(t, f.Matches("v2/person.schema.json"))
+	require.False(t, f.Matches("README.md"))
+
+	f, err = NewFileFilter("", "")
+	require.NoError(t, err)
+	require.True(t, f.Matches("README.md"))
+}
+
+func TestGetLocalFiles(t *testing.T) {
+	const dir = "../uploadcmd/testdata/schemas"
+
+	t.Run("Directory", func(t *testing.T) {
+		files, err := GetLocalFiles("", dir, "", "*.md")
+		require.NoError(t, err)
+		require.Len(t, files, 3)
+		require.Equal(t, "v1/address.schema.json", files[0].Name)
+		require.Equal(t, "v1/person.schema.json", files[1].Name)
+		require.Equal(t, "v2/person.schema.json", files[2].Name)
+	})
+
+	t.Run("Paths and directory", func(t *testing.T) {
+		files, err := GetLocalFiles("../uploadcmd/testdata/person.schema.json", dir, "*.json", "v2/*")
+		require.NoError(t, err)
+		require.Len(t, files, 3)
+		require.Equal(t, "person.schema.json", files[0].Name)
+		require.Equal(t, "v1/address.schema.json", files[1].Name)
+		require.Equal(t, "v1/person.schema.json", files[2].Name)
+	})
+
+	t.Run("Not a pattern", func(t *testing.T) {
+		files, err := GetLocalFiles("./xxx.json", "", "", "")
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+
+		_, err = ReadFiles(files, &ContentTypeResolver{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no such file or directory")
+	})
+}
@@ -0,0 +1,163 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+const (
+	fileIndexURLFlag  = "idxurl"
+	fileIndexURLUsage = "The URL of the file index Sidetree document. Example: --idxurl http://localhost:48326/file/identifiers/file:idx:1234"
+
+	noPromptFlag  = "noprompt"
+	noPromptUsage = "If specified then the operation will not prompt for confirmation. Example: --noprompt"
+
+	// MsgAborted is displayed when the user aborts the operation
+	MsgAborted = "Operation aborted"
+
+	// MsgContinueOrAbort prompts the user for confirmation
+	MsgContinueOrAbort = "Enter Y to continue or N to abort "
+)
+
+// ErrFileIndexURLRequired indicates that the file index URL was not provided
+var ErrFileIndexURLRequired = errors.New("file index URL (--idxurl) is required")
+
+// HTTPClient performs HTTP GET, HEAD and POST requests
+type HTTPClient interface {
+	HTTPGetter
+	HTTPHeader
+	HTTPPoster
+	HTTPStreamPoster
+}
+
+// OperationBaseCommand may be used as a base command for commands that submit Sidetree operations for an
+// existing file index document. It registers the flags for the file index URL, the authorization token,
+// the confirmation prompt and the HTTP client.
+type OperationBaseCommand struct {
+	*basecmd.Command
+	client HTTPClient
+
+	// Flags
+	FileIndexURL string
+	NoPrompt     bool
+
+	// Authorization tokens (resolved by ConfigureHTTPClient)
+	AuthToken        string
+	ContentAuthToken string
+
+	httpOpts      HTTPClientOptions
+	authOpts      AuthOptions
+	operationsURL string
+}
+
+// NewOperationBaseCommand returns an OperationBaseCommand
+func NewOperationBaseCommand(settings *environment.Settings, client HTTPClient, cmd *cobra.Command) *OperationBaseCommand {
+	c := &OperationBaseCommand{
+		Command: basecmd.New(settings, nil),
+		client:  client,
+	}
+
+	c.Settings = settings
+	cmd.SetOutput(c.Settings.Streams.Out)
+	cmd.SilenceUsage = true
+
+	cmd.Flags().StringVar(&c.FileIndexURL, fileIndexURLFlag, "", fileIndexURLUsage)
+	cmd.Flags().BoolVar(&c.NoPrompt, noPromptFlag, false, noPromptUsage)
+	c.authOpts.AddFlags(cmd)
+	c.httpOpts.AddFlags(cmd)
+
+	return c
+}
+
+// ConfigureHTTPClient validates the HTTP client flags (TLS, timeout, retries, proxy and headers) and the
+// authorization flags (token file or environment variable and OAuth2) and applies them to the client
+func (c *OperationBaseCommand) ConfigureHTTPClient() error {
+	if err := c.httpOpts.Configure(c.client, c.Settings.Streams.Err); err != nil {
+		return err
+	}
+
+	if err := c.authOpts.Configure(c.client); err != nil {
+		return err
+	}
+
+	c.AuthToken = c.authOpts.Token
+	c.ContentAuthToken = c.authOpts.ContentToken
+
+	return nil
+}
+
+// AddContentAuthFlags registers the flags that provide the authorization token of the content endpoint with
+// the given command. The token is available in ContentAuthToken once the HTTP client is configured.
+func (c *OperationBaseCommand) AddContentAuthFlags(cmd *cobra.Command) {
+	c.authOpts.AddContentFlags(cmd)
+}
+
+// ValidateFileIndexURL validates the file index URL and derives the URL to which operations are submitted
+func (c *OperationBaseCommand) ValidateFileIndexURL() error {
+	if c.FileIndexURL == "" {
+		return ErrFileIndexURLRequired
+	}
+
+	operationsURL, err := GetUpdateURL(c.FileIndexURL)
+	if err != nil {
+		return err
+	}
+
+	c.operationsURL = operationsURL
+
+	return nil
+}
+
+// GetFileIndexDoc retrieves the file index document
+func (c *OperationBaseCommand) GetFileIndexDoc() (*model.FileIndexDoc, error) {
+	return GetFileIndexDoc(c.client, c.FileIndexURL, c.AuthToken)
+}
+
+// Confirm displays the given message and prompts the user for confirmation. True is returned
+// without prompting if --noprompt was specified.
+func (c *OperationBaseCommand) Confirm(msg string) (bool, error) {
+	if c.NoPrompt {
+		return true, nil
+	}
+
+	if err := c.Fprintln(fmt.Sprintf("%s\n%s", msg, MsgContinueOrAbort)); err != nil {
+		return false, err
+	}
+
+	return strings.ToLower(c.Prompt()) == "y", nil
+}
+
+// SubmitOperation submits the given Sidetree operation request for the file index document. The given action
+// (for example, "updating") is included in the error that is returned if the request is rejected.
+func (c *OperationBaseCommand) SubmitOperation(req []byte, action string) error {
+	var reqOpts []httpclient.RequestOpt
+	if c.AuthToken != "" {
+		reqOpts = append(reqOpts, httpclient.WithAuthToken(c.AuthToken))
+	}
+
+	resp, err := c.client.Post(c.operationsURL, req, reqOpts...)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return NewStatusError(action+" file index document", c.operationsURL, resp, AuthTokenFlag)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+const (
+	// WaitPublishedFlag is the flag that indicates that the command waits until the operation is published
+	WaitPublishedFlag  = "wait-published"
+	waitPublishedUsage = "If specified then the command waits until the Sidetree operation has been anchored and is reflected in the published file index document. Example: --wait-published"
+
+	// TimeoutFlag is the flag that specifies how long to wait for the operation to be published
+	TimeoutFlag  = "timeout"
+	timeoutUsage = "The maximum time to wait for the Sidetree operation to be published (used with --wait-published). Example: --timeout 5m"
+
+	defaultPublishTimeout = 2 * time.Minute
+
+	// PublishPollInterval is the interval at which the file index document is retrieved while waiting for
+	// an operation to be published
+	PublishPollInterval = time.Second
+)
+
+// PublishCondition returns true if the given method metadata of a file index document reflects
+// the operation that is being waited for
+type PublishCondition func(metadata *model.MethodMetadata) bool
+
+// IsPublished is a condition that is satisfied once the document is published
+func IsPublished(metadata *model.MethodMetadata) bool {
+	return metadata.Published
+}
+
+// IsPublishedWithCommitment returns a condition that is satisfied once the document is published and
+// commits to the given update commitment, i.e. the operation that set the commitment has been anchored
+func IsPublishedWithCommitment(updateCommitment string) PublishCondition {
+	return func(metadata *model.MethodMetadata) bool {
+		return metadata.Published && metadata.UpdateCommitment == updateCommitment
+	}
+}
+
+// PublishWaitOptions holds the flags that control whether (and for how long) a command waits for its
+// Sidetree operation to be published
+type PublishWaitOptions struct {
+	WaitPublished bool
+	Timeout       time.Duration
+}
+
+// AddFlags registers the --wait-published and --timeout flags with the given command
+func (o *PublishWaitOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.WaitPublished, WaitPublishedFlag, false, waitPublishedUsage)
+	cmd.Flags().DurationVar(&o.Timeout, TimeoutFlag, defaultPublishTimeout, timeoutUsage)
+}
+
+// Validate validates the timeout
+func (o *PublishWaitOptions) Validate() error {
+	if o.Timeout <= 0 {
+		return errors.Errorf("invalid timeout (--%s) [%s] - the timeout must be greater than 0", TimeoutFlag, o.Timeout)
+	}
+
+	return nil
+}
+
+// WaitForPublished retrieves the file index document at the given URL at the given interval until the given
+// condition is satisfied by the method metadata of the document or until the timeout expires. The document is
+// not found until its create operation is anchored, so a missing document is treated as not yet published.
+func WaitForPublished(client HTTPGetter, fileIndexURL, authToken string, condition PublishCondition, timeout, interval time.Duration) (*model.FileIndexDoc, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		fileIdxDoc, published, err := isPublished(client, fileIndexURL, authToken, condition)
+		if err != nil {
+			return nil, err
+		}
+
+		if published {
+			return fileIdxDoc, nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, errors.Errorf("timed out after %s waiting for the operation on file index document [%s] to be published - the operation was accepted but has not been anchored yet", timeout, fileIndexURL)
+		}
+
+		if remaining < interval {
+			time.Sleep(remaining)
+		} else {
+			time.Sleep(interval)
+		}
+	}
+}
+
+func isPublished(client HTTPGetter, fileIndexURL, authToken string, condition PublishCondition) (*model.FileIndexDoc, bool, error) {
+	fileIdxDoc, resolution, err := ResolveFileIndexDoc(client, fileIndexURL, authToken)
+	if err != nil {
+		if IsFileIndexDocNotFound(err) {
+			return nil, false, nil
+		}
+
+		return nil, false, err
+	}
+
+	metadata, err := GetMethodMetadata(resolution)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if metadata == nil {
+		return nil, false, errors.Errorf("the response for file index document [%s] does not contain method metadata - unable to determine whether the operation was published", fileIndexURL)
+	}
+
+	return fileIdxDoc, condition(metadata), nil
+}
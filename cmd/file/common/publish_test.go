@@ -0,0 +1,98 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+func TestWaitForPublished(t *testing.T) {
+	fileIdxDoc := &model.FileIndexDoc{
+		ID:        "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==",
+		FileIndex: model.FileIndex{BasePath: "/content"},
+	}
+
+	fileIdxDocBytes, err := json.Marshal(fileIdxDoc)
+	require.NoError(t, err)
+
+	newResponse := func(t *testing.T, metadata string) *http.Response {
+		resolutionBytes, err := json.Marshal(model.DIDResolution{DIDDocument: fileIdxDocBytes, MethodMetadata: []byte(metadata)})
+		require.NoError(t, err)
+
+		return &http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody(resolutionBytes)}
+	}
+
+	notFound := &http.Response{StatusCode: http.StatusNotFound, Body: mocks.NewResponseBody([]byte("not found"))}
+
+	t.Run("Published", func(t *testing.T) {
+		transport := mocks.NewTransport().WithOrderedGetResponses(
+			notFound,
+			newResponse(t, `{"published":false,"updateCommitment":"uc1"}`),
+			newResponse(t, `{"published":true,"updateCommitment":"uc1"}`),
+			newResponse(t, `{"published":true,"updateCommitment":"uc2"}`),
+		)
+
+		doc, err := WaitForPublished(httpclient.New(httpclient.WithTransport(transport)), idxURL, "",
+			IsPublishedWithCommitment("uc2"), time.Second, time.Millisecond)
+		require.NoError(t, err)
+		require.Equal(t, fileIdxDoc, doc)
+		require.Len(t, transport.GetRequests, 4)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		transport := mocks.NewTransport().WithGetResponse(newResponse(t, `{"published":false}`))
+
+		_, err := WaitForPublished(httpclient.New(httpclient.WithTransport(transport)), idxURL, "",
+			IsPublished, 20*time.Millisecond, 5*time.Millisecond)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "timed out after 20ms waiting for the operation on file index document ["+idxURL+"] to be published")
+	})
+
+	t.Run("No method metadata", func(t *testing.T) {
+		transport := mocks.NewTransport().WithGetResponse(&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody(fileIdxDocBytes)})
+
+		_, err := WaitForPublished(httpclient.New(httpclient.WithTransport(transport)), idxURL, "",
+			IsPublished, time.Second, time.Millisecond)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not contain method metadata")
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		transport := mocks.NewTransport().WithGetResponse(&http.Response{StatusCode: http.StatusUnauthorized, Body: mocks.NewResponseBody([]byte("unauthorized"))})
+
+		_, err := WaitForPublished(httpclient.New(httpclient.WithTransport(transport)), idxURL, "",
+			IsPublished, time.Second, time.Millisecond)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Status code 401")
+		require.Len(t, transport.GetRequests, 1)
+	})
+}
+
+func TestPublishWaitOptions(t *testing.T) {
+	opts := &PublishWaitOptions{}
+
+	cmd := &cobra.Command{}
+	opts.AddFlags(cmd)
+
+	require.NoError(t, cmd.ParseFlags([]string{"--" + WaitPublishedFlag, "--" + TimeoutFlag, "30s"}))
+	require.True(t, opts.WaitPublished)
+	require.Equal(t, 30*time.Second, opts.Timeout)
+	require.NoError(t, opts.Validate())
+
+	opts.Timeout = 0
+	require.EqualError(t, opts.Validate(), "invalid timeout (--timeout) [0s] - the timeout must be greater than 0")
+}
@@ -0,0 +1,129 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto"
+	"encoding/json"
+
+	"github.com/trustbloc/sidetree-core-go/pkg/commitment"
+	"github.com/trustbloc/sidetree-core-go/pkg/document"
+	"github.com/trustbloc/sidetree-core-go/pkg/util/pubkey"
+	"github.com/trustbloc/sidetree-core-go/pkg/versions/1_0/client"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+// NewRecoverRequest returns a Sidetree recover request that replaces the content of the file index document
+// at the given URL with the given file index. The request is signed with the given recovery key and commits
+// to the given next recovery key and next update key.
+func NewRecoverRequest(fileIndexURL string, fileIndex *model.FileIndex, recoveryKey crypto.PrivateKey, nextRecoveryKey, nextUpdateKey crypto.PublicKey) ([]byte, error) {
+	uniqueSuffix, err := GetUniqueSuffix(fileIndexURL)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := getOpaqueDocument(fileIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := NewSigner(recoveryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryKeyPublic, err := publicKeyJWK(recoveryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	revealValue, err := commitment.GetRevealValue(recoveryKeyPublic, sha2_256)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCommitment, err := getCommitment(nextRecoveryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	updateCommitment, err := getCommitment(nextUpdateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.NewRecoverRequest(&client.RecoverRequestInfo{
+		DidSuffix:          uniqueSuffix,
+		RevealValue:        revealValue,
+		RecoveryKey:        recoveryKeyPublic,
+		OpaqueDocument:     doc,
+		RecoveryCommitment: recoveryCommitment,
+		UpdateCommitment:   updateCommitment,
+		MultihashCode:      sha2_256,
+		Signer:             signer,
+	})
+}
+
+// NewDeactivateRequest returns a Sidetree deactivate request for the file index document at the given URL.
+// The request is signed with the given recovery key.
+func NewDeactivateRequest(fileIndexURL string, recoveryKey crypto.PrivateKey) ([]byte, error) {
+	uniqueSuffix, err := GetUniqueSuffix(fileIndexURL)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := NewSigner(recoveryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryKeyPublic, err := publicKeyJWK(recoveryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	revealValue, err := commitment.GetRevealValue(recoveryKeyPublic, sha2_256)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.NewDeactivateRequest(&client.DeactivateRequestInfo{
+		DidSuffix:   uniqueSuffix,
+		RevealValue: revealValue,
+		RecoveryKey: recoveryKeyPublic,
+		Signer:      signer,
+	})
+}
+
+func getCommitment(key crypto.PublicKey) (string, error) {
+	jwk, err := pubkey.GetPublicKeyJWK(key)
+	if err != nil {
+		return "", err
+	}
+
+	return commitment.GetCommitment(jwk, sha2_256)
+}
+
+func getOpaqueDocument(fileIndex *model.FileIndex) (string, error) {
+	docBytes, err := json.Marshal(&model.FileIndexDoc{FileIndex: *fileIndex})
+	if err != nil {
+		return "", err
+	}
+
+	doc, err := document.FromBytes(docBytes)
+	if err != nil {
+		return "", err
+	}
+
+	bytes, err := doc.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+func TestNewRecoverRequest(t *testing.T) {
+	recoveryKey, err := PrivateKeyFromPEM([]byte(signingKey))
+	require.NoError(t, err)
+
+	nextKey, err := PublicKeyFromPEM([]byte(nextUpdateKey))
+	require.NoError(t, err)
+
+	fileIndex := &model.FileIndex{
+		BasePath: "/content",
+		Mappings: map[string]string{".": "/content", "file1.json": "id1"},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		reqBytes, err := NewRecoverRequest(idxURL, fileIndex, recoveryKey, nextKey, nextKey)
+		require.NoError(t, err)
+
+		req := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(reqBytes, &req))
+		require.Equal(t, "recover", req["type"])
+		require.Equal(t, "EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==", req["didSuffix"])
+		require.NotEmpty(t, req["revealValue"])
+		require.NotEmpty(t, req["signedData"])
+
+		// The document is replaced with the given file index
+		require.Contains(t, string(reqBytes), `{"op":"add","path":"/fileIndex","value":{"basePath":"/content","mappings":{".":"/content","file1.json":"id1"}}}`)
+	})
+
+	t.Run("Re-used recovery key", func(t *testing.T) {
+		recoveryPublicKey, err := PublicKey(recoveryKey)
+		require.NoError(t, err)
+
+		_, err = NewRecoverRequest(idxURL, fileIndex, recoveryKey, recoveryPublicKey, nextKey)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "re-using public keys for commitment is not allowed")
+	})
+
+	t.Run("Invalid URL", func(t *testing.T) {
+		_, err := NewRecoverRequest("1234", fileIndex, recoveryKey, nextKey, nextKey)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unique suffix not provided")
+	})
+}
+
+func TestNewDeactivateRequest(t *testing.T) {
+	recoveryKey, err := PrivateKeyFromPEM([]byte(signingKey))
+	require.NoError(t, err)
+
+	t.Run("Success", func(t *testing.T) {
+		reqBytes, err := NewDeactivateRequest(idxURL, recoveryKey)
+		require.NoError(t, err)
+
+		req := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(reqBytes, &req))
+		require.Equal(t, "deactivate", req["type"])
+		require.Equal(t, "EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==", req["didSuffix"])
+		require.NotEmpty(t, req["signedData"])
+	})
+
+	t.Run("Invalid URL", func(t *testing.T) {
+		_, err := NewDeactivateRequest("1234", recoveryKey)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unique suffix not provided")
+	})
+}
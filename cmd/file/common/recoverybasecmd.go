@@ -0,0 +1,82 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	recoveryKeyFlag  = "recoverykey"
+	recoveryKeyUsage = "The private key PEM that corresponds to the current recovery commitment of the index document. Example: --recoverykey 'MHcCAQEEILmfa4yss8nsTJK2hKl+LAoiwW3p+eQzaHfITI9z8ptpoAoGCCqGSM49AwEHoUQDQgAEMd1/e/Nxh73bK12PEEcNSY9HxnP0N8er9ww9rjq1tNcsqfRjlL0bdTh9Basfn/4JrQHUHc6uS99yjQc+0u2bVg'"
+
+	recoveryKeyFileFlag  = "recoverykeyfile"
+	recoveryKeyFileUsage = "The file that contains the private key PEM that corresponds to the current recovery commitment of the index document. Example: --recoverykeyfile ./keys/recovery.key"
+)
+
+var (
+	// ErrRecoveryKeyOrFileRequired indicates that the recovery key was not provided
+	ErrRecoveryKeyOrFileRequired = errors.New("either recovery key (--recoverykey) or key file (--recoverykeyfile) is required")
+	// ErrOnlyOneOfRecoveryKeyOrFileRequired indicates that the recovery key was provided more than once
+	ErrOnlyOneOfRecoveryKeyOrFileRequired = errors.New("only one of recovery key (--recoverykey) or key file (--recoverykeyfile) may be specified")
+)
+
+// RecoveryBaseCommand may be used as a base command for commands that submit Sidetree operations which are
+// signed with the recovery key of a file index document (i.e. recover and deactivate).
+type RecoveryBaseCommand struct {
+	*OperationBaseCommand
+
+	// Flags
+	recoveryKeyFile   string
+	recoveryKeyString string
+}
+
+// NewRecoveryBaseCommand returns a RecoveryBaseCommand
+func NewRecoveryBaseCommand(settings *environment.Settings, client HTTPClient, cmd *cobra.Command) *RecoveryBaseCommand {
+	c := &RecoveryBaseCommand{
+		OperationBaseCommand: NewOperationBaseCommand(settings, client, cmd),
+	}
+
+	cmd.Flags().StringVar(&c.recoveryKeyString, recoveryKeyFlag, "", recoveryKeyUsage)
+	cmd.Flags().StringVar(&c.recoveryKeyFile, recoveryKeyFileFlag, "", recoveryKeyFileUsage)
+
+	return c
+}
+
+// Validate validates the file index URL, the HTTP client flags and the recovery key
+func (c *RecoveryBaseCommand) Validate() error {
+	if err := c.ValidateFileIndexURL(); err != nil {
+		return err
+	}
+
+	if err := c.ConfigureHTTPClient(); err != nil {
+		return err
+	}
+
+	if c.recoveryKeyFile == "" && c.recoveryKeyString == "" {
+		return ErrRecoveryKeyOrFileRequired
+	}
+
+	if c.recoveryKeyFile != "" && c.recoveryKeyString != "" {
+		return ErrOnlyOneOfRecoveryKeyOrFileRequired
+	}
+
+	return nil
+}
+
+// RecoveryKey returns the recovery private key
+func (c *RecoveryBaseCommand) RecoveryKey() (crypto.PrivateKey, error) {
+	if c.recoveryKeyFile != "" {
+		return PrivateKeyFromFile(c.recoveryKeyFile)
+	}
+
+	return PrivateKeyFromPEM([]byte(c.recoveryKeyString))
+}
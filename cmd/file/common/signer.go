@@ -0,0 +1,75 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/sidetree-core-go/pkg/jws"
+	"github.com/trustbloc/sidetree-core-go/pkg/util/ecsigner"
+	"github.com/trustbloc/sidetree-core-go/pkg/util/edsigner"
+	"github.com/trustbloc/sidetree-core-go/pkg/util/pubkey"
+	"github.com/trustbloc/sidetree-core-go/pkg/versions/1_0/client"
+)
+
+// Signing algorithms of the supported key types
+const (
+	AlgorithmES256  = "ES256"
+	AlgorithmES256K = "ES256K"
+	AlgorithmEdDSA  = "EdDSA"
+)
+
+// SigningAlgorithm returns the JWS algorithm that is used to sign with keys of the given type
+func SigningAlgorithm(keyType KeyType) (string, error) {
+	switch keyType {
+	case KeyTypeP256:
+		return AlgorithmES256, nil
+	case KeyTypeSecp256k1:
+		return AlgorithmES256K, nil
+	case KeyTypeEd25519:
+		return AlgorithmEdDSA, nil
+	default:
+		return "", errors.Errorf("unsupported key type [%s]", keyType)
+	}
+}
+
+// NewSigner returns a signer for Sidetree requests that signs with the given private key. The signing
+// algorithm is chosen from the type of the key.
+func NewSigner(privateKey crypto.PrivateKey) (client.Signer, error) {
+	keyType, err := GetKeyType(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	alg, err := SigningAlgorithm(keyType)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		return ecsigner.New(key, alg, ""), nil
+	case ed25519.PrivateKey:
+		return edsigner.New(key, alg, ""), nil
+	default:
+		return nil, errors.Errorf("unsupported key type [%T]", privateKey)
+	}
+}
+
+// publicKeyJWK returns the JWK of the public key of the given private key
+func publicKeyJWK(privateKey crypto.PrivateKey) (*jws.JWK, error) {
+	publicKey, err := PublicKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return pubkey.GetPublicKeyJWK(publicKey)
+}
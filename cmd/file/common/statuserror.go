@@ -0,0 +1,26 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+)
+
+// NewStatusError returns an *httpclient.StatusError for an unexpected response from the given endpoint. If the
+// request was unauthorized then the error suggests providing the authorization token with the given flag.
+func NewStatusError(operation, endpoint string, resp *httpclient.HTTPResponse, authTokenFlag string) error {
+	err := httpclient.NewStatusError(operation, endpoint, resp)
+
+	if errors.Is(err, httpclient.ErrUnauthorized) {
+		err.Hint = fmt.Sprintf("Did you provide an authorization token (--%s)?", authTokenFlag)
+	}
+
+	return err
+}
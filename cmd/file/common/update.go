@@ -0,0 +1,379 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/sidetree-core-go/pkg/commitment"
+	"github.com/trustbloc/sidetree-core-go/pkg/patch"
+	"github.com/trustbloc/sidetree-core-go/pkg/util/pubkey"
+	"github.com/trustbloc/sidetree-core-go/pkg/versions/1_0/client"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+const (
+	// default multihash for Sidetree
+	sha2_256 = 18
+
+	// JSONPatchBasePath is the JSON pointer to the mappings of a file index document
+	JSONPatchBasePath = "/fileIndex/mappings/"
+
+	// JSONPatchAddOp adds a mapping
+	JSONPatchAddOp = "add"
+	// JSONPatchReplaceOp replaces an existing mapping
+	JSONPatchReplaceOp = "replace"
+	// JSONPatchRemoveOp removes an existing mapping
+	JSONPatchRemoveOp = "remove"
+	// JSONPatchMoveOp moves an existing mapping to a new name
+	JSONPatchMoveOp = "move"
+)
+
+// maxConcurrentManifestRequests is the maximum number of manifests that are retrieved concurrently
+const maxConcurrentManifestRequests = 8
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// JSONPatch contains a JSON patch operation on the mappings of a file index document
+type JSONPatch struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// MappingPath returns the JSON pointer to the mapping with the given name. The name is escaped according
+// to RFC 6901 since it may contain slashes (for example, v1/person.schema.json).
+func MappingPath(name string) string {
+	return JSONPatchBasePath + jsonPointerEscaper.Replace(name)
+}
+
+// GetMappingPatches returns the patches that add the given files to the file index (or replace the
+// mappings of files that are already in the file index). The chunks of files that were uploaded in chunks
+// are also added to the file index.
+func GetMappingPatches(fileIdx *model.FileIndex, files Files) []JSONPatch {
+	var patches []JSONPatch
+
+	chunks := make(map[string]bool)
+
+	for _, f := range files {
+		// The chunks of a file are indexed before the file so that the file never refers to a missing chunk
+		for _, id := range f.Chunks {
+			name := ChunkMappingName(id)
+			if _, ok := fileIdx.Mappings[name]; ok || chunks[name] {
+				continue
+			}
+
+			chunks[name] = true
+
+			patches = append(patches, JSONPatch{
+				Op:    JSONPatchAddOp,
+				Path:  MappingPath(name),
+				Value: id,
+			})
+		}
+
+		p := JSONPatch{
+			Path:  MappingPath(f.Name),
+			Value: f.ID,
+		}
+
+		if _, ok := fileIdx.Mappings[f.Name]; ok {
+			p.Op = JSONPatchReplaceOp
+		} else {
+			p.Op = JSONPatchAddOp
+		}
+
+		patches = append(patches, p)
+	}
+
+	return patches
+}
+
+// GetChunkRemovalPatches returns the patches that remove the chunk mappings of the files that were uploaded in
+// chunks and are either removed from the file index (the given names) or replaced by one of the given files. Since
+// chunks are addressed by their content, a chunk may also belong to another file, so a chunk mapping is only removed
+// if the chunk doesn't belong to any of the given files or to any other file in the file index. The file index doesn't
+// record which file a chunk belongs to so the manifests are retrieved (concurrently) from the given content endpoint.
+// The manifests of the other files are only retrieved until all of the candidate chunks are found to belong to another
+// file, and each DCAS ID is only checked once. The patches must be applied after the patches that remove or replace
+// the files so that a file never refers to a missing chunk.
+func GetChunkRemovalPatches(client ContentGetter, contentURL, authToken string, fileIdx *model.FileIndex, removed []string, files Files) ([]JSONPatch, error) {
+	if !HasChunkMappings(fileIdx) {
+		return nil, nil
+	}
+
+	names := make(map[string]bool)
+	for _, name := range removed {
+		names[name] = true
+	}
+
+	for _, f := range files {
+		if id, ok := fileIdx.Mappings[f.Name]; ok && id != f.ID {
+			names[f.Name] = true
+		}
+	}
+
+	candidates := make(map[string]string)
+	for name := range names {
+		candidates[name] = fileIdx.Mappings[name]
+	}
+
+	chunks := make(map[string]bool)
+
+	err := getManifests(client, contentURL, authToken, candidates, func(manifest *model.FileManifest) bool {
+		for _, id := range manifest.Chunks {
+			chunks[ChunkMappingName(id)] = true
+		}
+
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		for _, id := range f.Chunks {
+			delete(chunks, ChunkMappingName(id))
+		}
+	}
+
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+
+	err = getManifests(client, contentURL, authToken, otherFiles(fileIdx, names), func(manifest *model.FileManifest) bool {
+		for _, id := range manifest.Chunks {
+			delete(chunks, ChunkMappingName(id))
+		}
+
+		return len(chunks) == 0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var chunkNames []string
+	for name := range chunks {
+		if _, ok := fileIdx.Mappings[name]; ok {
+			chunkNames = append(chunkNames, name)
+		}
+	}
+
+	sort.Strings(chunkNames)
+
+	var patches []JSONPatch
+	for _, name := range chunkNames {
+		patches = append(patches, JSONPatch{
+			Op:   JSONPatchRemoveOp,
+			Path: MappingPath(name),
+		})
+	}
+
+	return patches, nil
+}
+
+// otherFiles returns the mappings (name to DCAS ID) of the files in the file index, other than the given files, that
+// may refer to a manifest. Reserved mappings and files whose ID is the ID of a chunk are excluded, and only one name
+// is returned for each DCAS ID.
+func otherFiles(fileIdx *model.FileIndex, excluded map[string]bool) map[string]string {
+	chunkIDs := make(map[string]bool)
+	for name, id := range fileIdx.Mappings {
+		if strings.HasPrefix(name, ChunkMappingPrefix) {
+			chunkIDs[id] = true
+		}
+	}
+
+	var names []string
+	for name := range fileIdx.Mappings {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	ids := make(map[string]bool)
+	mappings := make(map[string]string)
+
+	for _, name := range names {
+		id := fileIdx.Mappings[name]
+
+		if excluded[name] || IsReservedMapping(name) || chunkIDs[id] || ids[id] {
+			continue
+		}
+
+		ids[id] = true
+		mappings[name] = id
+	}
+
+	return mappings
+}
+
+// getManifests concurrently retrieves the manifests of the given files (name to DCAS ID) and passes each manifest to
+// the given handler. The handler is only invoked by one goroutine at a time. No more manifests are retrieved once the
+// handler returns true or an error occurs.
+func getManifests(client ContentGetter, contentURL, authToken string, mappings map[string]string, handle func(manifest *model.FileManifest) bool) error {
+	if len(mappings) == 0 {
+		return nil
+	}
+
+	var names []string
+	for name := range mappings {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	parallel := maxConcurrentManifestRequests
+	if parallel > len(names) {
+		parallel = len(names)
+	}
+
+	var (
+		mutex    sync.Mutex
+		done     bool
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	isDone := func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return done || firstErr != nil
+	}
+
+	pending := make(chan string)
+
+	wg.Add(parallel)
+
+	for i := 0; i < parallel; i++ {
+		go func() {
+			defer wg.Done()
+
+			for name := range pending {
+				manifest, err := GetManifest(client, contentURL, name, mappings[name], authToken)
+
+				mutex.Lock()
+				switch {
+				case err != nil:
+					if firstErr == nil {
+						firstErr = err
+					}
+				case manifest != nil && !done:
+					done = handle(manifest)
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for _, name := range names {
+		if isDone() {
+			break
+		}
+
+		pending <- name
+	}
+
+	close(pending)
+	wg.Wait()
+
+	return firstErr
+}
+
+// HasChunkMappings returns true if the given file index contains the mapping of at least one chunk
+func HasChunkMappings(fileIdx *model.FileIndex) bool {
+	for name := range fileIdx.Mappings {
+		if strings.HasPrefix(name, ChunkMappingPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetUpdateURL returns the URL of the Sidetree operations endpoint for the given file index URL
+func GetUpdateURL(fileIndexURL string) (string, error) {
+	pos := strings.LastIndex(fileIndexURL, "/identifiers")
+	if pos == -1 {
+		return "", errors.Errorf("invalid file index URL: [%s] - the file index ID must be prefixed by identifiers/", fileIndexURL)
+	}
+
+	return fmt.Sprintf("%s/operations", fileIndexURL[0:pos]), nil
+}
+
+// GetUniqueSuffix returns the unique suffix of the Sidetree document from the given ID (or URL)
+func GetUniqueSuffix(id string) (string, error) {
+	p := strings.LastIndex(id, ":")
+	if p == -1 {
+		return "", errors.Errorf("unique suffix not provided in URL [%s]", id)
+	}
+
+	return id[p+1:], nil
+}
+
+// NewUpdateRequest returns a Sidetree update request for the file index document at the given URL. The
+// request is signed with the given update key and commits to the given next update key.
+func NewUpdateRequest(fileIndexURL string, patches []JSONPatch, updateKey crypto.PrivateKey, nextUpdateKey crypto.PublicKey) ([]byte, error) {
+	uniqueSuffix, err := GetUniqueSuffix(fileIndexURL)
+	if err != nil {
+		return nil, err
+	}
+
+	patchBytes, err := json.Marshal(patches)
+	if err != nil {
+		return nil, err
+	}
+
+	updatePatch, err := patch.NewJSONPatch(string(patchBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := NewSigner(updateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	updateKeyPublic, err := publicKeyJWK(updateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nextUpdateKeyPublic, err := pubkey.GetPublicKeyJWK(nextUpdateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	updateCommitment, err := commitment.GetCommitment(nextUpdateKeyPublic, sha2_256)
+	if err != nil {
+		return nil, err
+	}
+
+	revealValue, err := commitment.GetRevealValue(updateKeyPublic, sha2_256)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.NewUpdateRequest(&client.UpdateRequestInfo{
+		DidSuffix:        uniqueSuffix,
+		RevealValue:      revealValue,
+		UpdateCommitment: updateCommitment,
+		UpdateKey:        updateKeyPublic,
+		Patches:          []patch.Patch{updatePatch},
+		MultihashCode:    sha2_256,
+		Signer:           signer,
+	})
+}
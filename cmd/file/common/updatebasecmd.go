@@ -0,0 +1,261 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto"
+	"fmt"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+const (
+	fileIndexNextUpdateKeyFlag  = "nextupdatekey"
+	fileIndexNextUpdateKeyUsage = "The public key PEM used for creating commitment for next update of the index document. Example: --nextupdatekey 'MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEFMy2n9jYZChYSjdhK9vUWvPjz9tzBcEa13Ye33haxFsT//3kGxOQhI7yb3MJsDvwLtdfLL6txM3RdOrmLABBvw'"
+
+	fileIndexNextUpdateKeyFileFlag  = "nextupdatekeyfile"
+	fileIndexNextUpdateKeyFileUsage = "The file that contains the public key PEM used for creating commitment for next update of the index document. Example: --nextupdatekeyfile ./next_update_public.key"
+
+	fileIndexSigningKeyFlag  = "signingkey"
+	fileIndexSigningKeyUsage = "The private key PEM used for signing the update of the index document. Example: --signingkey 'MHcCAQEEILmfa4yss8nsTJK2hKl+LAoiwW3p+eQzaHfITI9z8ptpoAoGCCqGSM49AwEHoUQDQgAEMd1/e/Nxh73bK12PEEcNSY9HxnP0N8er9ww9rjq1tNcsqfRjlL0bdTh9Basfn/4JrQHUHc6uS99yjQc+0u2bVg'"
+
+	fileIndexSigningKeyFileFlag  = "signingkeyfile"
+	fileIndexSigningKeyFileUsage = "The file that contains the private key PEM used for signing the update of the index document. Example: --signingkeyfile ./keys/signing.key"
+
+	keyStoreFlag  = "keystore"
+	keyStoreUsage = "The directory of the key store that contains the update keys of the index document (see 'file keys import'). The current update key is used to sign the update and the keys are rotated after a successful update. This flag may not be used with the signing key or next update key flags. Example: --keystore ./keystore"
+)
+
+var (
+	// ErrNextUpdateKeyOrFileRequired indicates that the next update key was not provided
+	ErrNextUpdateKeyOrFileRequired = errors.New("either next update key (--nextupdatekey) or key file (--nextupdatekeyfile) is required")
+	// ErrOnlyOneOfNextUpdateKeyOrFileRequired indicates that the next update key was provided more than once
+	ErrOnlyOneOfNextUpdateKeyOrFileRequired = errors.New("only one of next update key (--nextupdatekey) or key file (--nextupdatekeyfile) may be specified")
+	// ErrSigningKeyOrFileRequired indicates that the signing key was not provided
+	ErrSigningKeyOrFileRequired = errors.New("either signing key (--signingkey) or key file (--signingkeyfile) is required")
+	// ErrOnlyOneOfSigningKeyOrFileRequired indicates that the signing key was provided more than once
+	ErrOnlyOneOfSigningKeyOrFileRequired = errors.New("only one of signing key (--signingkey) or key file (--signingkeyfile) may be specified")
+	// ErrKeyStoreAndKeysSpecified indicates that the keys were provided along with the key store
+	ErrKeyStoreAndKeysSpecified = errors.New("the signing key and next update key may not be specified when the key store (--keystore) is used")
+)
+
+// UpdateBaseCommand may be used as a base command for commands that update a file index document. It
+// registers the flags for the file index URL and the update keys and submits Sidetree update requests.
+type UpdateBaseCommand struct {
+	*OperationBaseCommand
+
+	// Flags
+	fileIndexSigningKeyFile      string
+	fileIndexSigningKeyString    string
+	fileIndexNextUpdateKeyFile   string
+	fileIndexNextUpdateKeyString string
+	keyStore                     string
+	publishWait                  PublishWaitOptions
+}
+
+// NewUpdateBaseCommand returns an UpdateBaseCommand
+func NewUpdateBaseCommand(settings *environment.Settings, client HTTPClient, cmd *cobra.Command) *UpdateBaseCommand {
+	c := &UpdateBaseCommand{
+		OperationBaseCommand: NewOperationBaseCommand(settings, client, cmd),
+	}
+
+	cmd.Flags().StringVar(&c.fileIndexNextUpdateKeyString, fileIndexNextUpdateKeyFlag, "", fileIndexNextUpdateKeyUsage)
+	cmd.Flags().StringVar(&c.fileIndexNextUpdateKeyFile, fileIndexNextUpdateKeyFileFlag, "", fileIndexNextUpdateKeyFileUsage)
+	cmd.Flags().StringVar(&c.fileIndexSigningKeyString, fileIndexSigningKeyFlag, "", fileIndexSigningKeyUsage)
+	cmd.Flags().StringVar(&c.fileIndexSigningKeyFile, fileIndexSigningKeyFileFlag, "", fileIndexSigningKeyFileUsage)
+	cmd.Flags().StringVar(&c.keyStore, keyStoreFlag, "", keyStoreUsage)
+	c.publishWait.AddFlags(cmd)
+
+	return c
+}
+
+// Validate validates the file index URL, the HTTP client flags, the update keys and the publish wait options
+func (c *UpdateBaseCommand) Validate() error {
+	if err := c.ValidateFileIndexURL(); err != nil {
+		return err
+	}
+
+	if err := c.ConfigureHTTPClient(); err != nil {
+		return err
+	}
+
+	if err := c.ValidatePublishWait(); err != nil {
+		return err
+	}
+
+	return c.ValidateKeys()
+}
+
+// ValidatePublishWait validates the --wait-published and --timeout flags
+func (c *UpdateBaseCommand) ValidatePublishWait() error {
+	return c.publishWait.Validate()
+}
+
+// ValidateKeys validates the signing key and next update key flags (or the key store)
+func (c *UpdateBaseCommand) ValidateKeys() error {
+	if c.keyStore != "" {
+		return c.validateKeyStore()
+	}
+
+	if c.fileIndexSigningKeyFile == "" && c.fileIndexSigningKeyString == "" {
+		return ErrSigningKeyOrFileRequired
+	}
+
+	if c.fileIndexSigningKeyFile != "" && c.fileIndexSigningKeyString != "" {
+		return ErrOnlyOneOfSigningKeyOrFileRequired
+	}
+
+	if c.fileIndexNextUpdateKeyFile == "" && c.fileIndexNextUpdateKeyString == "" {
+		return ErrNextUpdateKeyOrFileRequired
+	}
+
+	if c.fileIndexNextUpdateKeyFile != "" && c.fileIndexNextUpdateKeyString != "" {
+		return ErrOnlyOneOfNextUpdateKeyOrFileRequired
+	}
+
+	return nil
+}
+
+func (c *UpdateBaseCommand) validateKeyStore() error {
+	if c.fileIndexSigningKeyFile != "" || c.fileIndexSigningKeyString != "" ||
+		c.fileIndexNextUpdateKeyFile != "" || c.fileIndexNextUpdateKeyString != "" {
+		return ErrKeyStoreAndKeysSpecified
+	}
+
+	// Ensure that the key store contains the keys of the document before any changes are made
+	_, _, err := c.getKeys()
+
+	return err
+}
+
+// ChunkRemovalPatches returns the patches that remove the chunk mappings which are no longer required once the
+// files with the given names are removed from the file index or replaced by the given files (see
+// GetChunkRemovalPatches). If the manifests cannot be retrieved then a warning is displayed and no patches are
+// returned since the chunk mappings that are left in the file index don't affect any of the files.
+func (c *UpdateBaseCommand) ChunkRemovalPatches(contentURL string, fileIdx *model.FileIndex, removed []string, files Files) []JSONPatch {
+	patches, err := GetChunkRemovalPatches(c.client, contentURL, c.ContentAuthToken, fileIdx, removed, files)
+	if err != nil {
+		fmt.Fprintf(c.Settings.Streams.Err, "Warning: the chunks of the removed or replaced files were left in the file index: %s\n", err)
+
+		return nil
+	}
+
+	return patches
+}
+
+// UpdateFileIndex submits a Sidetree update request containing the given patches to the file index document.
+// If a key store is used then its keys are rotated after the update was accepted. If --wait-published was
+// specified then the function returns once the document commits to the next update key.
+func (c *UpdateBaseCommand) UpdateFileIndex(patches []JSONPatch) error {
+	updateKey, nextUpdateKey, err := c.getKeys()
+	if err != nil {
+		return err
+	}
+
+	req, err := NewUpdateRequest(c.FileIndexURL, patches, updateKey, nextUpdateKey)
+	if err != nil {
+		return err
+	}
+
+	if err := c.SubmitOperation(req, "updating"); err != nil {
+		return err
+	}
+
+	if err := c.rotateKeys(); err != nil {
+		return err
+	}
+
+	if !c.publishWait.WaitPublished {
+		return nil
+	}
+
+	updateCommitment, err := getCommitment(nextUpdateKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = WaitForPublished(c.client, c.FileIndexURL, c.AuthToken, IsPublishedWithCommitment(updateCommitment),
+		c.publishWait.Timeout, PublishPollInterval)
+
+	return err
+}
+
+func (c *UpdateBaseCommand) getKeys() (crypto.PrivateKey, crypto.PublicKey, error) {
+	if c.keyStore == "" {
+		updateKey, err := c.signingPrivateKey()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		nextUpdateKey, err := c.nextUpdateKey()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return updateKey, nextUpdateKey, nil
+	}
+
+	uniqueSuffix, err := GetUniqueSuffix(c.FileIndexURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updateKey, nextUpdateKey, err := NewKeyStore(c.keyStore).Get(uniqueSuffix)
+	if err != nil {
+		if errors.Cause(err) == ErrKeysNotFound {
+			return nil, nil, errors.WithMessagef(err, "the update key of the file index document must first be imported into key store [%s] using 'file keys import'", c.keyStore)
+		}
+
+		return nil, nil, err
+	}
+
+	nextUpdatePublicKey, err := PublicKey(nextUpdateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return updateKey, nextUpdatePublicKey, nil
+}
+
+// rotateKeys promotes the next update key in the key store (if used) to the current update key since
+// the file index document now commits to the next update key
+func (c *UpdateBaseCommand) rotateKeys() error {
+	if c.keyStore == "" {
+		return nil
+	}
+
+	uniqueSuffix, err := GetUniqueSuffix(c.FileIndexURL)
+	if err != nil {
+		return err
+	}
+
+	if err := NewKeyStore(c.keyStore).Rotate(uniqueSuffix); err != nil {
+		return errors.WithMessagef(err, "the file index document was updated but the keys in key store [%s] could not be rotated - the next update key (%s) must be used to sign the next update", c.keyStore, nextUpdateKeyFile)
+	}
+
+	return nil
+}
+
+func (c *UpdateBaseCommand) signingPrivateKey() (crypto.PrivateKey, error) {
+	if c.fileIndexSigningKeyFile != "" {
+		return PrivateKeyFromFile(c.fileIndexSigningKeyFile)
+	}
+
+	return PrivateKeyFromPEM([]byte(c.fileIndexSigningKeyString))
+}
+
+func (c *UpdateBaseCommand) nextUpdateKey() (crypto.PublicKey, error) {
+	if c.fileIndexNextUpdateKeyFile != "" {
+		return PublicKeyFromFile(c.fileIndexNextUpdateKeyFile)
+	}
+
+	return PublicKeyFromPEM([]byte(c.fileIndexNextUpdateKeyString))
+}
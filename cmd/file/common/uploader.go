@@ -0,0 +1,353 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+// UploadMode determines how the content of a file is sent to the DCAS content endpoint
+type UploadMode string
+
+const (
+	// UploadModeJSON uploads the content of each file (base64-encoded) in a JSON document. The entire
+	// file is loaded into memory.
+	UploadModeJSON UploadMode = "json"
+
+	// UploadModeStream streams the content of each file to the content endpoint as a raw request body
+	// with the content type of the file. The content endpoint must accept raw uploads.
+	UploadModeStream UploadMode = "stream"
+
+	// UploadModeChunked splits files that are larger than the chunk size into multiple DCAS objects
+	// and uploads a manifest that lists the chunks. The DCAS ID of the file is the ID of the manifest.
+	UploadModeChunked UploadMode = "chunked"
+)
+
+// ChunkContentType is the content type with which chunks are uploaded
+const ChunkContentType = "application/octet-stream"
+
+const (
+	defaultParallel  = 1
+	defaultChunkSize = 4 * 1024 * 1024
+)
+
+// MinChunkSize is the minimum size of a chunk. Each chunk of a file is added to the file index document (as a
+// '.chunks/<ID>' mapping) so a small chunk size would cause the file index document to grow very quickly.
+const MinChunkSize = 64 * 1024
+
+// ContentPoster uploads content to the DCAS content endpoint
+type ContentPoster interface {
+	HTTPPoster
+	HTTPStreamPoster
+}
+
+// Uploader uploads files to the DCAS content endpoint using a bounded pool of workers. Content is uploaded
+// as an idempotent request (the DCAS ID is derived from the content) so uploads that fail with a transient
+// error are retried according to the retry policy of the HTTP client.
+type Uploader struct {
+	client    ContentPoster
+	url       string
+	authToken string
+	mode      UploadMode
+	chunkSize int64
+	parallel  int
+	progress  io.Writer
+	mutex     sync.Mutex
+}
+
+// UploaderOpt sets an Uploader option
+type UploaderOpt func(u *Uploader)
+
+// WithMode sets the mode in which files are uploaded
+func WithMode(mode UploadMode) UploaderOpt {
+	return func(u *Uploader) {
+		u.mode = mode
+	}
+}
+
+// WithChunkSize sets the maximum size of a chunk when files are uploaded in chunked mode
+func WithChunkSize(chunkSize int64) UploaderOpt {
+	return func(u *Uploader) {
+		if chunkSize > 0 {
+			u.chunkSize = chunkSize
+		}
+	}
+}
+
+// WithParallel sets the maximum number of concurrent uploads
+func WithParallel(parallel int) UploaderOpt {
+	return func(u *Uploader) {
+		if parallel > 0 {
+			u.parallel = parallel
+		}
+	}
+}
+
+// WithProgress sets the writer to which upload progress is reported
+func WithProgress(w io.Writer) UploaderOpt {
+	return func(u *Uploader) {
+		u.progress = w
+	}
+}
+
+// NewUploader returns a new Uploader that uploads files to the given content endpoint
+func NewUploader(client ContentPoster, url, authToken string, opts ...UploaderOpt) *Uploader {
+	u := &Uploader{
+		client:    client,
+		url:       url,
+		authToken: authToken,
+		mode:      UploadModeJSON,
+		chunkSize: defaultChunkSize,
+		parallel:  defaultParallel,
+		progress:  ioutil.Discard,
+	}
+
+	for _, opt := range opts {
+		opt(u)
+	}
+
+	return u
+}
+
+// UploadError contains the errors of the files that could not be uploaded
+type UploadError struct {
+	Errors map[string]error
+}
+
+// Names returns the sorted names of the files that could not be uploaded
+func (e *UploadError) Names() []string {
+	var names []string
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Error returns the error of each file that could not be uploaded
+func (e *UploadError) Error() string {
+	var msgs []string
+	for _, name := range e.Names() {
+		msgs = append(msgs, fmt.Sprintf("[%s]: %s", name, e.Errors[name]))
+	}
+
+	return fmt.Sprintf("error uploading %d file(s): %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Upload uploads the given files and sets the ID of each file that was uploaded successfully. The files
+// that were uploaded successfully are returned (in their original order) along with an *UploadError if
+// any of the files could not be uploaded.
+func (u *Uploader) Upload(files Files) (Files, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	parallel := u.parallel
+	if parallel > len(files) {
+		parallel = len(files)
+	}
+
+	errs := make([]error, len(files))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(parallel)
+
+	completed := 0
+
+	for w := 0; w < parallel; w++ {
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				f := files[i]
+
+				id, err := u.upload(f)
+				if err != nil {
+					errs[i] = err
+				} else {
+					f.ID = id
+				}
+
+				u.mutex.Lock()
+				completed++
+				u.reportProgress(completed, len(files), f.Name, err)
+				u.mutex.Unlock()
+			}
+		}()
+	}
+
+	for i := range files {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	var uploaded Files
+	uploadErr := &UploadError{Errors: make(map[string]error)}
+
+	for i, f := range files {
+		if errs[i] != nil {
+			uploadErr.Errors[f.Name] = errs[i]
+		} else {
+			uploaded = append(uploaded, f)
+		}
+	}
+
+	if len(uploadErr.Errors) > 0 {
+		return uploaded, uploadErr
+	}
+
+	return uploaded, nil
+}
+
+func (u *Uploader) upload(f *File) (string, error) {
+	switch u.mode {
+	case UploadModeStream:
+		return u.uploadStream(f)
+	case UploadModeChunked:
+		return u.uploadChunked(f)
+	default:
+		return u.uploadJSON(f)
+	}
+}
+
+func (u *Uploader) uploadJSON(f *File) (string, error) {
+	content := f.Content
+	if content == nil {
+		var err error
+		content, err = ioutil.ReadFile(filepath.Clean(f.Path))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return UploadContent(u.client, u.url, f.ContentType, content, u.authToken)
+}
+
+func (u *Uploader) uploadStream(f *File) (string, error) {
+	if f.Content != nil {
+		return UploadStream(u.client, u.url, f.ContentType, bytes.NewReader(f.Content), u.authToken)
+	}
+
+	// The file is rewound by the HTTP client if the upload is retried
+	file, err := os.Open(filepath.Clean(f.Path))
+	if err != nil {
+		return "", err
+	}
+
+	defer u.closeFile(file)
+
+	return UploadStream(u.client, u.url, f.ContentType, file, u.authToken)
+}
+
+func (u *Uploader) uploadChunked(f *File) (string, error) {
+	if f.Content != nil {
+		return u.uploadChunks(f, bytes.NewReader(f.Content), int64(len(f.Content)))
+	}
+
+	file, err := os.Open(filepath.Clean(f.Path))
+	if err != nil {
+		return "", err
+	}
+
+	defer u.closeFile(file)
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	return u.uploadChunks(f, file, info.Size())
+}
+
+func (u *Uploader) uploadChunks(f *File, r io.Reader, size int64) (string, error) {
+	if size <= u.chunkSize {
+		content, err := ioutil.ReadAll(r)
+		if err != nil {
+			return "", err
+		}
+
+		return UploadContent(u.client, u.url, f.ContentType, content, u.authToken)
+	}
+
+	manifest := &model.FileManifest{
+		ContentType: f.ContentType,
+		Size:        size,
+	}
+
+	buf := make([]byte, u.chunkSize)
+
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			chunk := buf[:n]
+
+			id, e := UploadContent(u.client, u.url, ChunkContentType, chunk, u.authToken)
+			if e != nil {
+				return "", errors.WithMessagef(e, "error uploading chunk %d", len(manifest.Chunks)+1)
+			}
+
+			manifest.Chunks = append(manifest.Chunks, id)
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+
+		if err != nil {
+			return "", err
+		}
+	}
+
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := UploadContent(u.client, u.url, model.ManifestContentType, manifestBytes, u.authToken)
+	if err != nil {
+		return "", errors.WithMessage(err, "error uploading manifest")
+	}
+
+	f.Chunks = manifest.Chunks
+
+	return id, nil
+}
+
+func (u *Uploader) reportProgress(completed, total int, name string, err error) {
+	if err != nil {
+		fmt.Fprintf(u.progress, "[%d/%d] Failed to upload [%s]: %s\n", completed, total, name, err)
+		return
+	}
+
+	fmt.Fprintf(u.progress, "[%d/%d] Uploaded [%s]\n", completed, total, name)
+}
+
+func (u *Uploader) closeFile(f *os.File) {
+	if err := f.Close(); err != nil {
+		u.mutex.Lock()
+		fmt.Fprintf(u.progress, "error closing file [%s]: %s\n", f.Name(), err)
+		u.mutex.Unlock()
+	}
+}
@@ -0,0 +1,242 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+func TestUploader_Upload(t *testing.T) {
+	const contentURL = "http://localhost:48326/content"
+
+	newResponse := func(status int, body string) *http.Response {
+		return &http.Response{StatusCode: status, Body: mocks.NewResponseBody([]byte(body))}
+	}
+
+	retryPolicy := httpclient.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	newFiles := func(n int) Files {
+		var files Files
+		for i := 0; i < n; i++ {
+			files = append(files, &File{Name: fmt.Sprintf("file%d.json", i), ContentType: "application/json", Content: []byte(`{}`)})
+		}
+
+		return files
+	}
+
+	t.Run("Parallel", func(t *testing.T) {
+		transport := mocks.NewTransport().WithPostResponse(newResponse(http.StatusOK, `"id"`))
+
+		w := &mocks.Writer{}
+		u := NewUploader(httpclient.New(httpclient.WithTransport(transport)), contentURL, "", WithParallel(4), WithProgress(w))
+
+		files := newFiles(10)
+
+		uploaded, err := u.Upload(files)
+		require.NoError(t, err)
+		require.Len(t, uploaded, 10)
+		require.Len(t, transport.PostRequests, 10)
+
+		for i, f := range uploaded {
+			require.Equal(t, files[i].Name, f.Name)
+			require.Equal(t, "id", f.ID)
+		}
+
+		require.Contains(t, w.Written(), "[10/10] Uploaded")
+	})
+
+	t.Run("No files", func(t *testing.T) {
+		uploaded, err := NewUploader(nil, contentURL, "").Upload(nil)
+		require.NoError(t, err)
+		require.Empty(t, uploaded)
+	})
+
+	t.Run("Retry transient error", func(t *testing.T) {
+		transport := mocks.NewTransport().
+			WithPostResponses(
+				newResponse(http.StatusServiceUnavailable, "unavailable"),
+				newResponse(http.StatusTooManyRequests, "too many requests"),
+			).
+			WithPostResponse(newResponse(http.StatusOK, `"id"`))
+
+		w := &mocks.Writer{}
+		client := httpclient.New(httpclient.WithTransport(transport), httpclient.WithRetryPolicy(retryPolicy), httpclient.WithLogger(w))
+
+		// Content uploads are idempotent so they're retried by the HTTP client
+		uploaded, err := NewUploader(client, contentURL, "").Upload(newFiles(1))
+		require.NoError(t, err)
+		require.Len(t, uploaded, 1)
+		require.Len(t, transport.PostRequests, 3)
+		require.Contains(t, w.Written(), "Retrying POST "+contentURL)
+		require.Contains(t, w.Written(), "(attempt 2 of 3): status code 503")
+		require.Contains(t, w.Written(), "(attempt 3 of 3): status code 429")
+	})
+
+	t.Run("Retries exhausted", func(t *testing.T) {
+		transport := mocks.NewTransport().WithPostResponse(newResponse(http.StatusBadGateway, "bad gateway"))
+
+		client := httpclient.New(httpclient.WithTransport(transport), httpclient.WithRetryPolicy(retryPolicy))
+
+		uploaded, err := NewUploader(client, contentURL, "").Upload(newFiles(1))
+		require.Error(t, err)
+		require.Empty(t, uploaded)
+		require.Len(t, transport.PostRequests, 3)
+
+		uploadErr, ok := err.(*UploadError)
+		require.True(t, ok)
+		require.Equal(t, []string{"file0.json"}, uploadErr.Names())
+	})
+
+	t.Run("No retry on permanent error", func(t *testing.T) {
+		transport := mocks.NewTransport().
+			WithPostResponses(
+				newResponse(http.StatusOK, `"id"`),
+				newResponse(http.StatusBadRequest, "bad request"),
+			)
+
+		client := httpclient.New(httpclient.WithTransport(transport), httpclient.WithRetryPolicy(retryPolicy))
+
+		uploaded, err := NewUploader(client, contentURL, "").Upload(newFiles(2))
+		require.EqualError(t, err, "error uploading 1 file(s): [file1.json]: status code 400: bad request")
+		require.Len(t, uploaded, 1)
+		require.Equal(t, "file0.json", uploaded[0].Name)
+		require.Len(t, transport.PostRequests, 2)
+	})
+}
+
+func TestUploader_Modes(t *testing.T) {
+	const contentURL = "http://localhost:48326/content"
+
+	newResponse := func() *http.Response {
+		return &http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody([]byte(`"id"`))}
+	}
+
+	dir, err := ioutil.TempDir("", "uploader")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	filePath := filepath.Join(dir, "file.txt")
+	require.NoError(t, ioutil.WriteFile(filePath, []byte("0123456789"), 0600))
+
+	t.Run("JSON from path", func(t *testing.T) {
+		transport := mocks.NewTransport().WithPostResponse(newResponse())
+
+		f := &File{Name: "file.txt", ContentType: "text/plain", Path: filePath}
+
+		uploaded, err := NewUploader(httpclient.New(httpclient.WithTransport(transport)), contentURL, "").Upload(Files{f})
+		require.NoError(t, err)
+		require.Len(t, uploaded, 1)
+		require.Nil(t, f.Content)
+		require.Len(t, transport.PostRequests, 1)
+
+		uploadFile := &model.UploadFile{}
+		require.NoError(t, json.Unmarshal(transport.PostRequests[0], uploadFile))
+		require.Equal(t, "0123456789", string(uploadFile.Content))
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		transport := mocks.NewTransport().WithPostResponse(newResponse())
+
+		u := NewUploader(httpclient.New(httpclient.WithTransport(transport)), contentURL, "", WithMode(UploadModeStream))
+
+		uploaded, err := u.Upload(Files{
+			{Name: "file.txt", ContentType: "text/plain", Path: filePath},
+			{Name: "file.json", ContentType: "application/json", Content: []byte(`{}`)},
+		})
+		require.NoError(t, err)
+		require.Len(t, uploaded, 2)
+		require.Equal(t, []string{"text/plain", "application/json"}, transport.PostContentTypes)
+		require.Equal(t, "0123456789", string(transport.PostRequests[0]))
+		require.Equal(t, `{}`, string(transport.PostRequests[1]))
+	})
+
+	t.Run("Stream - file not found", func(t *testing.T) {
+		u := NewUploader(httpclient.New(httpclient.WithTransport(mocks.NewTransport())), contentURL, "", WithMode(UploadModeStream))
+
+		_, err := u.Upload(Files{{Name: "missing.txt", ContentType: "text/plain", Path: filepath.Join(dir, "missing.txt")}})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no such file or directory")
+	})
+
+	t.Run("Chunked", func(t *testing.T) {
+		transport := mocks.NewTransport().WithPostResponses(
+			&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody([]byte(`"chunk1"`))},
+			&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody([]byte(`"chunk2"`))},
+			&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody([]byte(`"chunk3"`))},
+			&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody([]byte(`"manifest"`))},
+		)
+
+		u := NewUploader(httpclient.New(httpclient.WithTransport(transport)), contentURL, "", WithMode(UploadModeChunked), WithChunkSize(4))
+
+		f := &File{Name: "file.txt", ContentType: "text/plain", Path: filePath}
+
+		uploaded, err := u.Upload(Files{f})
+		require.NoError(t, err)
+		require.Len(t, uploaded, 1)
+		require.Equal(t, "manifest", f.ID)
+		require.Equal(t, []string{"chunk1", "chunk2", "chunk3"}, f.Chunks)
+		require.Len(t, transport.PostRequests, 4)
+
+		var chunks []string
+		for _, reqBytes := range transport.PostRequests[:3] {
+			uploadFile := &model.UploadFile{}
+			require.NoError(t, json.Unmarshal(reqBytes, uploadFile))
+			require.Equal(t, ChunkContentType, uploadFile.ContentType)
+			chunks = append(chunks, string(uploadFile.Content))
+		}
+
+		require.Equal(t, []string{"0123", "4567", "89"}, chunks)
+
+		uploadFile := &model.UploadFile{}
+		require.NoError(t, json.Unmarshal(transport.PostRequests[3], uploadFile))
+		require.Equal(t, model.ManifestContentType, uploadFile.ContentType)
+
+		manifest := &model.FileManifest{}
+		require.NoError(t, json.Unmarshal(uploadFile.Content, manifest))
+		require.Equal(t, "text/plain", manifest.ContentType)
+		require.Equal(t, int64(10), manifest.Size)
+		require.Equal(t, f.Chunks, manifest.Chunks)
+	})
+
+	t.Run("Chunked - small file", func(t *testing.T) {
+		transport := mocks.NewTransport().WithPostResponse(newResponse())
+
+		u := NewUploader(httpclient.New(httpclient.WithTransport(transport)), contentURL, "", WithMode(UploadModeChunked))
+
+		f := &File{Name: "file.txt", ContentType: "text/plain", Path: filePath}
+
+		_, err := u.Upload(Files{f})
+		require.NoError(t, err)
+		require.Empty(t, f.Chunks)
+		require.Len(t, transport.PostRequests, 1)
+	})
+
+	t.Run("Chunked - chunk error", func(t *testing.T) {
+		transport := mocks.NewTransport().WithPostResponse(&http.Response{StatusCode: http.StatusBadRequest, Body: mocks.NewResponseBody([]byte("bad request"))})
+
+		u := NewUploader(httpclient.New(httpclient.WithTransport(transport)), contentURL, "", WithMode(UploadModeChunked), WithChunkSize(4))
+
+		_, err := u.Upload(Files{{Name: "file.txt", ContentType: "text/plain", Content: []byte("0123456789")}})
+		require.EqualError(t, err, "error uploading 1 file(s): [file.txt]: error uploading chunk 1: status code 400: bad request")
+	})
+}
@@ -9,6 +9,7 @@ package createidxcmd
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"testing"
@@ -18,6 +19,7 @@ import (
 
 	"github.com/hyperledger/fabric-cli/pkg/environment"
 
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
 	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
 	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
 	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
@@ -54,31 +56,36 @@ func TestCreateIDXCmd_InvalidOptions(t *testing.T) {
 	)
 
 	t.Run("No options", func(t *testing.T) {
-		require.EqualError(t, newMockCmd(t, nil).Execute(), errURLRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil).Execute(), common.ErrURLRequired.Error())
 	})
 
 	t.Run("No path", func(t *testing.T) {
-		require.EqualError(t, newMockCmd(t, nil, urlFlag, url).Execute(), errPathRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, urlFlag, url).Execute(), common.ErrPathRequired.Error())
 	})
 
 	t.Run("Invalid path", func(t *testing.T) {
-		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, pathFlag, "content").Execute(), errInvalidPath.Error())
+		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, pathFlag, "content").Execute(), common.ErrInvalidPath.Error())
 	})
 
 	t.Run("Recovery key required", func(t *testing.T) {
-		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, pathFlag, path, updatekeyFlag, updatePublicKey).Execute(), errRecoveryKeyOrFileRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, pathFlag, path, updatekeyFlag, updatePublicKey).Execute(), common.ErrRecoveryKeyOrFileRequired.Error())
 	})
 
 	t.Run("Recovery key and file specified", func(t *testing.T) {
-		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, pathFlag, path, recoverykeyFlag, recoveryPublicKey, recoverykeyfileFlag, "./key").Execute(), errOnlyOneOfRecoveryKeyOrFileRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, pathFlag, path, recoverykeyFlag, recoveryPublicKey, recoverykeyfileFlag, "./key").Execute(), common.ErrOnlyOneOfRecoveryKeyOrFileRequired.Error())
 	})
 
 	t.Run("Update key required", func(t *testing.T) {
-		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, pathFlag, path, recoverykeyFlag, recoveryPublicKey).Execute(), errUpdateKeyOrFileRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, pathFlag, path, recoverykeyFlag, recoveryPublicKey).Execute(), common.ErrUpdateKeyOrFileRequired.Error())
+	})
+
+	t.Run("Invalid --proxy", func(t *testing.T) {
+		err := newMockCmd(t, nil, urlFlag, url, pathFlag, path, recoverykeyFlag, recoveryPublicKey, updatekeyFlag, updatePublicKey, "--proxy", "proxy:3128").Execute()
+		require.EqualError(t, err, "invalid proxy URL (--proxy) [proxy:3128]")
 	})
 
 	t.Run("Update key and file specified", func(t *testing.T) {
-		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, pathFlag, path, recoverykeyFlag, recoveryPublicKey, updatekeyFlag, updatePublicKey, updatekeyfileFlag, "./key").Execute(), errOnlyOneOfUpdateKeyOrFileRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, pathFlag, path, recoverykeyFlag, recoveryPublicKey, updatekeyFlag, updatePublicKey, updatekeyfileFlag, "./key").Execute(), common.ErrOnlyOneOfUpdateKeyOrFileRequired.Error())
 	})
 }
 
@@ -126,6 +133,16 @@ func TestCreateIDXCmd(t *testing.T) {
 		require.Contains(t, w.Written(), string(fileIndexBytes))
 	})
 
+	t.Run("With Ed25519 and secp256k1 keys", func(t *testing.T) {
+		w := &mocks.Writer{}
+
+		args := []string{"--url", "http://localhost:80/file", "--path", "/content", "--noprompt",
+			"--recoverykey", publicKeyPEM(t, common.KeyTypeEd25519), "--updatekey", publicKeyPEM(t, common.KeyTypeSecp256k1)}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, transport, args...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), string(fileIndexBytes))
+	})
+
 	t.Run("With invalid update key", func(t *testing.T) {
 		w := &mocks.Writer{}
 
@@ -133,7 +150,7 @@ func TestCreateIDXCmd(t *testing.T) {
 		c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("Y\n")}, w, transport, args...)
 		err := c.Execute()
 		require.Error(t, err)
-		require.Contains(t, err.Error(), errPublicKeyNotFoundInPEM.Error())
+		require.Contains(t, err.Error(), common.ErrPublicKeyNotFoundInPEM.Error())
 	})
 
 	t.Run("With invalid recovery key", func(t *testing.T) {
@@ -143,7 +160,7 @@ func TestCreateIDXCmd(t *testing.T) {
 		c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("Y\n")}, w, transport, args...)
 		err := c.Execute()
 		require.Error(t, err)
-		require.Contains(t, err.Error(), errPublicKeyNotFoundInPEM.Error())
+		require.Contains(t, err.Error(), common.ErrPublicKeyNotFoundInPEM.Error())
 	})
 
 	t.Run("With key files", func(t *testing.T) {
@@ -219,6 +236,21 @@ func TestCreateIDXCmd(t *testing.T) {
 		require.Error(t, err)
 		require.Contains(t, err.Error(), expectedResponse)
 	})
+
+	t.Run("With HTTP unauthorized", func(t *testing.T) {
+		transport := mocks.NewTransport().WithPostResponse(
+			&http.Response{
+				StatusCode: http.StatusUnauthorized,
+				Header:     header,
+				Body:       mocks.NewResponseBody([]byte("unauthorized")),
+			},
+		)
+
+		c := newMockCmd(t, transport, append(args, "--noprompt")...)
+		err := c.Execute()
+		require.EqualError(t, err, "error creating file index document. Status code 401: unauthorized - Did you provide an authorization token (--authtoken)?")
+		require.True(t, errors.Is(err, httpclient.ErrUnauthorized))
+	})
 }
 
 func newMockCmd(t *testing.T, rt http.RoundTripper, args ...string) *cobra.Command {
@@ -240,3 +272,78 @@ func newMockCmdWithReaderWriter(t *testing.T, in io.Reader, w io.Writer, transpo
 
 	return c
 }
+
+func publicKeyPEM(t *testing.T, keyType common.KeyType) string {
+	privateKey, err := common.GenerateKeyOfType(keyType)
+	require.NoError(t, err)
+
+	publicKey, err := common.PublicKey(privateKey)
+	require.NoError(t, err)
+
+	keyPEM, err := common.PublicKeyToPEM(publicKey)
+	require.NoError(t, err)
+
+	return string(keyPEM)
+}
+
+func TestCreateIDXCmd_WaitPublished(t *testing.T) {
+	const idxURL = "http://localhost:80/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+
+	fileIndexBytes, err := json.Marshal(&model.FileIndexDoc{
+		ID:        "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==",
+		FileIndex: model.FileIndex{BasePath: "/content"},
+	})
+	require.NoError(t, err)
+
+	newResolution := func(t *testing.T, published bool) *http.Response {
+		resolutionBytes, err := json.Marshal(model.DIDResolution{
+			DIDDocument:    fileIndexBytes,
+			MethodMetadata: []byte(fmt.Sprintf(`{"published":%t}`, published)),
+		})
+		require.NoError(t, err)
+
+		return &http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody(resolutionBytes)}
+	}
+
+	args := []string{"--url", "http://localhost:80/file/", "--path", "/content", "--recoverykey", recoveryPublicKey,
+		"--updatekey", updatePublicKey, "--noprompt", "--wait-published"}
+
+	t.Run("Published", func(t *testing.T) {
+		transport := mocks.NewTransport().
+			WithPostResponse(newResolution(t, false)).
+			WithGetResponse(newResolution(t, true))
+
+		w := &mocks.Writer{}
+		require.NoError(t, newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, transport, args...).Execute())
+		require.Contains(t, w.Written(), string(fileIndexBytes))
+		require.Equal(t, []string{idxURL}, transport.GetRequests)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		transport := mocks.NewTransport().
+			WithPostResponse(newResolution(t, false)).
+			WithGetResponse(&http.Response{StatusCode: http.StatusNotFound, Body: mocks.NewResponseBody([]byte("not found"))})
+
+		args := []string{"--url", "http://localhost:80/file/operations", "--path", "/content", "--recoverykey", recoveryPublicKey,
+			"--updatekey", updatePublicKey, "--noprompt", "--wait-published", "--timeout", "10ms"}
+
+		err := newMockCmdWithReaderWriter(t, &mocks.Reader{}, &mocks.Writer{}, transport, args...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "timed out after 10ms waiting for the operation on file index document ["+idxURL+"] to be published")
+	})
+
+	t.Run("No ID", func(t *testing.T) {
+		transport := mocks.NewTransport().
+			WithPostResponse(&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody([]byte(`{}`))})
+
+		err := newMockCmdWithReaderWriter(t, &mocks.Reader{}, &mocks.Writer{}, transport, args...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "the ID of the created file index document was not returned")
+	})
+
+	t.Run("Invalid timeout", func(t *testing.T) {
+		err := newMockCmd(t, nil, append(args, "--timeout", "-1s")...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid timeout (--timeout)")
+	})
+}
@@ -0,0 +1,93 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package deactivateidxcmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+)
+
+const (
+	use      = "deactivateidx"
+	desc     = "Deactivate a file index document"
+	longDesc = `
+The deactivateidx command deactivates a Sidetree file index document. The deactivate request is signed with the recovery key of the document. A deactivated document can no longer be resolved, updated or recovered. The files themselves are not removed from DCAS.
+`
+	examples = `
+- Deactivate the given file index document:
+    $ ./fabric file deactivateidx --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --recoverykeyfile ./keys/recovery.key --noprompt
+`
+)
+
+const (
+	msgConfirm     = "Deactivating file index document [%s]. A deactivated document cannot be updated or recovered."
+	msgDeactivated = "File index document [%s] was deactivated"
+)
+
+// New returns the file deactivateidx sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	return newCmd(settings, httpclient.New())
+}
+
+func newCmd(settings *environment.Settings, client common.HTTPClient) *cobra.Command {
+	c := &command{}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   desc,
+		Long:    longDesc,
+		Example: examples,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return c.Validate()
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	c.RecoveryBaseCommand = common.NewRecoveryBaseCommand(settings, client, cmd)
+
+	return cmd
+}
+
+// command implements the deactivateidx command
+type command struct {
+	*common.RecoveryBaseCommand
+}
+
+func (c *command) run() error {
+	recoveryKey, err := c.RecoveryKey()
+	if err != nil {
+		return err
+	}
+
+	req, err := common.NewDeactivateRequest(c.FileIndexURL, recoveryKey)
+	if err != nil {
+		return err
+	}
+
+	confirmed, err := c.Confirm(fmt.Sprintf(msgConfirm, c.FileIndexURL))
+	if err != nil {
+		return err
+	}
+
+	if !confirmed {
+		return c.Fprintln(common.MsgAborted)
+	}
+
+	if err := c.SubmitOperation(req, "deactivating"); err != nil {
+		return err
+	}
+
+	return c.Fprintln(fmt.Sprintf(msgDeactivated, c.FileIndexURL))
+}
@@ -0,0 +1,458 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package downloadcmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+const (
+	use      = "download"
+	desc     = "Download a file from DCAS"
+	longDesc = `
+The download command allows a client to download one or more files that are indexed by a Sidetree file index document. The DCAS ID of each file is looked up in the file index document and the file is retrieved from the content endpoint. The content of the file is streamed to disk (rather than being loaded into memory) and is verified against its DCAS ID as it's written. The response is a JSON document that contains the names of the files that were downloaded along with their DCAS ID, content-type and the path to which they were written.
+
+Files that were uploaded in chunks (upload --uploadmode chunked) are reassembled from their manifest. Each chunk is verified against its DCAS ID.
+
+Each file is written to a temporary file in the target directory which is only renamed to the name of the file once the file has been downloaded and verified, so an existing file is never replaced by a partial or unverified file.
+`
+	examples = `
+- Download a single file into the current directory:
+    $ ./fabric file download --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --name person.schema.json
+
+	Response:
+		[
+		  {
+			"Name": "person.schema.json",
+			"ID": "TbVyraOqG00TacPQH5WwWGnxkszpYSEhBKRyX_f25JI=",
+			"ContentType": "application/json",
+			"Path": "person.schema.json"
+		  }
+		]
+
+- Download all of the files in the file index into the ./content directory:
+    $ ./fabric file download --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --all --dir ./content
+`
+)
+
+const (
+	fileIndexURLFlag  = "idxurl"
+	fileIndexURLUsage = "The URL of the file index Sidetree document. Example: --idxurl http://localhost:48326/file/identifiers/file:idx:1234"
+
+	urlFlag  = "url"
+	urlUsage = "The URL of the content endpoint from which to download the file(s). If not specified then the URL is derived from the host of --idxurl and the base path of the file index. Example: --url http://localhost:48326/content"
+
+	nameFlag  = "name"
+	nameUsage = "The name of the file to download. Example: --name person.schema.json"
+
+	allFlag  = "all"
+	allUsage = "If specified then all of the files in the file index are downloaded. Example: --all"
+
+	dirFlag  = "dir"
+	dirUsage = "The directory to which the file(s) are written. If not specified then the current directory is used. Example: --dir ./content"
+)
+
+var (
+	errFileIndexURLRequired = errors.New("file index URL (--idxurl) is required")
+	errNameOrAllRequired    = errors.New("either file name (--name) or all files (--all) is required")
+	errOnlyOneOfNameOrAll   = errors.New("only one of file name (--name) or all files (--all) may be specified")
+)
+
+type httpClient interface {
+	Get(url string, opts ...httpclient.RequestOpt) (*httpclient.HTTPResponse, error)
+	GetStream(url string, opts ...httpclient.RequestOpt) (*httpclient.StreamResponse, error)
+}
+
+// New returns the file download sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	return newCmd(settings, httpclient.New())
+}
+
+func newCmd(settings *environment.Settings, client httpClient) *cobra.Command {
+	c := &command{
+		Command: basecmd.New(settings, nil),
+		client:  client,
+	}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   desc,
+		Long:    longDesc,
+		Example: examples,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return c.validate()
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	c.Settings = settings
+	cmd.SetOutput(c.Settings.Streams.Out)
+	cmd.SilenceUsage = true
+
+	cmd.Flags().StringVar(&c.fileIndexURL, fileIndexURLFlag, "", fileIndexURLUsage)
+	cmd.Flags().StringVar(&c.url, urlFlag, "", urlUsage)
+	cmd.Flags().StringVar(&c.name, nameFlag, "", nameUsage)
+	cmd.Flags().BoolVar(&c.all, allFlag, false, allUsage)
+	cmd.Flags().StringVar(&c.dir, dirFlag, "", dirUsage)
+	c.authOpts.AddFlags(cmd)
+	c.authOpts.AddContentFlags(cmd)
+	c.httpOpts.AddFlags(cmd)
+
+	return cmd
+}
+
+// command implements the download command
+type command struct {
+	*basecmd.Command
+	client httpClient
+
+	// Flags
+	fileIndexURL string
+	url          string
+	name         string
+	all          bool
+	dir          string
+	httpOpts     common.HTTPClientOptions
+	authOpts     common.AuthOptions
+}
+
+type fileInfo struct {
+	Name        string `json:",omitempty"`
+	ID          string `json:",omitempty"`
+	ContentType string `json:",omitempty"`
+	Path        string `json:",omitempty"`
+}
+
+func (c *command) validate() error {
+	if c.fileIndexURL == "" {
+		return errFileIndexURLRequired
+	}
+
+	if _, err := url.Parse(c.fileIndexURL); err != nil {
+		return errors.WithMessagef(err, "invalid file index URL [%s]", c.fileIndexURL)
+	}
+
+	if c.name == "" && !c.all {
+		return errNameOrAllRequired
+	}
+
+	if c.name != "" && c.all {
+		return errOnlyOneOfNameOrAll
+	}
+
+	if err := c.httpOpts.Configure(c.client, c.Settings.Streams.Err); err != nil {
+		return err
+	}
+
+	if err := c.authOpts.Configure(c.client); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *command) run() error {
+	fileIdxDoc, err := common.GetFileIndexDoc(c.client, c.fileIndexURL, c.authOpts.Token)
+	if err != nil {
+		return err
+	}
+
+	contentURL, err := c.getContentURL(fileIdxDoc.FileIndex.BasePath)
+	if err != nil {
+		return err
+	}
+
+	names, err := c.getNames(fileIdxDoc.FileIndex.Mappings)
+	if err != nil {
+		return err
+	}
+
+	var files []*fileInfo
+
+	for _, name := range names {
+		file, e := c.download(contentURL, name, fileIdxDoc.FileIndex.Mappings[name])
+		if e != nil {
+			return e
+		}
+
+		files = append(files, file)
+	}
+
+	filesBytes, err := json.Marshal(files)
+	if err != nil {
+		return err
+	}
+
+	return c.Fprint(string(filesBytes))
+}
+
+// getNames returns the names of the files to download
+func (c *command) getNames(mappings map[string]string) ([]string, error) {
+	if !c.all {
+		if _, ok := mappings[c.name]; !ok || common.IsReservedMapping(c.name) {
+			return nil, errors.Errorf("file [%s] not found in file index document [%s]", c.name, c.fileIndexURL)
+		}
+
+		return []string{c.name}, nil
+	}
+
+	var names []string
+	for name := range mappings {
+		if !common.IsReservedMapping(name) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// getContentURL returns the URL of the content endpoint. If the URL wasn't provided then it's
+// derived from the file index URL and the base path of the file index.
+func (c *command) getContentURL(basePath string) (string, error) {
+	if c.url != "" {
+		return c.url, nil
+	}
+
+	return common.GetContentURL(c.fileIndexURL, basePath)
+}
+
+func (c *command) download(contentURL, name, id string) (*fileInfo, error) {
+	filePath, err := c.getFilePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.getContent(contentURL, name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.closeBody(resp.Body)
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0750); err != nil {
+		return nil, err
+	}
+
+	contentType := resp.ContentType
+
+	if contentType == model.ManifestContentType {
+		var manifest *model.FileManifest
+
+		manifest, err = readManifest(resp, name, id)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.writeFile(filePath, func(w io.Writer) error {
+			return c.writeChunks(contentURL, name, w, manifest)
+		})
+
+		contentType = manifest.ContentType
+	} else {
+		err = c.writeFile(filePath, func(w io.Writer) error {
+			_, e := copyContent(w, resp, name, id)
+			return e
+		})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileInfo{
+		Name:        name,
+		ID:          id,
+		ContentType: contentType,
+		Path:        filePath,
+	}, nil
+}
+
+// writeFile writes the file at the given path using the given write function. The content is written to a
+// temporary file in the same directory which is only renamed to the given path if the write function succeeds,
+// so that a partially downloaded (or unverified) file is never left at the given path.
+func (c *command) writeFile(filePath string, write func(w io.Writer) error) error {
+	dir, base := filepath.Split(filePath)
+
+	file, err := ioutil.TempFile(dir, "."+base+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		c.closeFile(file)
+		c.removeFile(file.Name())
+
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		c.removeFile(file.Name())
+		return err
+	}
+
+	// The temporary file is created with mode 0600
+	if err := os.Chmod(file.Name(), 0644); err != nil { //nolint: gosec
+		c.removeFile(file.Name())
+		return err
+	}
+
+	if err := os.Rename(file.Name(), filePath); err != nil {
+		c.removeFile(file.Name())
+		return err
+	}
+
+	return nil
+}
+
+// writeChunks downloads the chunks listed in the given manifest and writes them (in order) to the given writer
+func (c *command) writeChunks(contentURL, name string, w io.Writer, manifest *model.FileManifest) error {
+	var size int64
+
+	for _, chunkID := range manifest.Chunks {
+		n, err := c.writeChunk(contentURL, chunkID, w)
+		if err != nil {
+			return errors.WithMessagef(err, "error downloading chunk of file [%s]", name)
+		}
+
+		size += n
+	}
+
+	if size != manifest.Size {
+		return errors.Errorf("the size of file [%s] does not match the size in its manifest: %d != %d", name, size, manifest.Size)
+	}
+
+	return nil
+}
+
+// writeChunk downloads the chunk with the given DCAS ID, writes it to the given writer and returns the size of the chunk
+func (c *command) writeChunk(contentURL, id string, w io.Writer) (int64, error) {
+	chunkName := common.ChunkMappingName(id)
+
+	resp, err := c.getContent(contentURL, chunkName)
+	if err != nil {
+		return 0, err
+	}
+
+	defer c.closeBody(resp.Body)
+
+	return copyContent(w, resp, chunkName, id)
+}
+
+// getContent requests the content of the given file from the content endpoint. The content is streamed
+// from the body of the response, which must be closed by the caller.
+func (c *command) getContent(contentURL, name string) (*httpclient.StreamResponse, error) {
+	var reqOpts []httpclient.RequestOpt
+	if c.authOpts.ContentToken != "" {
+		reqOpts = append(reqOpts, httpclient.WithAuthToken(c.authOpts.ContentToken))
+	}
+
+	fileURL := common.GetFileURL(contentURL, name)
+
+	resp, err := c.client.GetStream(fileURL, reqOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		c.closeBody(resp.Body)
+
+		return nil, common.NewStatusError(fmt.Sprintf("downloading file [%s]", fileURL), fileURL, &resp.HTTPResponse, common.ContentAuthTokenFlag)
+	}
+
+	return resp, nil
+}
+
+// readManifest reads the manifest of a file that was uploaded in chunks and verifies that it matches the given DCAS ID
+func readManifest(resp *httpclient.StreamResponse, name, id string) (*model.FileManifest, error) {
+	manifestBytes := &bytes.Buffer{}
+	if _, err := copyContent(manifestBytes, resp, name, id); err != nil {
+		return nil, err
+	}
+
+	manifest := &model.FileManifest{}
+	if err := json.Unmarshal(manifestBytes.Bytes(), manifest); err != nil {
+		return nil, errors.WithMessagef(err, "invalid manifest for file [%s]", name)
+	}
+
+	return manifest, nil
+}
+
+// copyContent copies the content in the body of the given response to the given writer, computing the DCAS ID of
+// the content as it's copied, and returns the number of bytes copied. An error is returned if the content doesn't
+// match the given DCAS ID, in which case the content that was written must be discarded.
+func copyContent(w io.Writer, resp *httpclient.StreamResponse, name, id string) (int64, error) {
+	h := common.NewDCASHash(resp.ContentType)
+
+	n, err := io.Copy(io.MultiWriter(w, h), resp.Body)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "error downloading file [%s]", name)
+	}
+
+	computedID, err := h.ID()
+	if err != nil {
+		return 0, err
+	}
+
+	if computedID != id {
+		return 0, errors.Errorf("the content of file [%s] does not match its ID in the file index: [%s] != [%s]", name, computedID, id)
+	}
+
+	return n, nil
+}
+
+func (c *command) closeBody(body io.Closer) {
+	if err := body.Close(); err != nil {
+		fmt.Fprintf(c.Settings.Streams.Err, "error closing HTTP response: %s\n", err)
+	}
+}
+
+func (c *command) closeFile(f *os.File) {
+	if err := f.Close(); err != nil {
+		fmt.Fprintf(c.Settings.Streams.Err, "error closing file [%s]: %s\n", f.Name(), err)
+	}
+}
+
+func (c *command) removeFile(filePath string) {
+	if err := os.Remove(filePath); err != nil {
+		fmt.Fprintf(c.Settings.Streams.Err, "error removing file [%s]: %s\n", filePath, err)
+	}
+}
+
+// getFilePath returns the local path for the given file name. An error is returned if the
+// name would result in a file outside of the target directory.
+func (c *command) getFilePath(name string) (string, error) {
+	cleanName := path.Clean("/" + name)
+	if cleanName == "/" || cleanName[1:] != name {
+		return "", errors.Errorf("invalid file name [%s]", name)
+	}
+
+	return filepath.Join(c.dir, filepath.FromSlash(name)), nil
+}
@@ -0,0 +1,380 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package downloadcmd
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+const (
+	idxURL     = "http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+	contentURL = "http://localhost:48326/content"
+)
+
+func TestDownloadCmd_New(t *testing.T) {
+	require.NotNil(t, New(environment.NewDefaultSettings()))
+}
+
+func TestDownloadCmd_InvalidOptions(t *testing.T) {
+	t.Run("No options", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil).Execute(), errFileIndexURLRequired.Error())
+	})
+
+	t.Run("No name", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, "--idxurl", idxURL).Execute(), errNameOrAllRequired.Error())
+	})
+
+	t.Run("Name and all", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, "--idxurl", idxURL, "--name", "file1.json", "--all").Execute(), errOnlyOneOfNameOrAll.Error())
+	})
+}
+
+func TestDownloadCmd(t *testing.T) {
+	const (
+		jsonContent = `{"name":"file1"}`
+		textContent = "file2 content"
+	)
+
+	jsonID, err := common.GetDCASID("application/json", []byte(jsonContent))
+	require.NoError(t, err)
+
+	textID, err := common.GetDCASID("text/plain", []byte(textContent))
+	require.NoError(t, err)
+
+	fileIdxDoc := &model.FileIndexDoc{
+		ID: "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==",
+		FileIndex: model.FileIndex{
+			BasePath: "/content",
+			Mappings: map[string]string{
+				".":          "/content",
+				"file1.json": jsonID,
+				"v1/file2":   textID,
+				"bad.json":   textID,
+			},
+		},
+	}
+
+	fileIdxDocBytes, err := json.Marshal(fileIdxDoc)
+	require.NoError(t, err)
+
+	didResolutionBytes, err := json.Marshal(model.DIDResolution{DIDDocument: fileIdxDocBytes})
+	require.NoError(t, err)
+
+	newResponse := func(status int, contentType string, payload []byte) *http.Response {
+		return &http.Response{
+			StatusCode: status,
+			Header:     map[string][]string{"Content-Type": {contentType}},
+			Body:       mocks.NewResponseBody(payload),
+		}
+	}
+
+	transport := mocks.NewTransport().
+		WithGetResponseForURL(idxURL, newResponse(http.StatusOK, "application/json", didResolutionBytes)).
+		WithGetResponseForURL(contentURL+"/file1.json", newResponse(http.StatusOK, "application/json", []byte(jsonContent))).
+		WithGetResponseForURL(contentURL+"/v1/file2", newResponse(http.StatusOK, "text/plain", []byte(textContent))).
+		WithGetResponseForURL(contentURL+"/bad.json", newResponse(http.StatusOK, "application/json", []byte(jsonContent))).
+		WithGetResponse(newResponse(http.StatusNotFound, "text/plain", []byte("not found")))
+
+	t.Run("Single file", func(t *testing.T) {
+		dir := newTempDir(t)
+		defer removeDir(t, dir)
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithWriter(t, w, transport, "--idxurl", idxURL, "--name", "file1.json", "--dir", dir)
+		require.NoError(t, c.Execute())
+
+		var files []*fileInfo
+		require.NoError(t, json.Unmarshal([]byte(w.Written()), &files))
+		require.Len(t, files, 1)
+		require.Equal(t, "file1.json", files[0].Name)
+		require.Equal(t, jsonID, files[0].ID)
+		require.Equal(t, "application/json", files[0].ContentType)
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, "file1.json"))
+		require.NoError(t, err)
+		require.Equal(t, jsonContent, string(content))
+	})
+
+	t.Run("With content URL", func(t *testing.T) {
+		dir := newTempDir(t)
+		defer removeDir(t, dir)
+
+		c := newMockCmd(t, transport, "--idxurl", idxURL, "--url", contentURL+"/", "--name", "v1/file2", "--dir", dir)
+		require.NoError(t, c.Execute())
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, "v1", "file2"))
+		require.NoError(t, err)
+		require.Equal(t, textContent, string(content))
+	})
+
+	t.Run("Hash mismatch", func(t *testing.T) {
+		dir := newTempDir(t)
+		defer removeDir(t, dir)
+
+		err := newMockCmd(t, transport, "--idxurl", idxURL, "--name", "bad.json", "--dir", dir).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "the content of file [bad.json] does not match its ID in the file index")
+
+		_, err = os.Stat(filepath.Join(dir, "bad.json"))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("All files", func(t *testing.T) {
+		dir := newTempDir(t)
+		defer removeDir(t, dir)
+
+		// The file index contains a file whose content doesn't match
+		err := newMockCmd(t, transport, "--idxurl", idxURL, "--all", "--dir", dir).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "bad.json")
+
+		delete(fileIdxDoc.FileIndex.Mappings, "bad.json")
+		defer func() { fileIdxDoc.FileIndex.Mappings["bad.json"] = textID }()
+
+		docBytes, err := json.Marshal(fileIdxDoc)
+		require.NoError(t, err)
+
+		transport := mocks.NewTransport().
+			WithGetResponseForURL(idxURL, newResponse(http.StatusOK, "application/json", docBytes)).
+			WithGetResponseForURL(contentURL+"/file1.json", newResponse(http.StatusOK, "application/json", []byte(jsonContent))).
+			WithGetResponseForURL(contentURL+"/v1/file2", newResponse(http.StatusOK, "text/plain", []byte(textContent)))
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithWriter(t, w, transport, "--idxurl", idxURL, "--all", "--dir", dir)
+		require.NoError(t, c.Execute())
+
+		var files []*fileInfo
+		require.NoError(t, json.Unmarshal([]byte(w.Written()), &files))
+		require.Len(t, files, 2)
+		require.Equal(t, "file1.json", files[0].Name)
+		require.Equal(t, "v1/file2", files[1].Name)
+	})
+
+	t.Run("File not in index", func(t *testing.T) {
+		err := newMockCmd(t, transport, "--idxurl", idxURL, "--name", "file3.json").Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "file [file3.json] not found in file index document")
+
+		err = newMockCmd(t, transport, "--idxurl", idxURL, "--name", ".").Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "file [.] not found in file index document")
+	})
+
+	t.Run("File not found", func(t *testing.T) {
+		err := newMockCmd(t, transport, "--idxurl", idxURL, "--url", "http://localhost:48326/other", "--name", "file1.json").Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Status code 404: not found")
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		transport := mocks.NewTransport().
+			WithGetResponseForURL(idxURL, newResponse(http.StatusOK, "application/json", didResolutionBytes)).
+			WithGetResponse(newResponse(http.StatusUnauthorized, "text/plain", []byte("unauthorized")))
+
+		err := newMockCmd(t, transport, "--idxurl", idxURL, "--name", "file1.json").Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Did you provide an authorization token (--contentauthtoken)?")
+	})
+
+	t.Run("Invalid file name", func(t *testing.T) {
+		c := &command{}
+
+		_, err := c.getFilePath("../file1.json")
+		require.EqualError(t, err, "invalid file name [../file1.json]")
+
+		_, err = c.getFilePath("v1/../../file1.json")
+		require.Error(t, err)
+
+		p, err := c.getFilePath("v1/file1.json")
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join("v1", "file1.json"), p)
+	})
+
+	t.Run("Index not found", func(t *testing.T) {
+		transport := mocks.NewTransport().WithGetResponse(newResponse(http.StatusNotFound, "text/plain", []byte("not found")))
+
+		err := newMockCmd(t, transport, "--idxurl", idxURL, "--name", "file1.json").Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+	})
+}
+
+func TestDownloadCmd_Chunked(t *testing.T) {
+	chunks := []string{"0123", "4567", "89"}
+
+	newResponse := func(status int, contentType string, payload []byte) *http.Response {
+		return &http.Response{
+			StatusCode: status,
+			Header:     map[string][]string{"Content-Type": {contentType}},
+			Body:       mocks.NewResponseBody(payload),
+		}
+	}
+
+	transport := mocks.NewTransport()
+
+	manifest := &model.FileManifest{ContentType: "text/plain", Size: 10}
+
+	for _, chunk := range chunks {
+		id, err := common.GetDCASID(common.ChunkContentType, []byte(chunk))
+		require.NoError(t, err)
+
+		manifest.Chunks = append(manifest.Chunks, id)
+		transport.WithGetResponseForURL(common.GetFileURL(contentURL, common.ChunkMappingName(id)), newResponse(http.StatusOK, common.ChunkContentType, []byte(chunk)))
+	}
+
+	newTransport := func(manifest *model.FileManifest) *mocks.MockTransport {
+		manifestBytes, err := json.Marshal(manifest)
+		require.NoError(t, err)
+
+		manifestID, err := common.GetDCASID(model.ManifestContentType, manifestBytes)
+		require.NoError(t, err)
+
+		mappings := map[string]string{
+			".":        "/content",
+			"file.txt": manifestID,
+		}
+
+		for _, id := range manifest.Chunks {
+			mappings[common.ChunkMappingName(id)] = id
+		}
+
+		fileIdxDocBytes, err := json.Marshal(&model.FileIndexDoc{FileIndex: model.FileIndex{BasePath: "/content", Mappings: mappings}})
+		require.NoError(t, err)
+
+		didResolutionBytes, err := json.Marshal(model.DIDResolution{DIDDocument: fileIdxDocBytes})
+		require.NoError(t, err)
+
+		t := mocks.NewTransport().
+			WithGetResponseForURL(idxURL, newResponse(http.StatusOK, "application/json", didResolutionBytes)).
+			WithGetResponseForURL(contentURL+"/file.txt", newResponse(http.StatusOK, model.ManifestContentType, manifestBytes)).
+			WithGetResponse(newResponse(http.StatusNotFound, "text/plain", []byte("not found")))
+
+		for u, resp := range transport.GetResponses {
+			t.WithGetResponseForURL(u, resp)
+		}
+
+		return t
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		dir := newTempDir(t)
+		defer removeDir(t, dir)
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithWriter(t, w, newTransport(manifest), "--idxurl", idxURL, "--all", "--dir", dir)
+		require.NoError(t, c.Execute())
+
+		var files []*fileInfo
+		require.NoError(t, json.Unmarshal([]byte(w.Written()), &files))
+		require.Len(t, files, 1)
+		require.Equal(t, "file.txt", files[0].Name)
+		require.Equal(t, "text/plain", files[0].ContentType)
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, "file.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "0123456789", string(content))
+		requireFiles(t, dir, "file.txt")
+
+		info, err := os.Stat(filepath.Join(dir, "file.txt"))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	})
+
+	t.Run("Size mismatch", func(t *testing.T) {
+		dir := newTempDir(t)
+		defer removeDir(t, dir)
+
+		// An existing file is left unchanged if the download fails
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte("old content"), 0600))
+
+		m := *manifest
+		m.Size = 11
+
+		err := newMockCmd(t, newTransport(&m), "--idxurl", idxURL, "--name", "file.txt", "--dir", dir).Execute()
+		require.EqualError(t, err, "the size of file [file.txt] does not match the size in its manifest: 10 != 11")
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, "file.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "old content", string(content))
+		requireFiles(t, dir, "file.txt")
+	})
+
+	t.Run("Missing chunk", func(t *testing.T) {
+		dir := newTempDir(t)
+		defer removeDir(t, dir)
+
+		m := *manifest
+		m.Chunks = append([]string{"missing"}, m.Chunks...)
+
+		err := newMockCmd(t, newTransport(&m), "--idxurl", idxURL, "--name", "file.txt", "--dir", dir).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error downloading chunk of file [file.txt]")
+
+		// The partially downloaded file is removed
+		requireFiles(t, dir)
+	})
+}
+
+func newTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "downloadcmd")
+	require.NoError(t, err)
+
+	return dir
+}
+
+func removeDir(t *testing.T, dir string) {
+	require.NoError(t, os.RemoveAll(dir))
+}
+
+// requireFiles requires that the given directory contains only the given files (and no temporary files)
+func requireFiles(t *testing.T, dir string, names ...string) {
+	infos, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+
+	var actual []string
+	for _, info := range infos {
+		actual = append(actual, info.Name())
+	}
+
+	require.ElementsMatch(t, names, actual)
+}
+
+func newMockCmd(t *testing.T, rt http.RoundTripper, args ...string) *cobra.Command {
+	return newMockCmdWithWriter(t, &mocks.Writer{}, rt, args...)
+}
+
+func newMockCmdWithWriter(t *testing.T, w io.Writer, transport http.RoundTripper, args ...string) *cobra.Command {
+	settings := environment.NewDefaultSettings()
+	settings.Streams.Out = w
+	settings.Streams.In = &mocks.Reader{}
+
+	settings.Config.CurrentContext = "testctx"
+	settings.Config.Contexts[settings.Config.CurrentContext] = &environment.Context{}
+
+	c := newCmd(settings, httpclient.New(httpclient.WithTransport(transport)))
+	require.NotNil(t, c)
+
+	c.SetArgs(args)
+
+	return c
+}
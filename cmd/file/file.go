@@ -11,14 +11,24 @@ import (
 
 	"github.com/hyperledger/fabric-cli/pkg/environment"
 
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/bootstrapcmd"
 	"github.com/trustbloc/fabric-cli-ext/cmd/file/createidxcmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/deactivateidxcmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/downloadcmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/keyscmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/lscmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/mvcmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/recoveridxcmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/rmcmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/synccmd"
 	"github.com/trustbloc/fabric-cli-ext/cmd/file/uploadcmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/verifycmd"
 )
 
 const (
 	use      = "file"
 	desc     = "Manages file uploads"
-	longDesc = "The file command allows you to upload files and create file indexes as Sidetree documents"
+	longDesc = "The file command allows you to upload, download, list, remove, rename, synchronize and verify files, create, bootstrap, recover and deactivate file indexes as Sidetree documents and manage the keys of file indexes"
 )
 
 // New is the entry point to the file plugin
@@ -34,7 +44,17 @@ func New(settings *environment.Settings) *cobra.Command {
 
 	cmd.AddCommand(
 		createidxcmd.New(settings),
+		bootstrapcmd.New(settings),
+		recoveridxcmd.New(settings),
+		deactivateidxcmd.New(settings),
 		uploadcmd.New(settings),
+		downloadcmd.New(settings),
+		lscmd.New(settings),
+		rmcmd.New(settings),
+		mvcmd.New(settings),
+		synccmd.New(settings),
+		verifycmd.New(settings),
+		keyscmd.New(settings),
 	)
 
 	return cmd
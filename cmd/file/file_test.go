@@ -28,6 +28,26 @@ func TestNew(t *testing.T) {
 
 	// Make sure that the createidx command was added
 	require.Contains(t, w.Written(), "createidx")
+	// Make sure that the bootstrap command was added
+	require.Contains(t, w.Written(), "bootstrap")
 	// Make sure that the upload command was added
 	require.Contains(t, w.Written(), "upload")
+	// Make sure that the download command was added
+	require.Contains(t, w.Written(), "download")
+	// Make sure that the ls command was added
+	require.Contains(t, w.Written(), "List the files in a file index document")
+	// Make sure that the rm command was added
+	require.Contains(t, w.Written(), "Remove a file from a file index document")
+	// Make sure that the mv command was added
+	require.Contains(t, w.Written(), "Rename a file in a file index document")
+	// Make sure that the sync command was added
+	require.Contains(t, w.Written(), "Synchronize a local directory with a file index document")
+	// Make sure that the verify command was added
+	require.Contains(t, w.Written(), "Verify that the files in a file index document are retrievable")
+	// Make sure that the recoveridx command was added
+	require.Contains(t, w.Written(), "Recover a file index document")
+	// Make sure that the deactivateidx command was added
+	require.Contains(t, w.Written(), "Deactivate a file index document")
+	// Make sure that the keys command was added
+	require.Contains(t, w.Written(), "Manage the keys of file index documents")
 }
@@ -0,0 +1,122 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const redacted = "<redacted>"
+
+// sensitiveNameParts are the (lower-case) parts of header and query parameter names whose values are redacted
+var sensitiveNameParts = []string{"auth", "token", "secret", "key", "password", "cookie", "signature"}
+
+// WithDebug dumps the metadata (method, URL, status and headers but not the body) of every request and response
+// to the given writer. The values of headers and query parameters that may contain secrets are redacted.
+func WithDebug(w io.Writer) Opt {
+	return func(c *Client) {
+		c.debug = w
+	}
+}
+
+// dump writes the metadata of the given request and response (or error) to the debug writer
+func (c *Client) dump(httpReq *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+	if c.debug == nil {
+		return
+	}
+
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "> %s %s\n", httpReq.Method, redactURL(httpReq.URL))
+	dumpHeader(b, ">", httpReq.Header)
+
+	if err != nil {
+		fmt.Fprintf(b, "< error after %s: %s\n", elapsed, err)
+	} else {
+		fmt.Fprintf(b, "< %s (%s)\n", resp.Status, elapsed)
+		dumpHeader(b, "<", resp.Header)
+	}
+
+	c.debugMutex.Lock()
+	defer c.debugMutex.Unlock()
+
+	fmt.Fprint(c.debug, b.String())
+}
+
+func dumpHeader(b *strings.Builder, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			fmt.Fprintf(b, "%s %s: %s\n", prefix, name, redactHeader(name, value))
+		}
+	}
+}
+
+// redactHeader returns the value of the given header with any secret redacted. The scheme of an authorization
+// header (for example, Bearer) is retained.
+func redactHeader(name, value string) string {
+	if !isSensitive(name) {
+		return value
+	}
+
+	if strings.EqualFold(name, authHeader) || strings.EqualFold(name, "Proxy-Authorization") {
+		if p := strings.Index(value, " "); p > 0 {
+			return value[:p+1] + redacted
+		}
+	}
+
+	return redacted
+}
+
+// redactURL returns the given URL with the password and sensitive query parameters redacted
+func redactURL(u *url.URL) string {
+	r := *u
+
+	if _, ok := r.User.Password(); ok {
+		r.User = url.UserPassword(r.User.Username(), redacted)
+	}
+
+	if r.RawQuery != "" {
+		query := r.Query()
+
+		for name, values := range query {
+			if isSensitive(name) {
+				for i := range values {
+					values[i] = redacted
+				}
+			}
+		}
+
+		r.RawQuery = query.Encode()
+	}
+
+	return r.String()
+}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, part := range sensitiveNameParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,86 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrUnauthorized matches (using errors.Is) a StatusError with HTTP status 401
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrForbidden matches (using errors.Is) a StatusError with HTTP status 403
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrNotFound matches (using errors.Is) a StatusError with HTTP status 404
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict matches (using errors.Is) a StatusError with HTTP status 409
+	ErrConflict = errors.New("conflict")
+)
+
+// statusSentinels maps the status codes to the sentinel errors that they match
+var statusSentinels = map[int]error{
+	http.StatusUnauthorized: ErrUnauthorized,
+	http.StatusForbidden:    ErrForbidden,
+	http.StatusNotFound:     ErrNotFound,
+	http.StatusConflict:     ErrConflict,
+}
+
+// StatusError is returned when an HTTP endpoint responds with an unexpected status code
+type StatusError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+
+	// Endpoint is the URL to which the request was sent
+	Endpoint string
+
+	// Operation describes what the request was for (for example, "updating file index document"). The
+	// operation is optional.
+	Operation string
+
+	// Body is the body of the response
+	Body string
+
+	// Hint is an optional suggestion to the user on how to resolve the error
+	Hint string
+}
+
+// NewStatusError returns a StatusError for the given response from the given endpoint
+func NewStatusError(operation, endpoint string, resp *HTTPResponse) *StatusError {
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Endpoint:   endpoint,
+		Operation:  operation,
+		Body:       resp.ErrorMsg,
+	}
+}
+
+// Error returns the error message, which includes the operation, the status code, the body and the hint
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("status code %d: %s", e.StatusCode, e.Body)
+	if e.Operation != "" {
+		msg = fmt.Sprintf("error %s. Status code %d: %s", e.Operation, e.StatusCode, e.Body)
+	}
+
+	if e.Hint != "" {
+		msg = fmt.Sprintf("%s - %s", msg, e.Hint)
+	}
+
+	return msg
+}
+
+// Is returns true if the target is the sentinel error (ErrUnauthorized, ErrForbidden, ErrNotFound or ErrConflict) that
+// corresponds to the status code
+func (e *StatusError) Is(target error) bool {
+	sentinel, ok := statusSentinels[e.StatusCode]
+
+	return ok && sentinel == target
+}
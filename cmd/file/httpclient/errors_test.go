@@ -0,0 +1,53 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusError(t *testing.T) {
+	t.Run("Message", func(t *testing.T) {
+		err := NewStatusError("", "http://localhost:80/content", &HTTPResponse{StatusCode: http.StatusBadRequest, ErrorMsg: "bad request"})
+		require.EqualError(t, err, "status code 400: bad request")
+		require.Equal(t, "http://localhost:80/content", err.Endpoint)
+
+		err = NewStatusError("updating file index document", "http://localhost:80/operations", &HTTPResponse{StatusCode: http.StatusUnauthorized, ErrorMsg: "unauthorized"})
+		err.Hint = "Did you provide an authorization token?"
+		require.EqualError(t, err, "error updating file index document. Status code 401: unauthorized - Did you provide an authorization token?")
+	})
+
+	t.Run("Sentinels", func(t *testing.T) {
+		tests := []struct {
+			status   int
+			sentinel error
+		}{
+			{http.StatusUnauthorized, ErrUnauthorized},
+			{http.StatusForbidden, ErrForbidden},
+			{http.StatusNotFound, ErrNotFound},
+			{http.StatusConflict, ErrConflict},
+		}
+
+		for _, test := range tests {
+			err := pkgerrors.WithMessage(NewStatusError("", "", &HTTPResponse{StatusCode: test.status}), "wrapped")
+
+			for _, sentinel := range []error{ErrUnauthorized, ErrForbidden, ErrNotFound, ErrConflict} {
+				require.Equal(t, sentinel == test.sentinel, errors.Is(err, sentinel), "status %d", test.status)
+			}
+		}
+
+		err := NewStatusError("", "", &HTTPResponse{StatusCode: http.StatusInternalServerError})
+		require.False(t, errors.Is(err, ErrUnauthorized))
+		require.False(t, errors.Is(err, ErrNotFound))
+		require.False(t, errors.Is(err, ErrConflict))
+	})
+}
@@ -8,9 +8,14 @@ package httpclient
 
 import (
 	"bytes"
+	"crypto/tls"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"sync"
+	"time"
 )
 
 const (
@@ -24,38 +29,136 @@ type HTTPResponse struct {
 	Payload     []byte
 	ErrorMsg    string
 	ContentType string
+
+	// ContentLength is the value of the Content-Length header or -1 if the length is unknown
+	ContentLength int64
+
+	// ETag is the value of the ETag header
+	ETag string
+
+	// RetryAfter is the time to wait, as specified by the Retry-After header, before sending another request
+	RetryAfter time.Duration
+
+	// Header contains all of the response headers
+	Header http.Header
+}
+
+// StreamResponse contains an HTTP response whose body is read as a stream rather than being loaded into
+// memory. If the status code is 200 then the body must be read from Body. Otherwise the body is returned
+// in ErrorMsg. Body is never nil and must be closed by the caller.
+type StreamResponse struct {
+	HTTPResponse
+
+	Body io.ReadCloser
 }
 
 // Client is an HTTP client
 type Client struct {
-	client *http.Client
+	client      *http.Client
+	headers     http.Header
+	retry       RetryPolicy
+	log         io.Writer
+	tokenSource TokenSource
+	debug       io.Writer
+	debugMutex  sync.Mutex
 }
 
 // Opt defines an option for the HTTP client
 type Opt func(c *Client)
 
-// WithTransport sets the transport for the client. This is usually only set for unit tests.
+// WithTransport sets the transport for the client. This is usually only set for unit tests. The TLS and
+// proxy options have no effect if the transport is not an *http.Transport.
 func WithTransport(rt http.RoundTripper) Opt {
 	return func(c *Client) {
 		c.client.Transport = rt
 	}
 }
 
+// WithTLSConfig sets the TLS configuration of the client (see NewTLSConfig)
+func WithTLSConfig(cfg *tls.Config) Opt {
+	return func(c *Client) {
+		if t, ok := c.transport(); ok {
+			t.TLSClientConfig = cfg
+		}
+	}
+}
+
+// WithProxy sets the URL of the proxy through which all requests are sent. By default the proxy is taken from
+// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func WithProxy(proxyURL *url.URL) Opt {
+	return func(c *Client) {
+		if t, ok := c.transport(); ok {
+			t.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+}
+
+// WithTimeout sets the time limit for a request, including reading the response body. A timeout of zero means no timeout.
+func WithTimeout(timeout time.Duration) Opt {
+	return func(c *Client) {
+		c.client.Timeout = timeout
+	}
+}
+
+// WithHeader adds a header that is sent with every request
+func WithHeader(name, value string) Opt {
+	return func(c *Client) {
+		c.headers.Add(name, value)
+	}
+}
+
+// WithRetryPolicy sets the policy with which requests that fail with a transient error are retried. By default
+// requests are not retried.
+func WithRetryPolicy(policy RetryPolicy) Opt {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithLogger sets the writer to which retries are logged
+func WithLogger(w io.Writer) Opt {
+	return func(c *Client) {
+		c.log = w
+	}
+}
+
 // New returns a new HTTP client
 func New(opts ...Opt) *Client {
 	c := &Client{
-		client: &http.Client{},
+		client:  &http.Client{},
+		headers: make(http.Header),
+		retry:   RetryPolicy{MaxAttempts: 1},
+		log:     ioutil.Discard,
 	}
 
+	c.Configure(opts...)
+
+	return c
+}
+
+// Configure applies the given options to the client. This allows the client to be configured from command-line
+// flags, which are only available after the client was created.
+func (c *Client) Configure(opts ...Opt) {
 	for _, opt := range opts {
 		opt(c)
 	}
+}
 
-	return c
+// transport returns the transport of the client. A copy of the default transport is used if the transport wasn't
+// set. False is returned if a custom (non-HTTP) transport was set.
+func (c *Client) transport() (*http.Transport, bool) {
+	if c.client.Transport == nil {
+		c.client.Transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	t, ok := c.client.Transport.(*http.Transport)
+
+	return t, ok
 }
 
 type requestOptions struct {
-	authToken string
+	tokenSource TokenSource
+	idempotent  bool
 }
 
 // RequestOpt sets a request option
@@ -64,90 +167,204 @@ type RequestOpt func(opts *requestOptions)
 // WithAuthToken sets an authorization token in the header
 func WithAuthToken(token string) RequestOpt {
 	return func(opts *requestOptions) {
-		opts.authToken = token
+		opts.tokenSource = staticToken(token)
 	}
 }
 
-// Post posts an HTTP request
-func (c *Client) Post(url string, req []byte, opts ...RequestOpt) (*HTTPResponse, error) {
-	resp, err := c.put(url, req, opts)
-	if err != nil {
-		return nil, err
+// WithIdempotent indicates that the request may safely be sent more than once, so that it's retried after a
+// transient error (see RetryPolicy). Sidetree operations must not be sent with this option since the reveal
+// value in an operation may only be used once.
+func WithIdempotent() RequestOpt {
+	return func(opts *requestOptions) {
+		opts.idempotent = true
 	}
-	return c.handle(resp)
 }
 
-// Get put an HTTP GET request
+// Get sends an HTTP GET request
 func (c *Client) Get(url string, opts ...RequestOpt) (*HTTPResponse, error) {
-	resp, err := c.get(url, opts)
+	return c.handle(c.send(http.MethodGet, url, nil, opts))
+}
+
+// GetStream sends an HTTP GET request and returns the response without loading the body into memory
+func (c *Client) GetStream(url string, opts ...RequestOpt) (*StreamResponse, error) {
+	return c.handleStream(c.send(http.MethodGet, url, nil, opts))
+}
+
+// Head sends an HTTP HEAD request. The response contains the headers (for example, the content type and
+// length) that a GET request would return.
+func (c *Client) Head(url string, opts ...RequestOpt) (*HTTPResponse, error) {
+	return c.handle(c.send(http.MethodHead, url, nil, opts))
+}
+
+// Post sends an HTTP POST request with the given JSON body
+func (c *Client) Post(url string, req []byte, opts ...RequestOpt) (*HTTPResponse, error) {
+	return c.handle(c.send(http.MethodPost, url, req, opts))
+}
+
+// PostStream posts an HTTP request whose body is read from the given reader. The body is streamed to the
+// server (rather than being loaded into memory) with the given content type.
+func (c *Client) PostStream(url, contentType string, body io.Reader, opts ...RequestOpt) (*HTTPResponse, error) {
+	return c.handle(c.postStream(url, contentType, body, opts))
+}
+
+// Put sends an HTTP PUT request with the given JSON body
+func (c *Client) Put(url string, req []byte, opts ...RequestOpt) (*HTTPResponse, error) {
+	return c.handle(c.send(http.MethodPut, url, req, opts))
+}
+
+// Delete sends an HTTP DELETE request
+func (c *Client) Delete(url string, opts ...RequestOpt) (*HTTPResponse, error) {
+	return c.handle(c.send(http.MethodDelete, url, nil, opts))
+}
+
+// handle reads the body of the given response. The error (if any) is returned unchanged.
+func (c *Client) handle(resp *http.Response, err error) (*HTTPResponse, error) {
 	if err != nil {
 		return nil, err
 	}
-	return c.handle(resp)
-}
 
-func (c *Client) handle(resp *http.Response) (*HTTPResponse, error) {
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing HTTP response: %s", err)
-		}
-	}()
+	defer closeResponse(resp)
 
 	gotBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("reading response body failed: %s", err)
 	}
 
-	if status := resp.StatusCode; status != http.StatusOK {
-		return &HTTPResponse{
-			StatusCode: status,
-			ErrorMsg:   string(gotBody),
-		}, nil
-	}
+	httpResp := newHTTPResponse(resp)
 
-	var contentType string
-	hVal := resp.Header["Content-Type"]
-	if len(hVal) > 0 {
-		contentType = hVal[0]
+	if resp.StatusCode != http.StatusOK {
+		httpResp.ErrorMsg = string(gotBody)
+	} else {
+		httpResp.Payload = gotBody
 	}
 
-	return &HTTPResponse{
-		StatusCode:  http.StatusOK,
-		Payload:     gotBody,
-		ContentType: contentType,
-	}, nil
+	return httpResp, nil
 }
 
-func (c *Client) get(url string, opts []RequestOpt) (*http.Response, error) {
-	httpReq, err := http.NewRequest(http.MethodGet, url, nil)
+// handleStream returns the given response without reading the body unless the status code indicates an error
+func (c *Client) handleStream(resp *http.Response, err error) (*StreamResponse, error) {
 	if err != nil {
 		return nil, err
 	}
 
-	options := resolveRequestOptions(opts)
+	if resp.StatusCode == http.StatusOK {
+		return &StreamResponse{
+			HTTPResponse: *newHTTPResponse(resp),
+			Body:         resp.Body,
+		}, nil
+	}
 
-	if options.authToken != "" {
-		httpReq.Header.Set(authHeader, tokenPrefix+options.authToken)
+	httpResp, err := c.handle(resp, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	return c.client.Do(httpReq)
+	return &StreamResponse{
+		HTTPResponse: *httpResp,
+		Body:         http.NoBody,
+	}, nil
 }
 
-func (c *Client) put(url string, req []byte, opts []RequestOpt) (*http.Response, error) {
-	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(req))
-	if err != nil {
-		return nil, err
+// newHTTPResponse returns an HTTPResponse that contains the status and headers (but not the body) of the given response
+func newHTTPResponse(resp *http.Response) *HTTPResponse {
+	retryAfter, _ := parseRetryAfter(resp.Header.Get(retryAfterHeader), time.Now())
+
+	return &HTTPResponse{
+		StatusCode:    resp.StatusCode,
+		ContentType:   resp.Header.Get("Content-Type"),
+		ContentLength: resp.ContentLength,
+		ETag:          resp.Header.Get("ETag"),
+		RetryAfter:    retryAfter,
+		Header:        resp.Header,
 	}
+}
 
-	httpReq.Header.Set("Content-Type", "application/json")
+func closeResponse(resp *http.Response) {
+	if err := resp.Body.Close(); err != nil {
+		fmt.Printf("Error closing HTTP response: %s", err)
+	}
+}
 
-	options := resolveRequestOptions(opts)
+// send sends a request with the given method. If a body is provided then it's sent with content type application/json.
+func (c *Client) send(method, url string, req []byte, opts []RequestOpt) (*http.Response, error) {
+	return c.do(func() (*http.Request, error) {
+		var body io.Reader
+		if req != nil {
+			body = bytes.NewReader(req)
+		}
+
+		httpReq, err := http.NewRequest(method, url, body)
+		if err != nil {
+			return nil, err
+		}
+
+		if req != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+
+		return httpReq, nil
+	}, true, resolveRequestOptions(opts))
+}
+
+// postStream posts the content of the given reader. The request may only be retried if the reader is
+// an io.Seeker, in which case the reader is rewound to its original position before each retry.
+func (c *Client) postStream(url, contentType string, body io.Reader, opts []RequestOpt) (*http.Response, error) {
+	seeker, replayable := body.(io.Seeker)
+
+	var start int64
+
+	if replayable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.do(func() (*http.Request, error) {
+		if replayable {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+
+		reqBody := body
+		if _, ok := body.(io.Closer); ok {
+			// The transport closes the body of the request but the reader is owned by the caller
+			reqBody = ioutil.NopCloser(body)
+		}
+
+		httpReq, err := http.NewRequest(http.MethodPost, url, reqBody)
+		if err != nil {
+			return nil, err
+		}
+
+		httpReq.Header.Set("Content-Type", contentType)
+
+		return httpReq, nil
+	}, replayable, resolveRequestOptions(opts))
+}
+
+// setHeaders sets the headers of the client along with the authorization header of the request
+func (c *Client) setHeaders(httpReq *http.Request, token string) {
+	for name, values := range c.headers {
+		for _, v := range values {
+			httpReq.Header.Add(name, v)
+		}
+	}
+
+	if token != "" {
+		httpReq.Header.Set(authHeader, tokenPrefix+token)
+	}
+}
 
-	if options.authToken != "" {
-		httpReq.Header.Set(authHeader, tokenPrefix+options.authToken)
+// tokenSourceFor returns the token source of the request or, if the request doesn't have one, the default token
+// source of the client (which may be nil)
+func (c *Client) tokenSourceFor(options *requestOptions) TokenSource {
+	if options.tokenSource != nil {
+		return options.tokenSource
 	}
 
-	return c.client.Do(httpReq)
+	return c.tokenSource
 }
 
 func resolveRequestOptions(opts []RequestOpt) *requestOptions {
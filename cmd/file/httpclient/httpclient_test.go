@@ -7,9 +7,17 @@ SPDX-License-Identifier: Apache-2.0
 package httpclient
 
 import (
+	"bytes"
+	"crypto/tls"
+	"encoding/pem"
 	"errors"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
@@ -57,6 +65,26 @@ func TestClient(t *testing.T) {
 		require.Equal(t, respData, resp.Payload)
 	})
 
+	t.Run("PostStream -> success", func(t *testing.T) {
+		transport := mocks.NewTransport().
+			WithPostResponse(
+				&http.Response{
+					StatusCode: http.StatusOK,
+					Header:     header,
+					Body:       mocks.NewResponseBody(respData),
+				},
+			)
+
+		c := New(WithTransport(transport))
+		require.NotNil(t, c)
+
+		resp, err := c.PostStream("http://localhost:80", "image/png", bytes.NewReader(reqData), WithAuthToken("mytoken"))
+		require.NoError(t, err)
+		require.Equal(t, respData, resp.Payload)
+		require.Equal(t, [][]byte{reqData}, transport.PostRequests)
+		require.Equal(t, []string{"image/png"}, transport.PostContentTypes)
+	})
+
 	t.Run("Get error code", func(t *testing.T) {
 		const errMessage = "some error"
 		transport := mocks.NewTransport().
@@ -97,3 +125,255 @@ func TestClient(t *testing.T) {
 		require.Contains(t, err.Error(), errExpected.Error())
 	})
 }
+
+func TestClient_Methods(t *testing.T) {
+	type request struct {
+		method      string
+		contentType string
+		body        string
+	}
+
+	var requests []request
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		requests = append(requests, request{method: r.Method, contentType: r.Header.Get("Content-Type"), body: string(body)})
+
+		if r.URL.Path == "/busy" {
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, err = w.Write([]byte("busy"))
+			require.NoError(t, err)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("ETag", `"v1"`)
+		_, err = w.Write([]byte("content"))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	c := New()
+
+	t.Run("Get", func(t *testing.T) {
+		requests = nil
+
+		resp, err := c.Get(server.URL)
+		require.NoError(t, err)
+		require.Equal(t, "content", string(resp.Payload))
+		require.Equal(t, "text/plain", resp.ContentType)
+		require.Equal(t, int64(7), resp.ContentLength)
+		require.Equal(t, `"v1"`, resp.ETag)
+		require.Equal(t, `"v1"`, resp.Header.Get("ETag"))
+		require.Equal(t, []request{{method: http.MethodGet}}, requests)
+	})
+
+	t.Run("Head", func(t *testing.T) {
+		requests = nil
+
+		resp, err := c.Head(server.URL)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Empty(t, resp.Payload)
+		require.Equal(t, "text/plain", resp.ContentType)
+		require.Equal(t, `"v1"`, resp.ETag)
+		require.Equal(t, []request{{method: http.MethodHead}}, requests)
+	})
+
+	t.Run("Post", func(t *testing.T) {
+		requests = nil
+
+		_, err := c.Post(server.URL, []byte(`{"name":"file1"}`))
+		require.NoError(t, err)
+		require.Equal(t, []request{{method: http.MethodPost, contentType: "application/json", body: `{"name":"file1"}`}}, requests)
+	})
+
+	t.Run("Put", func(t *testing.T) {
+		requests = nil
+
+		_, err := c.Put(server.URL, []byte(`{"name":"file1"}`))
+		require.NoError(t, err)
+		require.Equal(t, []request{{method: http.MethodPut, contentType: "application/json", body: `{"name":"file1"}`}}, requests)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		requests = nil
+
+		_, err := c.Delete(server.URL)
+		require.NoError(t, err)
+		require.Equal(t, []request{{method: http.MethodDelete}}, requests)
+	})
+
+	t.Run("Retry-After", func(t *testing.T) {
+		resp, err := c.Get(server.URL + "/busy")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		require.Equal(t, "busy", resp.ErrorMsg)
+		require.Equal(t, 2*time.Minute, resp.RetryAfter)
+	})
+
+	t.Run("GetStream", func(t *testing.T) {
+		resp, err := c.GetStream(server.URL)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/plain", resp.ContentType)
+		require.Equal(t, int64(7), resp.ContentLength)
+		require.Empty(t, resp.Payload)
+
+		content, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "content", string(content))
+		require.NoError(t, resp.Body.Close())
+
+		resp, err = c.GetStream(server.URL + "/busy")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		require.Equal(t, "busy", resp.ErrorMsg)
+		require.NotNil(t, resp.Body)
+		require.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("GetStream error", func(t *testing.T) {
+		errExpected := errors.New("injected GET error")
+
+		_, err := New(WithTransport(mocks.NewTransport().WithGetError(errExpected))).GetStream("http://localhost:80")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+
+		transport := mocks.NewTransport().WithGetResponse(&http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       &mocks.MockResponseBody{Err: errExpected},
+		})
+
+		_, err = New(WithTransport(transport)).GetStream("http://localhost:80")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+	})
+}
+
+func TestClient_Options(t *testing.T) {
+	t.Run("Headers", func(t *testing.T) {
+		var header http.Header
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+		}))
+		defer server.Close()
+
+		c := New(WithHeader("X-Api-Key", "mykey"), WithHeader("Authorization", "Bearer default"))
+
+		_, err := c.Get(server.URL)
+		require.NoError(t, err)
+		require.Equal(t, "mykey", header.Get("X-Api-Key"))
+		require.Equal(t, "Bearer default", header.Get("Authorization"))
+
+		// The authorization token of the request takes precedence
+		_, err = c.Post(server.URL, []byte("{}"), WithAuthToken("mytoken"))
+		require.NoError(t, err)
+		require.Equal(t, "mykey", header.Get("X-Api-Key"))
+		require.Equal(t, []string{"Bearer mytoken"}, header["Authorization"])
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		c := New()
+		c.Configure(WithTimeout(10 * time.Millisecond))
+
+		_, err := c.Get(server.URL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Client.Timeout exceeded")
+	})
+
+	t.Run("TLS", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		_, err := New().Get(server.URL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "certificate")
+
+		caFile := writeTempFile(t, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+		defer func() { require.NoError(t, os.Remove(caFile)) }()
+
+		tlsConfig, err := NewTLSConfig([]string{caFile}, "", "", false)
+		require.NoError(t, err)
+
+		resp, err := New(WithTLSConfig(tlsConfig)).Get(server.URL)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		tlsConfig, err = NewTLSConfig(nil, "", "", true)
+		require.NoError(t, err)
+
+		resp, err = New(WithTLSConfig(tlsConfig)).Get(server.URL)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Proxy", func(t *testing.T) {
+		proxyURL, err := url.Parse("http://proxy.example.com:3128")
+		require.NoError(t, err)
+
+		c := New(WithProxy(proxyURL))
+
+		req, err := http.NewRequest(http.MethodGet, "http://localhost:80", nil)
+		require.NoError(t, err)
+
+		u, err := c.client.Transport.(*http.Transport).Proxy(req)
+		require.NoError(t, err)
+		require.Equal(t, proxyURL, u)
+	})
+
+	t.Run("Custom transport", func(t *testing.T) {
+		transport := mocks.NewTransport()
+
+		c := New(WithTransport(transport), WithTLSConfig(&tls.Config{}), WithProxy(&url.URL{})) //nolint: gosec
+		require.Equal(t, transport, c.client.Transport)
+	})
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	t.Run("CA file not found", func(t *testing.T) {
+		_, err := NewTLSConfig([]string{"./xxx.crt"}, "", "", false)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error reading CA certificate file [./xxx.crt]")
+	})
+
+	t.Run("No certificates in CA file", func(t *testing.T) {
+		caFile := writeTempFile(t, []byte("invalid"))
+		defer func() { require.NoError(t, os.Remove(caFile)) }()
+
+		_, err := NewTLSConfig([]string{caFile}, "", "", false)
+		require.EqualError(t, err, "no certificates found in CA certificate file ["+caFile+"]")
+	})
+
+	t.Run("Client certificate without key", func(t *testing.T) {
+		_, err := NewTLSConfig(nil, "./client.crt", "", false)
+		require.EqualError(t, err, "both the client certificate and the client key must be provided")
+	})
+
+	t.Run("Client certificate not found", func(t *testing.T) {
+		_, err := NewTLSConfig(nil, "./client.crt", "./client.key", false)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error loading client certificate [./client.crt] and key [./client.key]")
+	})
+}
+
+func writeTempFile(t *testing.T, content []byte) string {
+	f, err := ioutil.TempFile("", "httpclient")
+	require.NoError(t, err)
+
+	_, err = f.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	return f.Name()
+}
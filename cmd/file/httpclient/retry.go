@@ -0,0 +1,251 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultInitialBackoff is the default time to wait before the first retry
+	DefaultInitialBackoff = 500 * time.Millisecond
+
+	// DefaultMaxBackoff is the default maximum time to wait between attempts
+	DefaultMaxBackoff = 30 * time.Second
+
+	retryAfterHeader = "Retry-After"
+)
+
+// retryableStatusCodes are the HTTP status codes for which an idempotent request is retried
+var retryableStatusCodes = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// idempotentMethods are the HTTP methods that may be sent more than once with the same effect
+var idempotentMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodHead:   true,
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+}
+
+// RetryPolicy determines how requests that fail with a transient error are retried.
+//
+// Idempotent requests (GET, HEAD, PUT and DELETE requests and requests sent with WithIdempotent) are retried if the server responds
+// with HTTP status 429, 502, 503 or 504 or if the connection is reset. Any other request (for example, a
+// Sidetree operation, whose reveal value may only be used once) is only retried if the connection to the
+// server could not be established, in which case the request was never sent.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times that a request is sent, including the first attempt
+	MaxAttempts int
+
+	// InitialBackoff is the time to wait before the first retry. The time is doubled for each subsequent
+	// retry (up to MaxBackoff) and a random jitter is applied.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum time to wait between attempts. A request is not retried if the server
+	// asks (using the Retry-After header) to wait for longer than this.
+	MaxBackoff time.Duration
+}
+
+// jitter returns a random duration in the range [d/2, d] so that concurrent clients don't retry in lock step
+var jitter = func(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1)) //nolint: gosec
+}
+
+// do sends the request returned by newRequest and, if the request fails with a transient error, retries it
+// according to the retry policy of the client. A new request is created for each attempt. If the request is
+// rejected with HTTP status 401 and the token source provides a new token then the request is sent once more
+// with the new token. If replayable is false then the body of the request can't be sent again and the request
+// is neither retried nor sent again.
+func (c *Client) do(newRequest func() (*http.Request, error), replayable bool, options *requestOptions) (*http.Response, error) {
+	backoff := c.retry.InitialBackoff
+	tokenSource := c.tokenSourceFor(options)
+	refreshed := false
+
+	for attempt := 1; ; attempt++ {
+		token, err := getToken(tokenSource)
+		if err != nil {
+			return nil, err
+		}
+
+		httpReq, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		c.setHeaders(httpReq, token)
+
+		start := time.Now()
+
+		resp, err := c.client.Do(httpReq)
+
+		c.dump(httpReq, resp, err, time.Since(start))
+
+		if !replayable {
+			return resp, err
+		}
+
+		if !refreshed && isUnauthorized(resp, err) && refreshToken(tokenSource, token) {
+			discard(resp)
+
+			// The request was rejected so sending it again with the new token doesn't count as a retry
+			refreshed = true
+			attempt--
+
+			continue
+		}
+
+		if attempt >= c.retry.MaxAttempts {
+			return resp, err
+		}
+
+		wait, reason, retry := c.shouldRetry(httpReq, resp, err, options.idempotent, backoff)
+		if !retry {
+			return resp, err
+		}
+
+		if resp != nil {
+			discard(resp)
+		}
+
+		fmt.Fprintf(c.log, "Retrying %s %s in %s (attempt %d of %d): %s\n",
+			httpReq.Method, httpReq.URL, wait, attempt+1, c.retry.MaxAttempts, reason)
+
+		time.Sleep(wait)
+
+		backoff *= 2
+		if backoff > c.retry.MaxBackoff {
+			backoff = c.retry.MaxBackoff
+		}
+	}
+}
+
+func getToken(tokenSource TokenSource) (string, error) {
+	if tokenSource == nil {
+		return "", nil
+	}
+
+	return tokenSource.Token()
+}
+
+func isUnauthorized(resp *http.Response, err error) bool {
+	return err == nil && resp.StatusCode == http.StatusUnauthorized
+}
+
+// refreshToken invalidates the given token and returns true if the token source provides a different token
+func refreshToken(tokenSource TokenSource, token string) bool {
+	if tokenSource == nil {
+		return false
+	}
+
+	tokenSource.Invalidate(token)
+
+	newToken, err := tokenSource.Token()
+
+	return err == nil && newToken != token
+}
+
+// shouldRetry returns true, along with the time to wait and the reason, if the given request should be retried
+func (c *Client) shouldRetry(httpReq *http.Request, resp *http.Response, err error, idempotent bool,
+	backoff time.Duration) (time.Duration, string, bool) {
+	idempotent = idempotent || idempotentMethods[httpReq.Method]
+
+	if err != nil {
+		if isDialError(err) || (idempotent && isConnectionReset(err)) {
+			return jitter(backoff), err.Error(), true
+		}
+
+		return 0, "", false
+	}
+
+	if !idempotent || !retryableStatusCodes[resp.StatusCode] {
+		return 0, "", false
+	}
+
+	reason := fmt.Sprintf("status code %d", resp.StatusCode)
+
+	retryAfter, ok := parseRetryAfter(resp.Header.Get(retryAfterHeader), time.Now())
+	if !ok {
+		return jitter(backoff), reason, true
+	}
+
+	if retryAfter > c.retry.MaxBackoff {
+		return 0, "", false
+	}
+
+	return retryAfter, reason, true
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which may contain either the number of
+// seconds to wait or an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if wait := t.Sub(now); wait > 0 {
+		return wait, true
+	}
+
+	return 0, true
+}
+
+// isDialError returns true if the connection to the server could not be established, in which case
+// the request was never sent
+func isDialError(err error) bool {
+	var opErr *net.OpError
+
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isConnectionReset returns true if the connection was closed by the server before the response was received
+func isConnectionReset(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// discard reads and closes the body of a response that won't be returned so that the connection may be reused
+func discard(resp *http.Response) {
+	if resp.Body == nil {
+		return
+	}
+
+	if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+		fmt.Printf("Error reading HTTP response: %s", err)
+	}
+
+	closeResponse(resp)
+}
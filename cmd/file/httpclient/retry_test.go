@@ -0,0 +1,234 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+func TestClient_Retry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	newResponse := func(status int, header http.Header) *http.Response {
+		return &http.Response{StatusCode: status, Header: header, Body: mocks.NewResponseBody([]byte(http.StatusText(status)))}
+	}
+
+	t.Run("Get -> retried", func(t *testing.T) {
+		transport := mocks.NewTransport().WithOrderedGetResponses(
+			newResponse(http.StatusServiceUnavailable, nil),
+			newResponse(http.StatusBadGateway, nil),
+			newResponse(http.StatusOK, nil),
+		)
+
+		w := &mocks.Writer{}
+		c := New(WithTransport(transport), WithRetryPolicy(policy), WithLogger(w))
+
+		resp, err := c.Get("http://localhost:80/file")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Len(t, transport.GetRequests, 3)
+		require.Contains(t, w.Written(), "Retrying GET http://localhost:80/file in ")
+		require.Contains(t, w.Written(), "(attempt 2 of 4): status code 503")
+		require.Contains(t, w.Written(), "(attempt 3 of 4): status code 502")
+	})
+
+	t.Run("Get -> retries exhausted", func(t *testing.T) {
+		transport := mocks.NewTransport().WithGetResponse(newResponse(http.StatusGatewayTimeout, nil))
+
+		c := New(WithTransport(transport), WithRetryPolicy(policy))
+
+		resp, err := c.Get("http://localhost:80/file")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+		require.Len(t, transport.GetRequests, 4)
+	})
+
+	t.Run("Get -> not retryable", func(t *testing.T) {
+		transport := mocks.NewTransport().WithGetResponse(newResponse(http.StatusInternalServerError, nil))
+
+		c := New(WithTransport(transport), WithRetryPolicy(policy))
+
+		resp, err := c.Get("http://localhost:80/file")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		require.Len(t, transport.GetRequests, 1)
+	})
+
+	t.Run("Get -> no retry policy", func(t *testing.T) {
+		transport := mocks.NewTransport().WithGetResponse(newResponse(http.StatusServiceUnavailable, nil))
+
+		resp, err := New(WithTransport(transport)).Get("http://localhost:80/file")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		require.Len(t, transport.GetRequests, 1)
+	})
+
+	t.Run("Get -> Retry-After", func(t *testing.T) {
+		transport := mocks.NewTransport().WithOrderedGetResponses(
+			newResponse(http.StatusTooManyRequests, http.Header{retryAfterHeader: {"0"}}),
+			newResponse(http.StatusOK, nil),
+		)
+
+		w := &mocks.Writer{}
+		c := New(WithTransport(transport), WithRetryPolicy(policy), WithLogger(w))
+
+		resp, err := c.Get("http://localhost:80/file")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, w.Written(), "Retrying GET http://localhost:80/file in 0s (attempt 2 of 4): status code 429")
+
+		// The server asks to wait for longer than the maximum backoff
+		transport = mocks.NewTransport().WithOrderedGetResponses(
+			newResponse(http.StatusTooManyRequests, http.Header{retryAfterHeader: {"120"}}),
+			newResponse(http.StatusOK, nil),
+		)
+
+		c = New(WithTransport(transport), WithRetryPolicy(policy))
+
+		resp, err = c.Get("http://localhost:80/file")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		require.Len(t, transport.GetRequests, 1)
+	})
+
+	t.Run("Get -> connection reset", func(t *testing.T) {
+		transport := mocks.NewTransport().WithGetError(syscall.ECONNRESET)
+
+		c := New(WithTransport(transport), WithRetryPolicy(policy))
+
+		_, err := c.Get("http://localhost:80/file")
+		require.Error(t, err)
+		require.Len(t, transport.GetRequests, 4)
+	})
+
+	t.Run("Post -> Sidetree operation not retried", func(t *testing.T) {
+		transport := mocks.NewTransport().WithPostResponses(
+			newResponse(http.StatusBadGateway, nil),
+			newResponse(http.StatusOK, nil),
+		)
+
+		c := New(WithTransport(transport), WithRetryPolicy(policy))
+
+		resp, err := c.Post("http://localhost:80/sidetree/operations", []byte(`{"type":"update"}`))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		require.Len(t, transport.PostRequests, 1)
+
+		transport = mocks.NewTransport().WithPostError(syscall.ECONNRESET)
+
+		c = New(WithTransport(transport), WithRetryPolicy(policy))
+
+		_, err = c.Post("http://localhost:80/sidetree/operations", []byte(`{"type":"update"}`))
+		require.Error(t, err)
+		require.Len(t, transport.PostRequests, 1)
+	})
+
+	t.Run("Post -> connection refused", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.Close()
+
+		w := &mocks.Writer{}
+		c := New(WithRetryPolicy(policy), WithLogger(w))
+
+		// The request was never sent so it's safe to retry
+		_, err := c.Post(server.URL, []byte(`{"type":"update"}`))
+		require.Error(t, err)
+		require.Contains(t, w.Written(), "(attempt 4 of 4)")
+	})
+
+	t.Run("Post -> idempotent", func(t *testing.T) {
+		transport := mocks.NewTransport().WithPostResponses(
+			newResponse(http.StatusServiceUnavailable, nil),
+			newResponse(http.StatusOK, nil),
+		)
+
+		c := New(WithTransport(transport), WithRetryPolicy(policy))
+
+		resp, err := c.Post("http://localhost:80/content", []byte("content"), WithIdempotent())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, [][]byte{[]byte("content"), []byte("content")}, transport.PostRequests)
+	})
+
+	t.Run("PostStream -> seekable", func(t *testing.T) {
+		transport := mocks.NewTransport().WithPostResponses(
+			newResponse(http.StatusServiceUnavailable, nil),
+			newResponse(http.StatusOK, nil),
+		)
+
+		c := New(WithTransport(transport), WithRetryPolicy(policy))
+
+		body := bytes.NewReader([]byte("xxcontent"))
+		_, err := body.Seek(2, io.SeekStart)
+		require.NoError(t, err)
+
+		resp, err := c.PostStream("http://localhost:80/content", "text/plain", body, WithIdempotent())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, [][]byte{[]byte("content"), []byte("content")}, transport.PostRequests)
+	})
+
+	t.Run("PostStream -> not seekable", func(t *testing.T) {
+		transport := mocks.NewTransport().WithPostResponses(
+			newResponse(http.StatusServiceUnavailable, nil),
+			newResponse(http.StatusOK, nil),
+		)
+
+		c := New(WithTransport(transport), WithRetryPolicy(policy))
+
+		resp, err := c.PostStream("http://localhost:80/content", "text/plain", io.MultiReader(strings.NewReader("content")), WithIdempotent())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		require.Len(t, transport.PostRequests, 1)
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	d, ok := parseRetryAfter("", now)
+	require.False(t, ok)
+	require.Zero(t, d)
+
+	d, ok = parseRetryAfter("5", now)
+	require.True(t, ok)
+	require.Equal(t, 5*time.Second, d)
+
+	d, ok = parseRetryAfter("Thu, 01 Oct 2020 12:00:30 GMT", now)
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, d)
+
+	d, ok = parseRetryAfter("Thu, 01 Oct 2020 11:00:00 GMT", now)
+	require.True(t, ok)
+	require.Zero(t, d)
+
+	_, ok = parseRetryAfter("-5", now)
+	require.False(t, ok)
+
+	_, ok = parseRetryAfter("soon", now)
+	require.False(t, ok)
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := jitter(100 * time.Millisecond)
+		require.True(t, d >= 50*time.Millisecond && d <= 100*time.Millisecond, d)
+	}
+
+	require.Zero(t, jitter(0))
+}
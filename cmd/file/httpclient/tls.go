@@ -0,0 +1,72 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// NewTLSConfig returns a TLS configuration that trusts the CA certificates in the given PEM files (in addition to
+// the system CAs) and, if a client certificate and key file are provided, authenticates the client using the
+// certificate. If insecureSkipVerify is true then the certificate of the server is not verified, which should
+// only be used for development.
+func NewTLSConfig(caCertFiles []string, certFile, keyFile string, insecureSkipVerify bool) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: insecureSkipVerify, //nolint: gosec
+	}
+
+	if len(caCertFiles) > 0 {
+		rootCAs, err := loadRootCAs(caCertFiles)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.RootCAs = rootCAs
+	}
+
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, errors.New("both the client certificate and the client key must be provided")
+		}
+
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "error loading client certificate [%s] and key [%s]", certFile, keyFile)
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+func loadRootCAs(caCertFiles []string) (*x509.CertPool, error) {
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		// The system pool isn't available on all platforms, in which case only the given CAs are trusted
+		rootCAs = x509.NewCertPool()
+	}
+
+	for _, caCertFile := range caCertFiles {
+		pemBytes, err := ioutil.ReadFile(filepath.Clean(caCertFile))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "error reading CA certificate file [%s]", caCertFile)
+		}
+
+		if !rootCAs.AppendCertsFromPEM(pemBytes) {
+			return nil, errors.Errorf("no certificates found in CA certificate file [%s]", caCertFile)
+		}
+	}
+
+	return rootCAs, nil
+}
@@ -0,0 +1,184 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// expiryDelta is subtracted from the lifetime of an OAuth2 token so that the token isn't used just as it expires
+const expiryDelta = 10 * time.Second
+
+// TokenSource provides the bearer token that is sent in the Authorization header of a request
+type TokenSource interface {
+	// Token returns the current token
+	Token() (string, error)
+
+	// Invalidate is called when the given token was rejected (HTTP status 401) so that a new token is returned
+	// by the next call to Token
+	Invalidate(token string)
+}
+
+// WithTokenSource sets the source of the authorization token of the request. If the request is rejected with
+// HTTP status 401 then the token is invalidated and, if the source provides a new token, the request is sent
+// again. (The request was rejected so it's safe to send it again.)
+func WithTokenSource(ts TokenSource) RequestOpt {
+	return func(opts *requestOptions) {
+		opts.tokenSource = ts
+	}
+}
+
+// WithDefaultTokenSource sets the token source that is used for requests that don't specify an authorization
+// token (see WithAuthToken and WithTokenSource)
+func WithDefaultTokenSource(ts TokenSource) Opt {
+	return func(c *Client) {
+		c.tokenSource = ts
+	}
+}
+
+// WithClientCredentials sets the default token source of the client to a source that fetches tokens from an
+// OAuth2 token endpoint using the client credentials grant. The token endpoint is accessed using the same
+// TLS configuration, proxy and timeout as the client.
+func WithClientCredentials(creds ClientCredentials) Opt {
+	return func(c *Client) {
+		c.tokenSource = NewClientCredentialsTokenSource(c.client, creds)
+	}
+}
+
+// staticToken is a token source that always returns the same token
+type staticToken string
+
+func (t staticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+func (t staticToken) Invalidate(string) {}
+
+// ClientCredentials contains the settings of the OAuth2 client credentials grant
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// ClientCredentialsTokenSource fetches tokens from an OAuth2 token endpoint using the client credentials grant.
+// A token is cached until it expires or is invalidated.
+type ClientCredentialsTokenSource struct {
+	client *http.Client
+	creds  ClientCredentials
+	mutex  sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewClientCredentialsTokenSource returns a token source that uses the given HTTP client to fetch tokens
+func NewClientCredentialsTokenSource(client *http.Client, creds ClientCredentials) *ClientCredentialsTokenSource {
+	return &ClientCredentialsTokenSource{
+		client: client,
+		creds:  creds,
+	}
+}
+
+// Token returns the cached token or, if the token has expired (or was invalidated), fetches a new token
+func (s *ClientCredentialsTokenSource) Token() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.token != "" && (s.expiry.IsZero() || time.Now().Before(s.expiry)) {
+		return s.token, nil
+	}
+
+	token, expiry, err := s.fetch()
+	if err != nil {
+		return "", err
+	}
+
+	s.token = token
+	s.expiry = expiry
+
+	return token, nil
+}
+
+// Invalidate removes the given token from the cache
+func (s *ClientCredentialsTokenSource) Invalidate(token string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.token == token {
+		s.token = ""
+	}
+}
+
+func (s *ClientCredentialsTokenSource) fetch() (string, time.Time, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.creds.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.creds.Scopes, " "))
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, s.creds.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.SetBasicAuth(url.QueryEscape(s.creds.ClientID), url.QueryEscape(s.creds.ClientSecret))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error fetching OAuth2 token from [%s]: %s", s.creds.TokenURL, err)
+	}
+
+	defer closeResponse(resp)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error fetching OAuth2 token from [%s]: %s", s.creds.TokenURL, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, &StatusError{
+			StatusCode: resp.StatusCode,
+			Endpoint:   s.creds.TokenURL,
+			Operation:  fmt.Sprintf("fetching OAuth2 token from [%s]", s.creds.TokenURL),
+			Body:       string(body),
+		}
+	}
+
+	tr := &tokenResponse{}
+	if err := json.Unmarshal(body, tr); err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid OAuth2 token response from [%s]: %s", s.creds.TokenURL, err)
+	}
+
+	if tr.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("invalid OAuth2 token response from [%s]: access_token is missing", s.creds.TokenURL)
+	}
+
+	if tr.TokenType != "" && !strings.EqualFold(tr.TokenType, "bearer") {
+		return "", time.Time{}, fmt.Errorf("unsupported OAuth2 token type from [%s]: %s", s.creds.TokenURL, tr.TokenType)
+	}
+
+	var expiry time.Time
+	if tr.ExpiresIn > 0 {
+		expiry = time.Now().Add(time.Duration(tr.ExpiresIn)*time.Second - expiryDelta)
+	}
+
+	return tr.AccessToken, expiry, nil
+}
@@ -0,0 +1,227 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mockAuthServer is an OAuth2 token endpoint that issues a new token for each request
+type mockAuthServer struct {
+	*httptest.Server
+
+	mutex     sync.Mutex
+	issued    int
+	expiresIn int
+	response  string
+	status    int
+}
+
+func newMockAuthServer(t *testing.T) *mockAuthServer {
+	s := &mockAuthServer{expiresIn: 3600, status: http.StatusOK}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "client1", clientID)
+		require.Equal(t, "secret1", secret)
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		require.Equal(t, "read write", r.PostForm.Get("scope"))
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		w.WriteHeader(s.status)
+
+		if s.response != "" {
+			_, err := w.Write([]byte(s.response))
+			require.NoError(t, err)
+
+			return
+		}
+
+		s.issued++
+
+		_, err := fmt.Fprintf(w, `{"access_token":"token%d","token_type":"Bearer","expires_in":%d}`, s.issued, s.expiresIn)
+		require.NoError(t, err)
+	}))
+
+	return s
+}
+
+// mockResourceServer only accepts requests with the given token
+type mockResourceServer struct {
+	*httptest.Server
+
+	mutex      sync.Mutex
+	validToken string
+	requests   int
+}
+
+func newMockResourceServer() *mockResourceServer {
+	s := &mockResourceServer{}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.requests++
+
+		if r.Header.Get(authHeader) != tokenPrefix+s.validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+
+	return s
+}
+
+func (s *mockResourceServer) setValidToken(token string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.validToken = token
+}
+
+func TestClientCredentials(t *testing.T) {
+	creds := func(tokenURL string) ClientCredentials {
+		return ClientCredentials{TokenURL: tokenURL, ClientID: "client1", ClientSecret: "secret1", Scopes: []string{"read", "write"}}
+	}
+
+	t.Run("Token cached", func(t *testing.T) {
+		authServer := newMockAuthServer(t)
+		defer authServer.Close()
+
+		server := newMockResourceServer()
+		defer server.Close()
+
+		server.setValidToken("token1")
+
+		c := New(WithClientCredentials(creds(authServer.URL)))
+
+		for i := 0; i < 3; i++ {
+			resp, err := c.Get(server.URL)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+
+		require.Equal(t, 1, authServer.issued)
+	})
+
+	t.Run("Token expired", func(t *testing.T) {
+		authServer := newMockAuthServer(t)
+		defer authServer.Close()
+
+		// The token expires within the expiry delta so a new token is fetched for each request
+		authServer.expiresIn = 5
+
+		ts := NewClientCredentialsTokenSource(http.DefaultClient, creds(authServer.URL))
+
+		token, err := ts.Token()
+		require.NoError(t, err)
+		require.Equal(t, "token1", token)
+
+		token, err = ts.Token()
+		require.NoError(t, err)
+		require.Equal(t, "token2", token)
+	})
+
+	t.Run("Token refreshed on 401", func(t *testing.T) {
+		authServer := newMockAuthServer(t)
+		defer authServer.Close()
+
+		server := newMockResourceServer()
+		defer server.Close()
+
+		server.setValidToken("token1")
+
+		c := New(WithClientCredentials(creds(authServer.URL)))
+
+		resp, err := c.Get(server.URL)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// The token is revoked so the request is rejected and sent again with a new token. The request
+		// was rejected so it's safe to send it again even if it isn't idempotent.
+		server.setValidToken("token2")
+
+		resp, err = c.Post(server.URL, []byte(`{"type":"update"}`))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 2, authServer.issued)
+		require.Equal(t, 3, server.requests)
+
+		// The new token is also rejected so the 401 is returned
+		server.setValidToken("xxx")
+
+		resp, err = c.Get(server.URL)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		require.Equal(t, 3, authServer.issued)
+		require.Equal(t, 5, server.requests)
+	})
+
+	t.Run("Request token takes precedence", func(t *testing.T) {
+		authServer := newMockAuthServer(t)
+		defer authServer.Close()
+
+		server := newMockResourceServer()
+		defer server.Close()
+
+		server.setValidToken("mytoken")
+
+		c := New(WithClientCredentials(creds(authServer.URL)))
+
+		resp, err := c.Get(server.URL, WithAuthToken("mytoken"))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Zero(t, authServer.issued)
+
+		// A static token can't be refreshed so the request isn't sent again
+		server.setValidToken("xxx")
+
+		resp, err = c.Get(server.URL, WithAuthToken("mytoken"))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		require.Equal(t, 2, server.requests)
+	})
+
+	t.Run("Token endpoint error", func(t *testing.T) {
+		tests := []struct {
+			status      int
+			response    string
+			expectedErr string
+		}{
+			{http.StatusUnauthorized, `{"error":"invalid_client"}`, `Status code 401: {"error":"invalid_client"}`},
+			{http.StatusOK, `{"access_token":`, "invalid OAuth2 token response"},
+			{http.StatusOK, `{"token_type":"Bearer"}`, "access_token is missing"},
+			{http.StatusOK, `{"access_token":"token1","token_type":"mac"}`, "unsupported OAuth2 token type"},
+		}
+
+		for _, test := range tests {
+			authServer := newMockAuthServer(t)
+			authServer.status = test.status
+			authServer.response = test.response
+
+			_, err := New(WithClientCredentials(creds(authServer.URL))).Get("http://localhost:80")
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.expectedErr)
+
+			authServer.Close()
+		}
+
+		_, err := New(WithClientCredentials(creds("http://localhost:1"))).Get("http://localhost:80")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error fetching OAuth2 token from [http://localhost:1]")
+	})
+}
@@ -0,0 +1,170 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package generatecmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+)
+
+const (
+	use      = "generate"
+	desc     = "Generate a key pair"
+	longDesc = `
+The generate command generates a key pair of the given type (P-256, secp256k1 or Ed25519) and writes the private key and public key PEMs to the given directory. The private key file (<name>.key) is only readable by the owner. The public key file is named <name>_public.key. The response is a JSON document that contains the paths of the key files.
+`
+	examples = `
+- Generate the recovery and update key pairs that are used to create a file index document:
+    $ ./fabric file keys generate --dir ./keys --name recovery
+    $ ./fabric file keys generate --dir ./keys --name update
+
+- Generate an Ed25519 key pair:
+    $ ./fabric file keys generate --dir ./keys --name update --type Ed25519
+
+	Response:
+		{
+		  "PrivateKeyFile": "keys/update.key",
+		  "PublicKeyFile": "keys/update_public.key"
+		}
+`
+)
+
+const (
+	dirFlag  = "dir"
+	dirUsage = "The directory to which the key files are written. The directory is created if it doesn't exist. Example: --dir ./keys"
+
+	nameFlag  = "name"
+	nameUsage = "The name of the key pair. The private key is written to <name>.key and the public key is written to <name>_public.key. Example: --name update"
+
+	typeFlag  = "type"
+	typeUsage = "The type of the key pair - one of P-256 (ES256), secp256k1 (ES256K) or Ed25519 (EdDSA). Example: --type Ed25519"
+
+	forceFlag  = "force"
+	forceUsage = "If specified then existing key files are overwritten. Example: --force"
+
+	keyDirPerm os.FileMode = 0700
+)
+
+var errNameRequired = errors.New("name (--name) is required")
+
+// New returns the file keys generate sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	c := &command{
+		Command: basecmd.New(settings, nil),
+	}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   desc,
+		Long:    longDesc,
+		Example: examples,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return c.validate()
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	cmd.SetOutput(c.Settings.Streams.Out)
+	cmd.SilenceUsage = true
+
+	cmd.Flags().StringVar(&c.dir, dirFlag, ".", dirUsage)
+	cmd.Flags().StringVar(&c.name, nameFlag, "", nameUsage)
+	cmd.Flags().StringVar(&c.keyType, typeFlag, string(common.KeyTypeP256), typeUsage)
+	cmd.Flags().BoolVar(&c.force, forceFlag, false, forceUsage)
+
+	return cmd
+}
+
+// command implements the generate command
+type command struct {
+	*basecmd.Command
+
+	dir     string
+	name    string
+	keyType string
+	force   bool
+}
+
+type keyFiles struct {
+	PrivateKeyFile string
+	PublicKeyFile  string
+}
+
+func (c *command) validate() error {
+	if c.name == "" {
+		return errNameRequired
+	}
+
+	if filepath.Base(c.name) != c.name {
+		return errors.Errorf("invalid name [%s]", c.name)
+	}
+
+	for _, keyType := range common.KeyTypes() {
+		if c.keyType == string(keyType) {
+			return nil
+		}
+	}
+
+	return errors.Errorf("invalid key type (--type) [%s] - expecting one of: %s", c.keyType, keyTypes())
+}
+
+func (c *command) run() error {
+	files := &keyFiles{
+		PrivateKeyFile: filepath.Join(c.dir, c.name+".key"),
+		PublicKeyFile:  filepath.Join(c.dir, c.name+"_public.key"),
+	}
+
+	if !c.force {
+		for _, file := range []string{files.PrivateKeyFile, files.PublicKeyFile} {
+			if _, err := os.Stat(file); err == nil {
+				return errors.Errorf("key file [%s] already exists - use --force to overwrite", file)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(c.dir, keyDirPerm); err != nil {
+		return err
+	}
+
+	key, err := common.GenerateKeyOfType(common.KeyType(c.keyType))
+	if err != nil {
+		return err
+	}
+
+	if err := common.WriteKeyPair(key, files.PrivateKeyFile, files.PublicKeyFile); err != nil {
+		return err
+	}
+
+	filesBytes, err := json.Marshal(files)
+	if err != nil {
+		return err
+	}
+
+	return c.Fprint(string(filesBytes))
+}
+
+func keyTypes() string {
+	var types []string
+
+	for _, keyType := range common.KeyTypes() {
+		types = append(types, string(keyType))
+	}
+
+	return strings.Join(types, ", ")
+}
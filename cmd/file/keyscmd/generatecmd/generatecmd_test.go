@@ -0,0 +1,106 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package generatecmd
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+func TestGenerateCmd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	keyDir := filepath.Join(dir, "keys")
+
+	t.Run("Name required", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, &mocks.Writer{}, "--dir", keyDir).Execute(), errNameRequired.Error())
+	})
+
+	t.Run("Invalid name", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, &mocks.Writer{}, "--dir", keyDir, "--name", "../update").Execute(), "invalid name [../update]")
+	})
+
+	t.Run("Invalid key type", func(t *testing.T) {
+		err := newMockCmd(t, &mocks.Writer{}, "--dir", keyDir, "--name", "update", "--type", "RSA").Execute()
+		require.EqualError(t, err, "invalid key type (--type) [RSA] - expecting one of: P-256, secp256k1, Ed25519")
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		w := &mocks.Writer{}
+		require.NoError(t, newMockCmd(t, w, "--dir", keyDir, "--name", "update").Execute())
+
+		files := &keyFiles{}
+		require.NoError(t, json.Unmarshal(w.Bytes, files))
+		require.Equal(t, filepath.Join(keyDir, "update.key"), files.PrivateKeyFile)
+		require.Equal(t, filepath.Join(keyDir, "update_public.key"), files.PublicKeyFile)
+
+		info, err := os.Stat(files.PrivateKeyFile)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		privateKey, err := common.PrivateKeyFromFile(files.PrivateKeyFile)
+		require.NoError(t, err)
+
+		publicKey, err := common.PublicKeyFromFile(files.PublicKeyFile)
+		require.NoError(t, err)
+		require.Equal(t, &privateKey.(*ecdsa.PrivateKey).PublicKey, publicKey)
+	})
+
+	t.Run("Key types", func(t *testing.T) {
+		for _, keyType := range common.KeyTypes() {
+			w := &mocks.Writer{}
+			require.NoError(t, newMockCmd(t, w, "--dir", keyDir, "--name", string(keyType), "--type", string(keyType)).Execute())
+
+			files := &keyFiles{}
+			require.NoError(t, json.Unmarshal(w.Bytes, files))
+
+			privateKey, err := common.PrivateKeyFromFile(files.PrivateKeyFile)
+			require.NoError(t, err)
+
+			kt, err := common.GetKeyType(privateKey)
+			require.NoError(t, err)
+			require.Equal(t, keyType, kt)
+		}
+	})
+
+	t.Run("Already exists", func(t *testing.T) {
+		err := newMockCmd(t, &mocks.Writer{}, "--dir", keyDir, "--name", "update").Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "already exists - use --force to overwrite")
+
+		require.NoError(t, newMockCmd(t, &mocks.Writer{}, "--dir", keyDir, "--name", "update", "--force").Execute())
+	})
+}
+
+func newMockCmd(t *testing.T, w *mocks.Writer, args ...string) *cobra.Command {
+	settings := environment.NewDefaultSettings()
+	settings.Streams.Out = w
+
+	c := New(settings)
+	require.NotNil(t, c)
+
+	c.SetArgs(args)
+
+	return c
+}
@@ -0,0 +1,149 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package importcmd
+
+import (
+	"crypto"
+	"fmt"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+)
+
+const (
+	use      = "import"
+	desc     = "Import the update key of a file index document into a key store"
+	longDesc = `
+The import command adds the current update key of a file index document to a local key store and pre-generates the next update key of the document. Commands that update the file index document (for example, upload --keystore) then use the keys in the key store and rotate them after each successful update.
+
+The keys of each file index document are stored in a sub-directory of the key store that is named after the unique suffix of the document. Any keys that were previously stored for the document are replaced.
+`
+	examples = `
+- Import the update key that was used to create a file index document:
+    $ ./fabric file keys import --keystore ./keystore --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --signingkeyfile ./keys/update.key
+`
+)
+
+const (
+	keyStoreFlag  = "keystore"
+	keyStoreUsage = "The directory of the key store. Example: --keystore ./keystore"
+
+	fileIndexURLFlag  = "idxurl"
+	fileIndexURLUsage = "The URL (or ID) of the file index Sidetree document. Example: --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+
+	signingKeyFlag  = "signingkey"
+	signingKeyUsage = "The private key PEM that is used to sign the next update of the file index document. Example: --signingkey 'MHcCAQEEILmfa4yss8nsTJK2hKl+LAoiwW3p+eQzaHfITI9z8ptpoAoGCCqGSM49AwEHoUQDQgAEMd1/e/Nxh73bK12PEEcNSY9HxnP0N8er9ww9rjq1tNcsqfRjlL0bdTh9Basfn/4JrQHUHc6uS99yjQc+0u2bVg'"
+
+	signingKeyFileFlag  = "signingkeyfile"
+	signingKeyFileUsage = "The file that contains the private key PEM that is used to sign the next update of the file index document. Example: --signingkeyfile ./keys/update.key"
+
+	msgImported = "The update key of file index [%s] was imported into key store [%s]"
+)
+
+var (
+	errKeyStoreRequired     = errors.New("key store (--keystore) is required")
+	errFileIndexURLRequired = errors.New("file index URL (--idxurl) is required")
+)
+
+// New returns the file keys import sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	c := &command{
+		Command: basecmd.New(settings, nil),
+	}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   desc,
+		Long:    longDesc,
+		Example: examples,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return c.validate()
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	cmd.SetOutput(c.Settings.Streams.Out)
+	cmd.SilenceUsage = true
+
+	cmd.Flags().StringVar(&c.keyStore, keyStoreFlag, "", keyStoreUsage)
+	cmd.Flags().StringVar(&c.fileIndexURL, fileIndexURLFlag, "", fileIndexURLUsage)
+	cmd.Flags().StringVar(&c.signingKeyString, signingKeyFlag, "", signingKeyUsage)
+	cmd.Flags().StringVar(&c.signingKeyFile, signingKeyFileFlag, "", signingKeyFileUsage)
+
+	return cmd
+}
+
+// command implements the import command
+type command struct {
+	*basecmd.Command
+
+	keyStore         string
+	fileIndexURL     string
+	signingKeyString string
+	signingKeyFile   string
+	uniqueSuffix     string
+}
+
+func (c *command) validate() error {
+	if c.keyStore == "" {
+		return errKeyStoreRequired
+	}
+
+	if c.fileIndexURL == "" {
+		return errFileIndexURLRequired
+	}
+
+	if _, err := url.Parse(c.fileIndexURL); err != nil {
+		return errors.WithMessagef(err, "invalid file index URL [%s]", c.fileIndexURL)
+	}
+
+	uniqueSuffix, err := common.GetUniqueSuffix(c.fileIndexURL)
+	if err != nil {
+		return err
+	}
+
+	c.uniqueSuffix = uniqueSuffix
+
+	if c.signingKeyFile == "" && c.signingKeyString == "" {
+		return common.ErrSigningKeyOrFileRequired
+	}
+
+	if c.signingKeyFile != "" && c.signingKeyString != "" {
+		return common.ErrOnlyOneOfSigningKeyOrFileRequired
+	}
+
+	return nil
+}
+
+func (c *command) run() error {
+	signingKey, err := c.signingKey()
+	if err != nil {
+		return err
+	}
+
+	if err := common.NewKeyStore(c.keyStore).Import(c.uniqueSuffix, signingKey); err != nil {
+		return err
+	}
+
+	return c.Fprintln(fmt.Sprintf(msgImported, c.uniqueSuffix, c.keyStore))
+}
+
+func (c *command) signingKey() (crypto.PrivateKey, error) {
+	if c.signingKeyFile != "" {
+		return common.PrivateKeyFromFile(c.signingKeyFile)
+	}
+
+	return common.PrivateKeyFromPEM([]byte(c.signingKeyString))
+}
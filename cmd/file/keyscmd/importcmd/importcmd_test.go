@@ -0,0 +1,102 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package importcmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+const (
+	idxURL       = "http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+	uniqueSuffix = "EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+)
+
+func TestImportCmd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	key, err := common.GenerateKey()
+	require.NoError(t, err)
+
+	keyPEM, err := common.PrivateKeyToPEM(key)
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(dir, "update.key")
+	require.NoError(t, ioutil.WriteFile(keyFile, keyPEM, 0600))
+
+	keyStore := filepath.Join(dir, "keystore")
+
+	t.Run("Key store required", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, &mocks.Writer{}).Execute(), errKeyStoreRequired.Error())
+	})
+
+	t.Run("File index URL required", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, &mocks.Writer{}, "--keystore", keyStore).Execute(), errFileIndexURLRequired.Error())
+	})
+
+	t.Run("Invalid file index URL", func(t *testing.T) {
+		err := newMockCmd(t, &mocks.Writer{}, "--keystore", keyStore, "--idxurl", "fileindex").Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unique suffix not provided")
+	})
+
+	t.Run("Signing key required", func(t *testing.T) {
+		err := newMockCmd(t, &mocks.Writer{}, "--keystore", keyStore, "--idxurl", idxURL).Execute()
+		require.EqualError(t, err, common.ErrSigningKeyOrFileRequired.Error())
+
+		err = newMockCmd(t, &mocks.Writer{}, "--keystore", keyStore, "--idxurl", idxURL, "--signingkey", string(keyPEM), "--signingkeyfile", keyFile).Execute()
+		require.EqualError(t, err, common.ErrOnlyOneOfSigningKeyOrFileRequired.Error())
+	})
+
+	t.Run("Invalid signing key", func(t *testing.T) {
+		err := newMockCmd(t, &mocks.Writer{}, "--keystore", keyStore, "--idxurl", idxURL, "--signingkey", "xxx").Execute()
+		require.EqualError(t, err, common.ErrPrivateKeyNotFoundInPEM.Error())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		w := &mocks.Writer{}
+		require.NoError(t, newMockCmd(t, w, "--keystore", keyStore, "--idxurl", idxURL, "--signingkeyfile", keyFile).Execute())
+		require.Equal(t, fmt.Sprintf(msgImported, uniqueSuffix, keyStore)+"\n", string(w.Bytes))
+
+		current, next, err := common.NewKeyStore(keyStore).Get(uniqueSuffix)
+		require.NoError(t, err)
+		require.Equal(t, key, current)
+		require.NotNil(t, next)
+	})
+
+	t.Run("With signing key PEM", func(t *testing.T) {
+		require.NoError(t, newMockCmd(t, &mocks.Writer{}, "--keystore", keyStore, "--idxurl", idxURL, "--signingkey", string(keyPEM)).Execute())
+	})
+}
+
+func newMockCmd(t *testing.T, w *mocks.Writer, args ...string) *cobra.Command {
+	settings := environment.NewDefaultSettings()
+	settings.Streams.Out = w
+
+	c := New(settings)
+	require.NotNil(t, c)
+
+	c.SetArgs(args)
+
+	return c
+}
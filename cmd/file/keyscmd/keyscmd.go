@@ -0,0 +1,41 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package keyscmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/keyscmd/generatecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/keyscmd/importcmd"
+)
+
+const (
+	use      = "keys"
+	desc     = "Manage the keys of file index documents"
+	longDesc = "The keys command allows you to generate the key pairs that are used to create and update file index documents and to manage a local key store that tracks the update keys of each file index document"
+)
+
+// New returns the file keys sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: desc,
+		Long:  longDesc,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	cmd.AddCommand(
+		generatecmd.New(settings),
+		importcmd.New(settings),
+	)
+
+	return cmd
+}
@@ -0,0 +1,31 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package keyscmd
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+func TestNew(t *testing.T) {
+	cmd := New(environment.NewDefaultSettings())
+	require.NotNil(t, cmd)
+
+	w := &mocks.Writer{}
+	cmd.SetOutput(w)
+
+	require.NoError(t, cmd.Execute())
+
+	// Make sure that the generate command was added
+	require.Contains(t, w.Written(), "Generate a key pair")
+	// Make sure that the import command was added
+	require.Contains(t, w.Written(), "Import the update key of a file index document into a key store")
+}
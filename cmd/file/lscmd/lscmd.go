@@ -0,0 +1,347 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package lscmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+const (
+	use      = "ls"
+	alias    = "show-index"
+	desc     = "List the files in a file index document"
+	longDesc = `
+The ls command (or its alias, show-index) lists the files that are indexed by a Sidetree file index document along with their DCAS IDs. The Sidetree metadata of the file index document (for example, whether or not the document has been published and the commitment for the next update) is also displayed. If the --details option is specified then the content type and size of each file are requested from the content endpoint (without downloading the content). The content type and size of a file that was uploaded in chunks are taken from its manifest.
+`
+	examples = `
+- List the files in a file index document:
+    $ ./fabric file ls --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==
+
+	Response:
+		File index:        file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==
+		Published:         true
+		Update commitment: EiDS6W7kmwthx8UxJUI6SV1ttuQ4EpvGO5hSrgFWwYXQ5A
+
+		NAME                 DCAS ID                                        BASE PATH
+		person.schema.json   TbVyraOqG00TacPQH5WwWGnxkszpYSEhBKRyX_f25JI=   /content
+
+- List the files in a file index document along with their content type and size:
+    $ ./fabric file ls --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --details
+`
+)
+
+const (
+	fileIndexURLFlag  = "idxurl"
+	fileIndexURLUsage = "The URL of the file index Sidetree document. Example: --idxurl http://localhost:48326/file/identifiers/file:idx:1234"
+
+	urlFlag  = "url"
+	urlUsage = "The URL of the content endpoint from which to retrieve file details. If not specified then the URL is derived from the host of --idxurl and the base path of the file index. Example: --url http://localhost:48326/content"
+
+	detailsFlag  = "details"
+	detailsUsage = "If specified then the content type and size of each file are requested from the content endpoint and displayed. Example: --details"
+
+	msgNoFiles = "The file index document does not contain any files"
+
+	notAvailable = "-"
+)
+
+var errFileIndexURLRequired = errors.New("file index URL (--idxurl) is required")
+
+type httpClient interface {
+	Get(url string, opts ...httpclient.RequestOpt) (*httpclient.HTTPResponse, error)
+	Head(url string, opts ...httpclient.RequestOpt) (*httpclient.HTTPResponse, error)
+}
+
+// New returns the file ls sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	return newCmd(settings, httpclient.New())
+}
+
+func newCmd(settings *environment.Settings, client httpClient) *cobra.Command {
+	c := &command{
+		Command: basecmd.New(settings, nil),
+		client:  client,
+	}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Aliases: []string{alias},
+		Short:   desc,
+		Long:    longDesc,
+		Example: examples,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return c.validate()
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	c.Settings = settings
+	cmd.SetOutput(c.Settings.Streams.Out)
+	cmd.SilenceUsage = true
+
+	cmd.Flags().StringVar(&c.fileIndexURL, fileIndexURLFlag, "", fileIndexURLUsage)
+	cmd.Flags().StringVar(&c.url, urlFlag, "", urlUsage)
+	cmd.Flags().BoolVar(&c.details, detailsFlag, false, detailsUsage)
+	c.authOpts.AddFlags(cmd)
+	c.authOpts.AddContentFlags(cmd)
+	c.httpOpts.AddFlags(cmd)
+
+	return cmd
+}
+
+// command implements the ls command
+type command struct {
+	*basecmd.Command
+	client httpClient
+
+	// Flags
+	fileIndexURL string
+	url          string
+	details      bool
+	httpOpts     common.HTTPClientOptions
+	authOpts     common.AuthOptions
+}
+
+// fileDetails contains the details of a file that are retrieved from the content endpoint
+type fileDetails struct {
+	contentType string
+	size        string
+	err         error
+}
+
+func (c *command) validate() error {
+	if c.fileIndexURL == "" {
+		return errFileIndexURLRequired
+	}
+
+	if err := c.httpOpts.Configure(c.client, c.Settings.Streams.Err); err != nil {
+		return err
+	}
+
+	if err := c.authOpts.Configure(c.client); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *command) run() error {
+	fileIdxDoc, resolution, err := common.ResolveFileIndexDoc(c.client, c.fileIndexURL, c.authOpts.Token)
+	if err != nil {
+		return err
+	}
+
+	header, err := c.header(fileIdxDoc, resolution)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for name := range fileIdxDoc.FileIndex.Mappings {
+		if !common.IsReservedMapping(name) {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return c.Fprintln(fmt.Sprintf("%s\n%s", header, msgNoFiles))
+	}
+
+	sort.Strings(names)
+
+	details, err := c.getDetails(fileIdxDoc.FileIndex, names)
+	if err != nil {
+		return err
+	}
+
+	files, err := table(fileIdxDoc.FileIndex, names, details)
+	if err != nil {
+		return err
+	}
+
+	return c.Fprint(fmt.Sprintf("%s\n%s", header, files))
+}
+
+// header returns the ID and Sidetree metadata of the file index document
+func (c *command) header(fileIdxDoc *model.FileIndexDoc, resolution *model.DIDResolution) (string, error) {
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+
+	fmt.Fprintf(w, "File index:\t%s\n", fileIdxDoc.ID)
+
+	metadata, err := common.GetMethodMetadata(resolution)
+	if err != nil {
+		return "", err
+	}
+
+	if metadata != nil {
+		fmt.Fprintf(w, "Published:\t%t\n", metadata.Published)
+
+		if metadata.UpdateCommitment != "" {
+			fmt.Fprintf(w, "Update commitment:\t%s\n", metadata.UpdateCommitment)
+		}
+
+		if metadata.RecoveryCommitment != "" {
+			fmt.Fprintf(w, "Recovery commitment:\t%s\n", metadata.RecoveryCommitment)
+		}
+	}
+
+	if resolution != nil && len(resolution.ResolverMetadata) != 0 && string(resolution.ResolverMetadata) != "null" {
+		fmt.Fprintf(w, "Resolver metadata:\t%s\n", resolution.ResolverMetadata)
+	}
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// getDetails retrieves the content type and size of each of the given files (using a HEAD request so
+// that the content isn't downloaded). Nil is returned if details were not requested.
+func (c *command) getDetails(fileIdx model.FileIndex, names []string) (map[string]*fileDetails, error) {
+	if !c.details {
+		return nil, nil
+	}
+
+	contentURL := c.url
+	if contentURL == "" {
+		var err error
+		contentURL, err = common.GetContentURL(c.fileIndexURL, fileIdx.BasePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var reqOpts []httpclient.RequestOpt
+	if c.authOpts.ContentToken != "" {
+		reqOpts = append(reqOpts, httpclient.WithAuthToken(c.authOpts.ContentToken))
+	}
+
+	details := make(map[string]*fileDetails)
+
+	for _, name := range names {
+		details[name] = c.getFileDetails(contentURL, name, reqOpts)
+	}
+
+	return details, nil
+}
+
+// getFileDetails retrieves the content type and size of the given file. If the file was uploaded in chunks then
+// the content type and size of the file are taken from its manifest.
+func (c *command) getFileDetails(contentURL, name string, reqOpts []httpclient.RequestOpt) *fileDetails {
+	fileURL := common.GetFileURL(contentURL, name)
+
+	resp, err := c.client.Head(fileURL, reqOpts...)
+	if err != nil {
+		return &fileDetails{err: err}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &fileDetails{err: common.NewStatusError("", fileURL, resp, common.ContentAuthTokenFlag)}
+	}
+
+	if resp.ContentType != model.ManifestContentType {
+		return &fileDetails{
+			contentType: resp.ContentType,
+			size:        formatSize(resp.ContentLength),
+		}
+	}
+
+	// The manifest is small so it's retrieved in full
+	resp, err = c.client.Get(fileURL, reqOpts...)
+	if err != nil {
+		return &fileDetails{err: err}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &fileDetails{err: common.NewStatusError("", fileURL, resp, common.ContentAuthTokenFlag)}
+	}
+
+	manifest := &model.FileManifest{}
+	if err := json.Unmarshal(resp.Payload, manifest); err != nil {
+		return &fileDetails{err: errors.WithMessage(err, "invalid manifest")}
+	}
+
+	return &fileDetails{
+		contentType: manifest.ContentType,
+		size:        formatSize(manifest.Size),
+	}
+}
+
+// formatSize returns the given content length or, if the length is unknown, "-"
+func formatSize(contentLength int64) string {
+	if contentLength < 0 {
+		return notAvailable
+	}
+
+	return strconv.FormatInt(contentLength, 10)
+}
+
+// table returns a table of the given files. If details are provided then the content type and
+// size columns are included along with any errors that occurred while retrieving the details.
+func table(fileIdx model.FileIndex, names []string, details map[string]*fileDetails) (string, error) {
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 0, 3, ' ', 0)
+
+	if details == nil {
+		fmt.Fprintln(w, "NAME\tDCAS ID\tBASE PATH")
+	} else {
+		fmt.Fprintln(w, "NAME\tDCAS ID\tBASE PATH\tCONTENT TYPE\tSIZE")
+	}
+
+	var errs []string
+
+	for _, name := range names {
+		if details == nil {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", name, fileIdx.Mappings[name], fileIdx.BasePath)
+			continue
+		}
+
+		d := details[name]
+		contentType, size := d.contentType, d.size
+
+		if d.err != nil {
+			contentType, size = notAvailable, notAvailable
+			errs = append(errs, fmt.Sprintf("Unable to retrieve details of [%s]: %s", name, d.err))
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name, fileIdx.Mappings[name], fileIdx.BasePath, contentType, size)
+	}
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	for _, e := range errs {
+		buf.WriteString(fmt.Sprintf("\n%s", e))
+	}
+
+	if len(errs) > 0 {
+		buf.WriteString("\n")
+	}
+
+	return buf.String(), nil
+}
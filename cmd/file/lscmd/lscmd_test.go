@@ -0,0 +1,205 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package lscmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+const (
+	idxURL     = "http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+	contentURL = "http://localhost:48326/content"
+	docID      = "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+)
+
+func TestLsCmd_New(t *testing.T) {
+	c := New(environment.NewDefaultSettings())
+	require.NotNil(t, c)
+	require.True(t, c.HasAlias("show-index"))
+}
+
+func TestLsCmd_InvalidOptions(t *testing.T) {
+	require.EqualError(t, newMockCmd(t, &mocks.Writer{}, nil).Execute(), errFileIndexURLRequired.Error())
+	require.EqualError(t, newMockCmd(t, &mocks.Writer{}, nil, "--idxurl", idxURL, "--authtoken", "mytoken", "--authtoken-env", "LSCMD_TEST_TOKEN").Execute(),
+		"only one of --authtoken, --authtoken-file or --authtoken-env may be specified")
+	require.EqualError(t, newMockCmd(t, &mocks.Writer{}, nil, "--idxurl", idxURL, "--oauth2-client-id", "client1").Execute(),
+		"the OAuth2 token URL (--oauth2-token-url), client ID (--oauth2-client-id) and client secret file (--oauth2-client-secret-file) are required for OAuth2")
+}
+
+func TestLsCmd(t *testing.T) {
+	fileIdxDoc := &model.FileIndexDoc{
+		ID: docID,
+		FileIndex: model.FileIndex{
+			BasePath: "/content",
+			Mappings: map[string]string{
+				".":                  "/content",
+				"person.schema.json": "TbVyraOqG00TacPQH5WwWGnxkszpYSEhBKRyX_f25JI=",
+				"raised-hand.png":    "k1fqlkDdtmkTBVTHQgvpJbhTEch2XP0cn0C-DuP-9pE=",
+			},
+		},
+	}
+
+	fileIdxDocBytes, err := json.Marshal(fileIdxDoc)
+	require.NoError(t, err)
+
+	didResolutionBytes, err := json.Marshal(model.DIDResolution{
+		DIDDocument:      fileIdxDocBytes,
+		ResolverMetadata: []byte(`{"driver":"sidetree"}`),
+		MethodMetadata:   []byte(`{"published":true,"updateCommitment":"EiDS6W7kmwthx8UxJUI6SV1ttuQ4EpvGO5hSrgFWwYXQ5A"}`),
+	})
+	require.NoError(t, err)
+
+	newResponse := func(status int, contentType string, payload []byte) *http.Response {
+		return &http.Response{
+			StatusCode:    status,
+			Header:        map[string][]string{"Content-Type": {contentType}},
+			Body:          mocks.NewResponseBody(payload),
+			ContentLength: int64(len(payload)),
+		}
+	}
+
+	newTransport := func() *mocks.MockTransport {
+		return mocks.NewTransport().
+			WithGetResponseForURL(idxURL, newResponse(http.StatusOK, "application/json", didResolutionBytes)).
+			WithGetResponseForURL(contentURL+"/person.schema.json", newResponse(http.StatusOK, "application/json", []byte(`{"name":"person"}`))).
+			WithGetResponse(newResponse(http.StatusNotFound, "text/plain", []byte("not found")))
+	}
+
+	transport := newTransport()
+
+	t.Run("Success", func(t *testing.T) {
+		w := &mocks.Writer{}
+		require.NoError(t, newMockCmd(t, w, transport, "--idxurl", idxURL).Execute())
+
+		require.Equal(t, `File index:        file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==
+Published:         true
+Update commitment: EiDS6W7kmwthx8UxJUI6SV1ttuQ4EpvGO5hSrgFWwYXQ5A
+Resolver metadata: {"driver":"sidetree"}
+
+NAME                 DCAS ID                                        BASE PATH
+person.schema.json   TbVyraOqG00TacPQH5WwWGnxkszpYSEhBKRyX_f25JI=   /content
+raised-hand.png      k1fqlkDdtmkTBVTHQgvpJbhTEch2XP0cn0C-DuP-9pE=   /content
+`, string(w.Bytes))
+	})
+
+	t.Run("With details", func(t *testing.T) {
+		transport := newTransport()
+
+		w := &mocks.Writer{}
+		require.NoError(t, newMockCmd(t, w, transport, "--idxurl", idxURL, "--details").Execute())
+
+		// The content of the files isn't downloaded
+		require.Equal(t, []string{idxURL}, transport.GetRequests)
+		require.Equal(t, []string{contentURL + "/person.schema.json", contentURL + "/raised-hand.png"}, transport.HeadRequests)
+
+		require.Contains(t, w.Written(), "NAME                 DCAS ID                                        BASE PATH   CONTENT TYPE       SIZE")
+		require.Contains(t, w.Written(), "person.schema.json   TbVyraOqG00TacPQH5WwWGnxkszpYSEhBKRyX_f25JI=   /content    application/json   17")
+		require.Contains(t, w.Written(), "raised-hand.png      k1fqlkDdtmkTBVTHQgvpJbhTEch2XP0cn0C-DuP-9pE=   /content    -                  -")
+		require.Contains(t, w.Written(), "Unable to retrieve details of [raised-hand.png]: status code 404")
+	})
+
+	t.Run("With details - GET error", func(t *testing.T) {
+		errExpected := errors.New("injected GET error")
+
+		transport := mocks.NewTransport().
+			WithGetResponseForURL(idxURL, newResponse(http.StatusOK, "application/json", fileIdxDocBytes)).
+			WithGetError(errExpected)
+
+		w := &mocks.Writer{}
+		require.NoError(t, newMockCmd(t, w, transport, "--idxurl", idxURL, "--url", contentURL, "--details").Execute())
+		require.NotContains(t, w.Written(), "Published")
+		require.Contains(t, w.Written(), errExpected.Error())
+	})
+
+	t.Run("With details - chunked file", func(t *testing.T) {
+		manifestBytes, err := json.Marshal(&model.FileManifest{ContentType: "video/mp4", Size: 10485760, Chunks: []string{"chunk1", "chunk2", "chunk3"}})
+		require.NoError(t, err)
+
+		docBytes, err := json.Marshal(&model.FileIndexDoc{
+			ID: docID,
+			FileIndex: model.FileIndex{
+				BasePath: "/content",
+				Mappings: map[string]string{
+					".":              "/content",
+					"video.mp4":      "manifestID",
+					".chunks/chunk1": "chunk1",
+					"bad.mp4":        "badManifestID",
+					"private.txt":    "privateID",
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		transport := mocks.NewTransport().
+			WithGetResponseForURL(idxURL, newResponse(http.StatusOK, "application/json", docBytes)).
+			WithGetResponseForURL(contentURL+"/video.mp4", newResponse(http.StatusOK, model.ManifestContentType, manifestBytes)).
+			WithGetResponseForURL(contentURL+"/bad.mp4", newResponse(http.StatusOK, model.ManifestContentType, []byte("{"))).
+			WithGetResponseForURL(contentURL+"/private.txt", newResponse(http.StatusUnauthorized, "text/plain", nil)).
+			WithGetResponse(newResponse(http.StatusNotFound, "text/plain", []byte("not found")))
+
+		w := &mocks.Writer{}
+		require.NoError(t, newMockCmd(t, w, transport, "--idxurl", idxURL, "--details").Execute())
+
+		// The content type and size of the file are taken from the manifest (rather than the manifest itself)
+		require.Contains(t, w.Written(), "video.mp4     manifestID      /content    video/mp4      10485760")
+		require.Contains(t, w.Written(), "Unable to retrieve details of [bad.mp4]: invalid manifest")
+		require.Contains(t, w.Written(), "Unable to retrieve details of [private.txt]: status code 401: ")
+		require.Contains(t, w.Written(), "Did you provide an authorization token (--contentauthtoken)?")
+		require.NotContains(t, w.Written(), ".chunks/")
+	})
+
+	t.Run("No files", func(t *testing.T) {
+		docBytes, err := json.Marshal(&model.FileIndexDoc{
+			ID:        docID,
+			FileIndex: model.FileIndex{BasePath: "/content", Mappings: map[string]string{".": "/content"}},
+		})
+		require.NoError(t, err)
+
+		transport := mocks.NewTransport().WithGetResponse(newResponse(http.StatusOK, "application/json", docBytes))
+
+		w := &mocks.Writer{}
+		require.NoError(t, newMockCmd(t, w, transport, "--idxurl", idxURL).Execute())
+		require.Contains(t, w.Written(), msgNoFiles)
+	})
+
+	t.Run("Index not found", func(t *testing.T) {
+		transport := mocks.NewTransport().WithGetResponse(newResponse(http.StatusNotFound, "text/plain", []byte("not found")))
+
+		err := newMockCmd(t, &mocks.Writer{}, transport, "--idxurl", idxURL).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+	})
+}
+
+func newMockCmd(t *testing.T, w io.Writer, transport http.RoundTripper, args ...string) *cobra.Command {
+	settings := environment.NewDefaultSettings()
+	settings.Streams.Out = w
+	settings.Streams.In = &mocks.Reader{}
+
+	settings.Config.CurrentContext = "testctx"
+	settings.Config.Contexts[settings.Config.CurrentContext] = &environment.Context{}
+
+	c := newCmd(settings, httpclient.New(httpclient.WithTransport(transport)))
+	require.NotNil(t, c)
+
+	c.SetArgs(args)
+
+	return c
+}
@@ -21,6 +21,24 @@ type FileIndex struct {
 	Mappings map[string]string `json:"mappings,omitempty"`
 }
 
+// UploadFile contains the content of a file (along with its content type) that is stored in DCAS
+type UploadFile struct {
+	ContentType string `json:"contentType"`
+	Content     []byte `json:"content"`
+}
+
+// ManifestContentType is the content type of a file manifest. A manifest is stored in DCAS in place of a
+// large file that was uploaded as multiple chunks.
+const ManifestContentType = "application/vnd.trustbloc.file-manifest+json"
+
+// FileManifest describes a file that was uploaded to DCAS as multiple chunks. The file is reassembled by
+// concatenating the chunks in order.
+type FileManifest struct {
+	ContentType string   `json:"contentType"`
+	Size        int64    `json:"size"`
+	Chunks      []string `json:"chunks"`
+}
+
 // DIDResolution did resolution
 type DIDResolution struct {
 	Context          interface{}     `json:"@context"`
@@ -28,3 +46,10 @@ type DIDResolution struct {
 	ResolverMetadata json.RawMessage `json:"resolverMetadata"`
 	MethodMetadata   json.RawMessage `json:"methodMetadata"`
 }
+
+// MethodMetadata contains the Sidetree method metadata of a resolved document
+type MethodMetadata struct {
+	Published          bool   `json:"published"`
+	UpdateCommitment   string `json:"updateCommitment,omitempty"`
+	RecoveryCommitment string `json:"recoveryCommitment,omitempty"`
+}
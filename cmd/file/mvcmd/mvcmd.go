@@ -0,0 +1,132 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mvcmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+)
+
+const (
+	use      = "mv <old name> <new name>"
+	desc     = "Rename a file in a file index document"
+	longDesc = `
+The mv command renames a file in a Sidetree file index document by moving the mapping of the old file name to the new file name. The DCAS ID of the file is unchanged. The operation fails if a file with the new name already exists in the file index document.
+`
+	examples = `
+- Rename a file in the given file index document:
+    $ ./fabric file mv person.schema.json person.v1.schema.json --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --signingkeyfile ./keys/update.key --nextupdatekeyfile ./keys/next_update_public.key --noprompt
+`
+)
+
+const (
+	msgMoved = "File [%s] was renamed to [%s]"
+)
+
+var (
+	errNamesRequired = errors.New("the old name and the new name of the file are required")
+	errSameName      = errors.New("the old name and the new name of the file must be different")
+)
+
+// New returns the file mv sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	return newCmd(settings, httpclient.New())
+}
+
+func newCmd(settings *environment.Settings, client common.HTTPClient) *cobra.Command {
+	c := &command{}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   desc,
+		Long:    longDesc,
+		Example: examples,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return c.validate(args)
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	c.UpdateBaseCommand = common.NewUpdateBaseCommand(settings, client, cmd)
+
+	return cmd
+}
+
+// command implements the mv command
+type command struct {
+	*common.UpdateBaseCommand
+
+	oldName string
+	newName string
+}
+
+func (c *command) validate(args []string) error {
+	if len(args) != 2 || args[0] == "" || args[1] == "" {
+		return errNamesRequired
+	}
+
+	c.oldName = args[0]
+	c.newName = args[1]
+
+	if c.oldName == c.newName {
+		return errSameName
+	}
+
+	for _, name := range args {
+		if common.IsReservedMapping(name) {
+			return errors.Errorf("invalid file name [%s]", name)
+		}
+	}
+
+	return c.Validate()
+}
+
+func (c *command) run() error {
+	fileIdxDoc, err := c.GetFileIndexDoc()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := fileIdxDoc.FileIndex.Mappings[c.oldName]; !ok {
+		return errors.Errorf("file [%s] not found in file index document [%s]", c.oldName, c.FileIndexURL)
+	}
+
+	if _, ok := fileIdxDoc.FileIndex.Mappings[c.newName]; ok {
+		return errors.Errorf("file [%s] already exists in file index document [%s]", c.newName, c.FileIndexURL)
+	}
+
+	confirmed, err := c.Confirm(fmt.Sprintf("Renaming file [%s] to [%s] in file index document [%s]", c.oldName, c.newName, c.FileIndexURL))
+	if err != nil {
+		return err
+	}
+
+	if !confirmed {
+		return c.Fprintln(common.MsgAborted)
+	}
+
+	err = c.UpdateFileIndex([]common.JSONPatch{
+		{
+			Op:   common.JSONPatchMoveOp,
+			From: common.MappingPath(c.oldName),
+			Path: common.MappingPath(c.newName),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.Fprintln(fmt.Sprintf(msgMoved, c.oldName, c.newName))
+}
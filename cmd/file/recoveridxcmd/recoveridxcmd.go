@@ -0,0 +1,386 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package recoveridxcmd
+
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+const (
+	use      = "recoveridx"
+	desc     = "Recover a file index document"
+	longDesc = `
+The recoveridx command recovers a Sidetree file index document, for example, if the update key of the document was lost or compromised. The recover request is signed with the recovery key of the document and commits to a new recovery key and a new update key.
+
+By default the current mappings of the file index document are kept. The base path may be changed (--path), the mappings may be cleared (--clearmappings) or the content of the document may be replaced with the file index document in the given file (--docfile).
+
+If a key store is specified (--keystore) then a new update key is generated and imported into the key store instead of providing the next update key. The new key is stored in the key store as a pending update key before the recover request is submitted and it becomes the current update key once the request succeeds, so the key is not lost if the command is interrupted.
+`
+	examples = `
+- Recover a file index document, keeping its current mappings:
+    $ ./fabric file recoveridx --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --recoverykeyfile ./keys/recovery.key --nextrecoverykeyfile ./keys/next_recovery_public.key --nextupdatekeyfile ./keys/next_update_public.key --noprompt
+
+- Recover a file index document with a new base path and no mappings, and track its new update key in the given key store:
+    $ ./fabric file recoveridx --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --path /schemas --clearmappings --recoverykeyfile ./keys/recovery.key --nextrecoverykeyfile ./keys/next_recovery_public.key --keystore ./keystore --noprompt
+`
+)
+
+const (
+	nextRecoveryKeyFlag  = "nextrecoverykey"
+	nextRecoveryKeyUsage = "The public key PEM used for creating the commitment for the next recovery of the document. Example: --nextrecoverykey 'MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEXlp4fWF5rgLthKr20tsJ0tBIE6UmrGuAC8iVG/DaedkSt7HihCx/t2BGjooduaKwEIOmPjx2zBsbkbFrYhhnVw'"
+
+	nextRecoveryKeyFileFlag  = "nextrecoverykeyfile"
+	nextRecoveryKeyFileUsage = "The file that contains the public key PEM used for creating the commitment for the next recovery of the document. Example: --nextrecoverykeyfile ./keys/next_recovery_public.key"
+
+	nextUpdateKeyFlag  = "nextupdatekey"
+	nextUpdateKeyUsage = "The public key PEM used for creating the commitment for the next update of the document. Example: --nextupdatekey 'MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEFMy2n9jYZChYSjdhK9vUWvPjz9tzBcEa13Ye33haxFsT//3kGxOQhI7yb3MJsDvwLtdfLL6txM3RdOrmLABBvw'"
+
+	nextUpdateKeyFileFlag  = "nextupdatekeyfile"
+	nextUpdateKeyFileUsage = "The file that contains the public key PEM used for creating the commitment for the next update of the document. Example: --nextupdatekeyfile ./keys/next_update_public.key"
+
+	keyStoreFlag  = "keystore"
+	keyStoreUsage = "The directory of a key store into which a newly generated update key is imported. This flag may not be used with the next update key flags. Example: --keystore ./keystore"
+
+	pathFlag  = "path"
+	pathUsage = "The new base path of the file index document. The current base path is kept if not specified. Example: --path /schema"
+
+	clearMappingsFlag  = "clearmappings"
+	clearMappingsUsage = "If specified then all of the mappings of the file index document are removed. Example: --clearmappings"
+
+	docFileFlag  = "docfile"
+	docFileUsage = "The file that contains the new file index document. Example: --docfile ./fileindex.json"
+
+	msgConfirm   = "Recovering file index document [%s] with the following content:\n%s"
+	msgRecovered = "File index document [%s] was recovered"
+)
+
+var (
+	errNextRecoveryKeyOrFileRequired          = errors.New("either next recovery key (--nextrecoverykey) or key file (--nextrecoverykeyfile) is required")
+	errOnlyOneOfNextRecoveryKeyOrFileRequired = errors.New("only one of next recovery key (--nextrecoverykey) or key file (--nextrecoverykeyfile) may be specified")
+	errNextUpdateKeyOrFileRequired            = errors.New("either next update key (--nextupdatekey), key file (--nextupdatekeyfile) or key store (--keystore) is required")
+	errOnlyOneOfNextUpdateKeyOrFileRequired   = errors.New("only one of next update key (--nextupdatekey), key file (--nextupdatekeyfile) or key store (--keystore) may be specified")
+	errDocFileAndOptionsSpecified             = errors.New("the path (--path) and clear mappings (--clearmappings) options may not be specified with the document file (--docfile)")
+	errInvalidPath                            = errors.New("path (--path) must begin with '/'")
+)
+
+// New returns the file recoveridx sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	return newCmd(settings, httpclient.New())
+}
+
+func newCmd(settings *environment.Settings, client common.HTTPClient) *cobra.Command {
+	c := &command{}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   desc,
+		Long:    longDesc,
+		Example: examples,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return c.validate()
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	c.RecoveryBaseCommand = common.NewRecoveryBaseCommand(settings, client, cmd)
+
+	cmd.Flags().StringVar(&c.nextRecoveryKeyString, nextRecoveryKeyFlag, "", nextRecoveryKeyUsage)
+	cmd.Flags().StringVar(&c.nextRecoveryKeyFile, nextRecoveryKeyFileFlag, "", nextRecoveryKeyFileUsage)
+	cmd.Flags().StringVar(&c.nextUpdateKeyString, nextUpdateKeyFlag, "", nextUpdateKeyUsage)
+	cmd.Flags().StringVar(&c.nextUpdateKeyFile, nextUpdateKeyFileFlag, "", nextUpdateKeyFileUsage)
+	cmd.Flags().StringVar(&c.keyStore, keyStoreFlag, "", keyStoreUsage)
+	cmd.Flags().StringVar(&c.path, pathFlag, "", pathUsage)
+	cmd.Flags().BoolVar(&c.clearMappings, clearMappingsFlag, false, clearMappingsUsage)
+	cmd.Flags().StringVar(&c.docFile, docFileFlag, "", docFileUsage)
+
+	return cmd
+}
+
+// command implements the recoveridx command
+type command struct {
+	*common.RecoveryBaseCommand
+
+	nextRecoveryKeyString string
+	nextRecoveryKeyFile   string
+	nextUpdateKeyString   string
+	nextUpdateKeyFile     string
+	keyStore              string
+	path                  string
+	clearMappings         bool
+	docFile               string
+}
+
+func (c *command) validate() error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if c.nextRecoveryKeyFile == "" && c.nextRecoveryKeyString == "" {
+		return errNextRecoveryKeyOrFileRequired
+	}
+
+	if c.nextRecoveryKeyFile != "" && c.nextRecoveryKeyString != "" {
+		return errOnlyOneOfNextRecoveryKeyOrFileRequired
+	}
+
+	if err := c.validateNextUpdateKey(); err != nil {
+		return err
+	}
+
+	if c.docFile != "" && (c.path != "" || c.clearMappings) {
+		return errDocFileAndOptionsSpecified
+	}
+
+	if c.path != "" && !strings.HasPrefix(c.path, "/") {
+		return errInvalidPath
+	}
+
+	return nil
+}
+
+func (c *command) validateNextUpdateKey() error {
+	n := 0
+
+	for _, v := range []string{c.nextUpdateKeyString, c.nextUpdateKeyFile, c.keyStore} {
+		if v != "" {
+			n++
+		}
+	}
+
+	switch {
+	case n == 0:
+		return errNextUpdateKeyOrFileRequired
+	case n > 1:
+		return errOnlyOneOfNextUpdateKeyOrFileRequired
+	default:
+		return nil
+	}
+}
+
+func (c *command) run() error {
+	fileIndex, err := c.getFileIndex()
+	if err != nil {
+		return err
+	}
+
+	recoveryKey, err := c.RecoveryKey()
+	if err != nil {
+		return err
+	}
+
+	nextRecoveryKey, err := c.nextRecoveryKey()
+	if err != nil {
+		return err
+	}
+
+	// The new update key is only generated if a key store is used
+	newUpdateKey, nextUpdateKey, err := c.nextUpdateKey(recoveryKey)
+	if err != nil {
+		return err
+	}
+
+	req, err := common.NewRecoverRequest(c.FileIndexURL, fileIndex, recoveryKey, nextRecoveryKey, nextUpdateKey)
+	if err != nil {
+		return err
+	}
+
+	fileIndexBytes, err := json.MarshalIndent(fileIndex, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	confirmed, err := c.Confirm(fmt.Sprintf(msgConfirm, c.FileIndexURL, fileIndexBytes))
+	if err != nil {
+		return err
+	}
+
+	if !confirmed {
+		return c.Fprintln(common.MsgAborted)
+	}
+
+	if newUpdateKey != nil {
+		if err := c.storePendingUpdateKey(newUpdateKey); err != nil {
+			return err
+		}
+	}
+
+	if err := c.SubmitOperation(req, "recovering"); err != nil {
+		if newUpdateKey != nil {
+			c.discardPendingUpdateKey(err)
+		}
+
+		return err
+	}
+
+	if newUpdateKey != nil {
+		if err := c.promotePendingUpdateKey(); err != nil {
+			return err
+		}
+	}
+
+	return c.Fprintln(fmt.Sprintf(msgRecovered, c.FileIndexURL))
+}
+
+// getFileIndex returns the new content of the file index document
+func (c *command) getFileIndex() (*model.FileIndex, error) {
+	if c.docFile != "" {
+		return c.readFileIndex()
+	}
+
+	fileIdxDoc, err := c.GetFileIndexDoc()
+	if err != nil {
+		return nil, err
+	}
+
+	fileIndex := &fileIdxDoc.FileIndex
+
+	if c.path != "" {
+		fileIndex.BasePath = c.path
+	}
+
+	if c.clearMappings || fileIndex.Mappings == nil {
+		fileIndex.Mappings = make(map[string]string)
+	}
+
+	fileIndex.Mappings[common.BasePathMapping] = fileIndex.BasePath
+
+	return fileIndex, nil
+}
+
+func (c *command) readFileIndex() (*model.FileIndex, error) {
+	docBytes, err := ioutil.ReadFile(filepath.Clean(c.docFile))
+	if err != nil {
+		return nil, err
+	}
+
+	fileIdxDoc := &model.FileIndexDoc{}
+	if err := json.Unmarshal(docBytes, fileIdxDoc); err != nil {
+		return nil, errors.WithMessagef(err, "invalid file index document [%s]", c.docFile)
+	}
+
+	fileIndex := &fileIdxDoc.FileIndex
+
+	if !strings.HasPrefix(fileIndex.BasePath, "/") {
+		return nil, errors.Errorf("invalid file index document [%s] - the base path must begin with '/'", c.docFile)
+	}
+
+	if fileIndex.Mappings == nil {
+		fileIndex.Mappings = make(map[string]string)
+	}
+
+	fileIndex.Mappings[common.BasePathMapping] = fileIndex.BasePath
+
+	return fileIndex, nil
+}
+
+func (c *command) nextRecoveryKey() (crypto.PublicKey, error) {
+	if c.nextRecoveryKeyFile != "" {
+		return common.PublicKeyFromFile(c.nextRecoveryKeyFile)
+	}
+
+	return common.PublicKeyFromPEM([]byte(c.nextRecoveryKeyString))
+}
+
+// nextUpdateKey returns the public key to which the next update commits. If a key store is used then a new
+// update key of the same type as the recovery key is generated and returned along with its public key.
+func (c *command) nextUpdateKey(recoveryKey crypto.PrivateKey) (crypto.PrivateKey, crypto.PublicKey, error) {
+	if c.keyStore != "" {
+		keyType, err := common.GetKeyType(recoveryKey)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key, err := common.GenerateKeyOfType(keyType)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		publicKey, err := common.PublicKey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return key, publicKey, nil
+	}
+
+	var (
+		key crypto.PublicKey
+		err error
+	)
+
+	if c.nextUpdateKeyFile != "" {
+		key, err = common.PublicKeyFromFile(c.nextUpdateKeyFile)
+	} else {
+		key, err = common.PublicKeyFromPEM([]byte(c.nextUpdateKeyString))
+	}
+
+	return nil, key, err
+}
+
+// storePendingUpdateKey stores the new update key in the key store before the recover request is submitted
+func (c *command) storePendingUpdateKey(updateKey crypto.PrivateKey) error {
+	uniqueSuffix, err := common.GetUniqueSuffix(c.FileIndexURL)
+	if err != nil {
+		return err
+	}
+
+	if err := common.NewKeyStore(c.keyStore).SetPendingUpdateKey(uniqueSuffix, updateKey); err != nil {
+		return errors.WithMessagef(err, "unable to store the new update key in key store [%s]", c.keyStore)
+	}
+
+	return nil
+}
+
+// promotePendingUpdateKey makes the new update key the current update key once the recover request succeeded
+func (c *command) promotePendingUpdateKey() error {
+	uniqueSuffix, err := common.GetUniqueSuffix(c.FileIndexURL)
+	if err != nil {
+		return err
+	}
+
+	if err := common.NewKeyStore(c.keyStore).PromotePendingUpdateKey(uniqueSuffix); err != nil {
+		// The key must not be lost since it's the only key that can update the document
+		return errors.WithMessagef(err, "the file index document was recovered but the new update key could not be promoted in key store [%s] - the key is held as the pending update key of the document and may be imported using 'file keys import'", c.keyStore)
+	}
+
+	return nil
+}
+
+// discardPendingUpdateKey removes the new update key from the key store if the recover request was rejected. The key
+// is kept if the outcome of the request is unknown (for example, if the connection failed) since the request may have
+// succeeded.
+func (c *command) discardPendingUpdateKey(submitErr error) {
+	var statusErr *httpclient.StatusError
+	if !errors.As(submitErr, &statusErr) {
+		return
+	}
+
+	uniqueSuffix, err := common.GetUniqueSuffix(c.FileIndexURL)
+	if err == nil {
+		err = common.NewKeyStore(c.keyStore).DiscardPendingUpdateKey(uniqueSuffix)
+	}
+
+	if err != nil {
+		fmt.Fprintf(c.Settings.Streams.Err, "Warning: unable to remove the pending update key from key store [%s]: %s\n", c.keyStore, err)
+	}
+}
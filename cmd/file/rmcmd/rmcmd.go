@@ -0,0 +1,155 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package rmcmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+)
+
+const (
+	use      = "rm <name>"
+	desc     = "Remove a file from a file index document"
+	longDesc = `
+The rm command removes the mapping of the given file name from a Sidetree file index document. If the file was uploaded in chunks then the mappings of its chunks are also removed, unless a chunk also belongs to another file in the file index. The manifest of the file is retrieved from the content endpoint (see --url) in order to determine its chunks, but only if the file index contains the mappings of chunks. The file itself is not removed from DCAS.
+`
+	examples = `
+- Remove a file from the given file index document:
+    $ ./fabric file rm person.schema.json --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --signingkeyfile ./keys/update.key --nextupdatekeyfile ./keys/next_update_public.key --noprompt
+
+- Remove a file that was uploaded in chunks, retrieving its manifest from the given content endpoint:
+    $ ./fabric file rm intro.mp4 --url https://content.example.com/content --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --keystore ./keystore --noprompt
+`
+)
+
+const (
+	urlFlag  = "url"
+	urlUsage = "The URL of the content endpoint from which to retrieve the manifests of files that were uploaded in chunks. If not specified then the URL is derived from the host of --idxurl and the base path of the file index. Example: --url http://localhost:48326/content"
+
+	msgRemoved = "File [%s] was removed from the file index document"
+)
+
+var errNameRequired = errors.New("the name of the file to remove is required")
+
+// New returns the file rm sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	return newCmd(settings, httpclient.New())
+}
+
+func newCmd(settings *environment.Settings, client common.HTTPClient) *cobra.Command {
+	c := &command{}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   desc,
+		Long:    longDesc,
+		Example: examples,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return c.validate(args)
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	c.UpdateBaseCommand = common.NewUpdateBaseCommand(settings, client, cmd)
+	cmd.Flags().StringVar(&c.url, urlFlag, "", urlUsage)
+	c.AddContentAuthFlags(cmd)
+
+	return cmd
+}
+
+// command implements the rm command
+type command struct {
+	*common.UpdateBaseCommand
+
+	name string
+	url  string
+}
+
+func (c *command) validate(args []string) error {
+	if len(args) == 0 || args[0] == "" {
+		return errNameRequired
+	}
+
+	if len(args) > 1 {
+		return errors.Errorf("only one file name may be specified: %s", args)
+	}
+
+	c.name = args[0]
+
+	if common.IsReservedMapping(c.name) {
+		return errors.Errorf("invalid file name [%s]", c.name)
+	}
+
+	return c.Validate()
+}
+
+func (c *command) run() error {
+	fileIdxDoc, err := c.GetFileIndexDoc()
+	if err != nil {
+		return err
+	}
+
+	id, ok := fileIdxDoc.FileIndex.Mappings[c.name]
+	if !ok {
+		return errors.Errorf("file [%s] not found in file index document [%s]", c.name, c.FileIndexURL)
+	}
+
+	var contentURL string
+
+	// The content endpoint is only needed to retrieve the manifest of a file that was uploaded in chunks
+	if common.HasChunkMappings(&fileIdxDoc.FileIndex) {
+		contentURL, err = c.getContentURL(fileIdxDoc.FileIndex.BasePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	confirmed, err := c.Confirm(fmt.Sprintf("Removing file [%s] with ID [%s] from file index document [%s]", c.name, id, c.FileIndexURL))
+	if err != nil {
+		return err
+	}
+
+	if !confirmed {
+		return c.Fprintln(common.MsgAborted)
+	}
+
+	patches := []common.JSONPatch{
+		{
+			Op:   common.JSONPatchRemoveOp,
+			Path: common.MappingPath(c.name),
+		},
+	}
+
+	if contentURL != "" {
+		patches = append(patches, c.ChunkRemovalPatches(contentURL, &fileIdxDoc.FileIndex, []string{c.name}, nil)...)
+	}
+
+	if err = c.UpdateFileIndex(patches); err != nil {
+		return err
+	}
+
+	return c.Fprintln(fmt.Sprintf(msgRemoved, c.name))
+}
+
+// getContentURL returns the URL of the content endpoint. If the URL wasn't provided then it's
+// derived from the file index URL and the base path of the file index.
+func (c *command) getContentURL(basePath string) (string, error) {
+	if c.url != "" {
+		return c.url, nil
+	}
+
+	return common.GetContentURL(c.FileIndexURL, basePath)
+}
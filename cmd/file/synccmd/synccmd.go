@@ -0,0 +1,389 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package synccmd
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+)
+
+const (
+	use      = "sync"
+	desc     = "Synchronize a local directory with a file index document"
+	longDesc = `
+The sync command uploads the files in a local directory to DCAS and adds them to a Sidetree file index document. The DCAS ID of each local file is computed (without loading the file into memory) and compared with the ID in the file index document so that only new and changed files are uploaded. A file that was uploaded in chunks is compared using the ID of its manifest, which is computed locally using the given chunk size (--chunksize), so the chunk size must be the same as the one that was used when the file was uploaded. A plan of the changes is displayed before any files are uploaded. If none of the files have changed then the file index document is not updated. Files in the file index document that do not exist in the directory are left unchanged. The content type of each file is resolved in the same way as the upload command (see --content-type and --mime-map) and must resolve to the same type that was used when the file was uploaded in order for the file to be considered unchanged. The response is a JSON document that contains the names of the files that were uploaded along with their DCAS ID and content-type.
+`
+	examples = `
+- Synchronize the JSON files in the ./schemas directory with the given file index document:
+    $ ./fabric file sync --url http://localhost:48326/content --dir ./schemas --include *.json --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --signingkeyfile ./keys/update.key --nextupdatekeyfile ./keys/next_update_public.key
+
+	Response:
+		Sync plan for file index [http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==]:
+		ACTION      NAME                     DCAS ID
+		add         v1/address.schema.json   PB6w7b18VtHHlY80CQQpz-2sRSppneFjs2C_Ch2tNNM=
+		update      v1/person.schema.json    TbVyraOqG00TacPQH5WwWGnxkszpYSEhBKRyX_f25JI=
+		unchanged   v2/person.schema.json    iXK3I3LP4Uhn5FlfRRj-c9umH2i34RPc46Z9vBGD6Ms=
+		Uploading 2 file(s) to [http://localhost:48326/content]
+		Enter Y to continue or N to abort
+
+- Synchronize the ./videos directory with the given file index document, uploading large files in chunks of 1MB:
+    $ ./fabric file sync --url http://localhost:48326/content --dir ./videos --uploadmode chunked --chunksize 1048576 --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --keystore ./keystore
+`
+)
+
+const (
+	urlFlag  = "url"
+	urlUsage = "The URL to which to upload the file(s). Example: --url http://localhost:48326/content"
+
+	dirFlag  = "dir"
+	dirUsage = "The directory whose files (including the files in sub-directories) are synchronized. The files are indexed by their path relative to the directory. Example: --dir ./schemas"
+
+	includeFlag  = "include"
+	includeUsage = "The comma separated glob patterns of the files to synchronize. A pattern that contains a slash is matched against the relative path of the file; otherwise it is matched against the file name. Example: --include *.json,v1/*"
+
+	excludeFlag  = "exclude"
+	excludeUsage = "The comma separated glob patterns of the files that are not to be synchronized. Patterns are matched in the same way as --include. Example: --exclude *.md"
+
+	parallelFlag  = "parallel"
+	parallelUsage = "The maximum number of files that are uploaded concurrently. Example: --parallel 4"
+
+	uploadModeFlag  = "uploadmode"
+	uploadModeUsage = "The mode in which files are uploaded: json (the content is embedded in a JSON document), stream (the content is streamed as the raw request body) or chunked (large files are split into multiple DCAS objects). Example: --uploadmode chunked"
+
+	chunkSizeFlag  = "chunksize"
+	chunkSizeUsage = "The maximum size (in bytes) of a chunk when --uploadmode is chunked. The chunk size must be at least 65536. Each chunk adds a mapping to the file index document so a larger chunk size keeps the file index document smaller. The chunk size is also used to compute the manifest ID of files that were previously uploaded in chunks. Example: --chunksize 1048576"
+
+	defaultChunkSize = 4 * 1024 * 1024
+
+	msgUpToDate = "The file index document is up to date"
+
+	actionAdd       = "add"
+	actionUpdate    = "update"
+	actionUnchanged = "unchanged"
+)
+
+var (
+	errURLRequired      = errors.New("URL (--url) is required")
+	errDirRequired      = errors.New("directory (--dir) is required")
+	errInvalidParallel  = errors.New("the number of concurrent uploads (--parallel) must be at least 1")
+	errInvalidChunkSize = errors.Errorf("the chunk size (--chunksize) must be at least %d bytes", common.MinChunkSize)
+)
+
+// New returns the file sync sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	return newCmd(settings, httpclient.New())
+}
+
+func newCmd(settings *environment.Settings, client common.HTTPClient) *cobra.Command {
+	c := &command{
+		client: client,
+	}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   desc,
+		Long:    longDesc,
+		Example: examples,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return c.validate()
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	c.UpdateBaseCommand = common.NewUpdateBaseCommand(settings, client, cmd)
+
+	cmd.Flags().StringVar(&c.url, urlFlag, "", urlUsage)
+	cmd.Flags().StringVar(&c.dir, dirFlag, "", dirUsage)
+	cmd.Flags().StringVar(&c.include, includeFlag, "", includeUsage)
+	cmd.Flags().StringVar(&c.exclude, excludeFlag, "", excludeUsage)
+	c.AddContentAuthFlags(cmd)
+	cmd.Flags().IntVar(&c.parallel, parallelFlag, 1, parallelUsage)
+	cmd.Flags().StringVar(&c.uploadMode, uploadModeFlag, string(common.UploadModeJSON), uploadModeUsage)
+	cmd.Flags().Int64Var(&c.chunkSize, chunkSizeFlag, defaultChunkSize, chunkSizeUsage)
+	c.contentTypes.AddFlags(cmd)
+
+	return cmd
+}
+
+// command implements the sync command
+type command struct {
+	*common.UpdateBaseCommand
+	client common.HTTPClient
+
+	url          string
+	dir          string
+	include      string
+	exclude      string
+	parallel     int
+	uploadMode   string
+	chunkSize    int64
+	contentTypes common.ContentTypeOptions
+	basePath     string
+	resolver     *common.ContentTypeResolver
+}
+
+// planEntry contains the action to be taken for a local file
+type planEntry struct {
+	action string
+	file   *common.File
+}
+
+func (c *command) validate() error {
+	if c.url == "" {
+		return errURLRequired
+	}
+
+	basePath, err := common.GetBasePath(c.url)
+	if err != nil {
+		return err
+	}
+
+	c.basePath = basePath
+
+	if c.dir == "" {
+		return errDirRequired
+	}
+
+	if c.parallel < 1 {
+		return errInvalidParallel
+	}
+
+	switch common.UploadMode(c.uploadMode) {
+	case common.UploadModeJSON, common.UploadModeStream, common.UploadModeChunked:
+	default:
+		return errors.Errorf("invalid upload mode (--uploadmode) [%s] - expecting one of: %s, %s, %s", c.uploadMode, common.UploadModeJSON, common.UploadModeStream, common.UploadModeChunked)
+	}
+
+	if c.chunkSize < common.MinChunkSize {
+		return errInvalidChunkSize
+	}
+
+	c.resolver, err = c.contentTypes.Resolver()
+	if err != nil {
+		return err
+	}
+
+	return c.Validate()
+}
+
+func (c *command) run() error {
+	fileIdxDoc, err := c.GetFileIndexDoc()
+	if err != nil {
+		return err
+	}
+
+	if fileIdxDoc.FileIndex.BasePath != c.basePath {
+		return errors.Errorf("base path of file index doc does not match the base path of the file: [%s] != [%s]", fileIdxDoc.FileIndex.BasePath, c.basePath)
+	}
+
+	local, err := common.GetLocalFiles("", c.dir, c.include, c.exclude)
+	if err != nil {
+		return err
+	}
+
+	// The content of the files is not loaded into memory. It is streamed when the DCAS IDs are computed and
+	// again when the files are uploaded.
+	files, err := common.NewFiles(local, c.resolver)
+	if err != nil {
+		return err
+	}
+
+	plan, changed, err := c.getPlan(&fileIdxDoc.FileIndex, files)
+	if err != nil {
+		return err
+	}
+
+	planStr, err := c.planString(plan)
+	if err != nil {
+		return err
+	}
+
+	if err = c.Fprint(planStr); err != nil {
+		return err
+	}
+
+	if len(changed) == 0 {
+		return c.Fprintln(msgUpToDate)
+	}
+
+	confirmed, err := c.Confirm(fmt.Sprintf("Uploading %d file(s) to [%s]", len(changed), c.url))
+	if err != nil {
+		return err
+	}
+
+	if !confirmed {
+		return c.Fprintln(common.MsgAborted)
+	}
+
+	uploaded, uploadErr := c.upload(changed)
+	if len(uploaded) == 0 {
+		return uploadErr
+	}
+
+	// Add the files that were uploaded successfully to the index even if some of the uploads failed
+	// so that a subsequent sync only needs to upload the files that failed.
+	patches := common.GetMappingPatches(&fileIdxDoc.FileIndex, uploaded)
+	patches = append(patches, c.ChunkRemovalPatches(c.url, &fileIdxDoc.FileIndex, nil, uploaded)...)
+
+	err = c.UpdateFileIndex(patches)
+	if err != nil {
+		return err
+	}
+
+	if err = c.Fprint(uploaded.String()); err != nil {
+		return err
+	}
+
+	if uploadErr != nil {
+		return errors.WithMessagef(uploadErr, "the file index document was updated with the %d file(s) that were uploaded successfully - re-run the command to upload the remaining files", len(uploaded))
+	}
+
+	return nil
+}
+
+// upload uploads the given files and verifies that the DCAS ID returned for each file matches the
+// computed ID. The files that were uploaded and verified are returned along with an *UploadError if
+// any of the files failed.
+func (c *command) upload(files common.Files) (common.Files, error) {
+	computedIDs := make(map[string]string)
+	for _, f := range files {
+		computedIDs[f.Name] = f.ID
+	}
+
+	uploaded, err := c.newUploader().Upload(files)
+
+	uploadErr, ok := err.(*common.UploadError)
+	if !ok {
+		if err != nil {
+			return nil, err
+		}
+
+		uploadErr = &common.UploadError{Errors: make(map[string]error)}
+	}
+
+	var verified common.Files
+
+	for _, f := range uploaded {
+		if f.ID != computedIDs[f.Name] {
+			uploadErr.Errors[f.Name] = errors.Errorf("the DCAS ID returned for the file does not match the computed ID: [%s] != [%s]", f.ID, computedIDs[f.Name])
+			continue
+		}
+
+		verified = append(verified, f)
+	}
+
+	if len(uploadErr.Errors) > 0 {
+		return verified, uploadErr
+	}
+
+	return verified, nil
+}
+
+// getPlan computes the DCAS IDs of each of the given files and compares them with the ID in the file index. The
+// plan for all files is returned along with the files that are new or changed.
+func (c *command) getPlan(fileIdx *model.FileIndex, files common.Files) ([]*planEntry, common.Files, error) {
+	var plan []*planEntry
+	var changed common.Files
+
+	for _, f := range files {
+		ids, err := common.GetFileIDs(f, c.chunkSize)
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "error computing DCAS ID of file [%s]", f.Name)
+		}
+
+		// The ID returned by DCAS for a file that is uploaded in chunks is the ID of its manifest
+		f.ID = ids.ID
+		if common.UploadMode(c.uploadMode) == common.UploadModeChunked && ids.ManifestID != "" {
+			f.ID = ids.ManifestID
+		}
+
+		action := actionUnchanged
+
+		existingID, ok := fileIdx.Mappings[f.Name]
+		switch {
+		case !ok:
+			action = actionAdd
+		case existingID != ids.ID && (ids.ManifestID == "" || existingID != ids.ManifestID):
+			action = actionUpdate
+
+			c.checkManifest(f, existingID, ids)
+		}
+
+		if action != actionUnchanged {
+			changed = append(changed, f)
+		}
+
+		plan = append(plan, &planEntry{action: action, file: f})
+	}
+
+	return plan, changed, nil
+}
+
+// checkManifest prints a warning if the existing mapping of a changed file is a manifest with the same content type
+// and size as the local file but with a number of chunks that does not match the chunk size, since the file is
+// most likely unchanged but was uploaded with a different chunk size.
+func (c *command) checkManifest(f *common.File, existingID string, ids *common.FileIDs) {
+	manifest, err := common.GetManifest(c.client, c.url, f.Name, existingID, c.ContentAuthToken)
+	if err != nil {
+		fmt.Fprintf(c.Settings.Streams.Err, "Warning: unable to check whether file [%s] was uploaded in chunks: %s\n", f.Name, err)
+
+		return
+	}
+
+	if manifest == nil || manifest.ContentType != f.ContentType || manifest.Size != ids.Size {
+		return
+	}
+
+	expectedChunks := (ids.Size + c.chunkSize - 1) / c.chunkSize
+	if ids.Size <= c.chunkSize {
+		expectedChunks = 0
+	}
+
+	if int64(len(manifest.Chunks)) != expectedChunks {
+		fmt.Fprintf(c.Settings.Streams.Err, "Warning: file [%s] was uploaded in %d chunk(s) of a different size than the chunk size (--%s %d)\n", f.Name, len(manifest.Chunks), chunkSizeFlag, c.chunkSize)
+	}
+}
+
+func (c *command) planString(plan []*planEntry) (string, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("Sync plan for file index [%s]:\n", c.FileIndexURL))
+
+	w := tabwriter.NewWriter(buf, 0, 0, 3, ' ', 0)
+
+	fmt.Fprintln(w, "ACTION\tNAME\tDCAS ID")
+
+	for _, e := range plan {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", e.action, e.file.Name, e.file.ID)
+	}
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func (c *command) newUploader() *common.Uploader {
+	return common.NewUploader(c.client, c.url, c.ContentAuthToken,
+		common.WithMode(common.UploadMode(c.uploadMode)),
+		common.WithChunkSize(c.chunkSize),
+		common.WithParallel(c.parallel),
+		common.WithProgress(c.Settings.Streams.Err),
+	)
+}
@@ -7,32 +7,15 @@ SPDX-License-Identifier: Apache-2.0
 package uploadcmd
 
 import (
-	"crypto"
-	"crypto/ecdsa"
-	"crypto/x509"
-	"encoding/json"
-	"encoding/pem"
 	"fmt"
-	"io/ioutil"
-	"mime"
-	"net/http"
-	"net/url"
-	"path/filepath"
-	"strings"
+	"io"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/hyperledger/fabric-cli/pkg/environment"
 
-	"github.com/trustbloc/sidetree-core-go/pkg/commitment"
-	"github.com/trustbloc/sidetree-core-go/pkg/jws"
-	"github.com/trustbloc/sidetree-core-go/pkg/patch"
-	"github.com/trustbloc/sidetree-core-go/pkg/util/ecsigner"
-	"github.com/trustbloc/sidetree-core-go/pkg/util/pubkey"
-	"github.com/trustbloc/sidetree-core-go/pkg/versions/1_0/client"
-
-	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
 	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
 	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
 )
@@ -42,8 +25,21 @@ const (
 	desc     = "Upload a file to DCAS"
 	longDesc = `
 The upload command allows a client to upload one or more files to DCAS and add them to a Sidetree file index document. The response is a JSON document that contains the names of the files that were updated along with their DCAS ID and content-type.
+
+The content type of a file is deduced from its extension. The content type may be set explicitly for files whose name matches a pattern (--content-type pattern=type) and additional extensions may be mapped to content types in a local mime map file (--mime-map). If the content type cannot be deduced from the name of the file (for example, Dockerfile) then it is detected from the content of the file. JSON content is detected as application/json or, if it contains an @context property, as application/ld+json.
+
+Files may be uploaded concurrently (--parallel) and upload progress is displayed on stderr. If some of the files cannot be uploaded then the file index document is updated with the files that were uploaded successfully and the command exits with an error that lists the files that failed, so that only those files need to be uploaded again. HTTP requests that fail with a transient error, such as uploading a file or retrieving the file index document, are retried (--http-retries) but the Sidetree update is only retried if the connection to the Sidetree node could not be established, since the update must not be replayed once the node may have accepted it.
+
+By default the content of each file is loaded into memory and uploaded in a JSON document (--uploadmode json). Large files may instead be streamed to a content endpoint that accepts raw uploads (--uploadmode stream) or split into chunks of at most --chunksize bytes (--uploadmode chunked). In chunked mode each chunk is stored as a separate DCAS object and the file is indexed by the DCAS ID of a manifest that lists the chunks. Each chunk is indexed under the reserved '.chunks/' prefix (so that it may be retrieved from the content endpoint), which means that a file that is uploaded in chunks adds one mapping to the file index document for each of its chunks in addition to the mapping of the file itself. The chunk size should therefore be large enough to keep the file index document small. The download command reassembles the file from its manifest. When a file that was uploaded in chunks is replaced, the mappings of the chunks that no longer belong to any file are removed from the file index.
+
+Instead of providing the signing key and next update key, the keys may be taken from a local key store (--keystore) into which the update key of the file index document was imported (see 'file keys import'). The update is signed with the current update key in the key store and, once the update is accepted, the pre-generated next update key is promoted to the current update key.
+
+The command returns as soon as the Sidetree node accepts the update. If --wait-published is specified then the command waits (for at most --timeout) until the update has been anchored and the published file index document commits to the next update key, so that subsequent commands see the new mappings.
 `
 	examples = `
+- Upload all of the JSON files in the ./schemas directory (including sub-directories). A file such as ./schemas/v1/person.schema.json is indexed as v1/person.schema.json:
+    $ ./fabric file upload --url http://localhost:48326/content --dir ./schemas --include *.json --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --signingkeyfile ./keys/update.key --nextupdatekeyfile ./keys/next_update_public.key --noprompt
+
 - Upload two files to the '/content' path and add index entries to the given file index document:
     $ ./fabric file upload --url http://localhost:48326/content --files ./fixtures/testdata/v1/person.schema.json;./fixtures/testdata/v1/raised-hand.png --idxurl http://localhost:48326/file/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --pwd pwd1 --nextpwd pwd2 --noprompt
 
@@ -60,70 +56,61 @@ The upload command allows a client to upload one or more files to DCAS and add t
 			"ContentType": "image/png"
 		  }
 		]
-`
-)
 
-const (
-	fileFlag  = "files"
-	fileUsage = "The semi-colin separated paths of the files to upload. Example: --files ./samples/content1.json;./samples/image.png"
-
-	urlFlag  = "url"
-	urlUsage = "The URL to which to add the file(s). Example: --url http://localhost:48326/content"
+- Upload all of the files in the ./schemas directory using the update keys in the given key store:
+    $ ./fabric file upload --url http://localhost:48326/content --dir ./schemas --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --keystore ./keystore --noprompt
 
-	fileIndexURLFlag  = "idxurl"
-	fileIndexURLUsage = "The URL of the file index Sidetree document to be updated with the new/updated files. Example: --idxurl http://localhost:48326/file/file:idx:1234"
+- Upload a file and wait for at most 5 minutes until the update of the file index document is published:
+    $ ./fabric file upload --url http://localhost:48326/content --files ./schemas/person.schema.json --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --keystore ./keystore --noprompt --wait-published --timeout 5m
 
-	authTokenFlag  = "authtoken"
-	authTokenUsage = "The bearer authorization token that may be required to access the URL specified by --idxurl. Example: --authtoken mytoken" //nolint: gosec
+- Upload the files in the ./docker directory, setting the content type of Dockerfile explicitly and mapping additional extensions using a local mime map file:
+    $ ./fabric file upload --url http://localhost:48326/content --dir ./docker --content-type Dockerfile=text/plain --mime-map ./mime.types --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --keystore ./keystore --noprompt
 
-	contentAuthTokenFlag  = "contentauthtoken"
-	contentAuthTokenUsage = "The bearer authorization token to upload files to the URL specified by --url. This is only required if it is different from --authtoken. Example: --contentauthtoken mytoken" //nolint: gosec
+- Upload a large file in chunks of 1MB:
+    $ ./fabric file upload --url http://localhost:48326/content --files ./videos/intro.mp4 --uploadmode chunked --chunksize 1048576 --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --signingkeyfile ./keys/update.key --nextupdatekeyfile ./keys/next_update_public.key --noprompt
+`
+)
 
-	fileIndexNextUpdateKeyFlag  = "nextupdatekey"
-	fileIndexNextUpdateKeyUsage = "The public key PEM used for creating commitment for next update of the index document. Example: --nextupdatekey 'MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEFMy2n9jYZChYSjdhK9vUWvPjz9tzBcEa13Ye33haxFsT//3kGxOQhI7yb3MJsDvwLtdfLL6txM3RdOrmLABBvw'"
+const (
+	fileFlag  = "files"
+	fileUsage = "The semi-colon separated paths of the files to upload. Each path may be a glob pattern. The files are indexed by their base name. Example: --files ./samples/content1.json;./samples/*.png"
 
-	fileIndexNextUpdateKeyFileFlag  = "nextupdatekeyfile"
-	fileIndexNextUpdateKeyFileUsage = "The file that contains the public key PEM used for creating commitment for next update of the index document. Example: --nextupdatekeyfile ./next_update_public.key"
+	dirFlag  = "dir"
+	dirUsage = "The directory whose files (including the files in sub-directories) are uploaded. The files are indexed by their path relative to the directory. Example: --dir ./schemas"
 
-	fileIndexSigningKeyFlag  = "signingkey"
-	fileIndexSigningKeyUsage = "The private key PEM used for signing the update of the index document. Example: --signingkey 'MHcCAQEEILmfa4yss8nsTJK2hKl+LAoiwW3p+eQzaHfITI9z8ptpoAoGCCqGSM49AwEHoUQDQgAEMd1/e/Nxh73bK12PEEcNSY9HxnP0N8er9ww9rjq1tNcsqfRjlL0bdTh9Basfn/4JrQHUHc6uS99yjQc+0u2bVg'"
+	includeFlag  = "include"
+	includeUsage = "The comma separated glob patterns of the files to upload. A pattern that contains a slash is matched against the relative path of the file; otherwise it is matched against the file name. Example: --include *.json,v1/*"
 
-	fileIndexSigningKeyFileFlag  = "signingkeyfile"
-	fileIndexSigningKeyFileUsage = "The file that contains the private key PEM used for signing the update of the index document. Example: --signingkeyfile ./keys/signing.key"
+	excludeFlag  = "exclude"
+	excludeUsage = "The comma separated glob patterns of the files that are not to be uploaded. Patterns are matched in the same way as --include. Example: --exclude *.md"
 
-	noPromptFlag  = "noprompt"
-	noPromptUsage = "If specified then the upload operation will not prompt for confirmation. Example: --noprompt"
+	urlFlag  = "url"
+	urlUsage = "The URL to which to add the file(s). Example: --url http://localhost:48326/content"
 
-	msgAborted         = "Operation aborted"
-	msgContinueOrAbort = "Enter Y to continue or N to abort "
+	parallelFlag  = "parallel"
+	parallelUsage = "The maximum number of files that are uploaded concurrently. Example: --parallel 4"
 
-	// default multihash for Sidetree
-	sha2_256 = 18
+	uploadModeFlag  = "uploadmode"
+	uploadModeUsage = "The mode in which files are uploaded: json (the content is embedded in a JSON document), stream (the content is streamed as the raw request body) or chunked (large files are split into multiple DCAS objects). Example: --uploadmode chunked"
 
-	signingAlgorithm = "ES256"
+	chunkSizeFlag  = "chunksize"
+	chunkSizeUsage = "The maximum size (in bytes) of a chunk when --uploadmode is chunked. The chunk size must be at least 65536. Each chunk adds a mapping to the file index document so a larger chunk size keeps the file index document smaller. Example: --chunksize 1048576"
 
-	jsonPatchBasePath  = "/fileIndex/mappings/"
-	jsonPatchAddOp     = "add"
-	jsonPatchReplaceOp = "replace"
+	defaultChunkSize = 4 * 1024 * 1024
 )
 
 var (
-	errURLRequired                          = errors.New("URL (--url) is required")
-	errFilesRequired                        = errors.New("files (--files) is required")
-	errFileIndexURLRequired                 = errors.New("file index URL (--idxurl) is required")
-	errNextUpdateKeyOrFileRequired          = errors.New("either next update key (--nextupdatekey) or key file (--nextupdatekeyfile) is required")
-	errOnlyOneOfNextUpdateKeyOrFileRequired = errors.New("only one of next update key (--nextupdatekey) or key file (--nextupdatekeyfile) may be specified")
-	errNoFileExtension                      = errors.New("content type cannot be deduced since no file extension provided")
-	errUnknownExtension                     = errors.New("content type cannot be deduced from extension")
-	errSigningKeyOrFileRequired             = errors.New("either signing key (--signingkey) or key file (--signingkeyfile) is required")
-	errOnlyOneOfSigningKeyOrFileRequired    = errors.New("only one of signing key (--signingkey) or key file (--signingkeyfile) may be specified")
-	errPrivateKeyNotFoundInPEM              = errors.New("private key not found in PEM")
-	errPublicKeyNotFoundInPEM               = errors.New("public key not found in PEM")
+	errURLRequired      = errors.New("URL (--url) is required")
+	errFilesRequired    = errors.New("files (--files) or directory (--dir) is required")
+	errInvalidParallel  = errors.New("the number of concurrent uploads (--parallel) must be at least 1")
+	errInvalidChunkSize = errors.Errorf("the chunk size (--chunksize) must be at least %d bytes", common.MinChunkSize)
 )
 
 type httpClient interface {
 	Post(url string, req []byte, opts ...httpclient.RequestOpt) (*httpclient.HTTPResponse, error)
 	Get(url string, opts ...httpclient.RequestOpt) (*httpclient.HTTPResponse, error)
+	Head(url string, opts ...httpclient.RequestOpt) (*httpclient.HTTPResponse, error)
+	PostStream(url, contentType string, body io.Reader, opts ...httpclient.RequestOpt) (*httpclient.HTTPResponse, error)
 }
 
 // New returns the file upload sub-command
@@ -133,8 +120,7 @@ func New(settings *environment.Settings) *cobra.Command {
 
 func newCmd(settings *environment.Settings, client httpClient) *cobra.Command {
 	c := &command{
-		Command: basecmd.New(settings, nil),
-		client:  client,
+		client: client,
 	}
 
 	cmd := &cobra.Command{
@@ -153,41 +139,38 @@ func newCmd(settings *environment.Settings, client httpClient) *cobra.Command {
 		},
 	}
 
-	c.Settings = settings
-	cmd.SetOutput(c.Settings.Streams.Out)
-	cmd.SilenceUsage = true
+	c.UpdateBaseCommand = common.NewUpdateBaseCommand(settings, client, cmd)
 
 	cmd.Flags().StringVar(&c.file, fileFlag, "", fileUsage)
+	cmd.Flags().StringVar(&c.dir, dirFlag, "", dirUsage)
+	cmd.Flags().StringVar(&c.include, includeFlag, "", includeUsage)
+	cmd.Flags().StringVar(&c.exclude, excludeFlag, "", excludeUsage)
 	cmd.Flags().StringVar(&c.url, urlFlag, "", urlUsage)
-	cmd.Flags().StringVar(&c.authToken, authTokenFlag, "", authTokenUsage)
-	cmd.Flags().StringVar(&c.contentAuthToken, contentAuthTokenFlag, "", contentAuthTokenUsage)
-	cmd.Flags().StringVar(&c.fileIndexURL, fileIndexURLFlag, "", fileIndexURLUsage)
-	cmd.Flags().StringVar(&c.fileIndexNextUpdateKeyString, fileIndexNextUpdateKeyFlag, "", fileIndexNextUpdateKeyUsage)
-	cmd.Flags().StringVar(&c.fileIndexNextUpdateKeyFile, fileIndexNextUpdateKeyFileFlag, "", fileIndexNextUpdateKeyFileUsage)
-	cmd.Flags().StringVar(&c.fileIndexSigningKeyString, fileIndexSigningKeyFlag, "", fileIndexSigningKeyUsage)
-	cmd.Flags().StringVar(&c.fileIndexSigningKeyFile, fileIndexSigningKeyFileFlag, "", fileIndexSigningKeyFileUsage)
-	cmd.Flags().BoolVar(&c.noPrompt, noPromptFlag, false, noPromptUsage)
+	c.AddContentAuthFlags(cmd)
+	cmd.Flags().IntVar(&c.parallel, parallelFlag, 1, parallelUsage)
+	cmd.Flags().StringVar(&c.uploadMode, uploadModeFlag, string(common.UploadModeJSON), uploadModeUsage)
+	cmd.Flags().Int64Var(&c.chunkSize, chunkSizeFlag, defaultChunkSize, chunkSizeUsage)
+	c.contentTypes.AddFlags(cmd)
 
 	return cmd
 }
 
 // command implements the update command
 type command struct {
-	*basecmd.Command
+	*common.UpdateBaseCommand
 	client httpClient
 
-	file                         string
-	url                          string
-	authToken                    string
-	contentAuthToken             string
-	basePath                     string
-	fileIndexURL                 string
-	fileIndexUpdateURL           string
-	fileIndexSigningKeyFile      string
-	fileIndexSigningKeyString    string
-	fileIndexNextUpdateKeyFile   string
-	fileIndexNextUpdateKeyString string
-	noPrompt                     bool
+	file         string
+	dir          string
+	include      string
+	exclude      string
+	url          string
+	parallel     int
+	uploadMode   string
+	chunkSize    int64
+	contentTypes common.ContentTypeOptions
+	basePath     string
+	resolver     *common.ContentTypeResolver
 }
 
 func (c *command) validateAndProcessArgs() error {
@@ -195,59 +178,61 @@ func (c *command) validateAndProcessArgs() error {
 		return err
 	}
 
-	if err := c.validateAndProcessFileIdxURL(); err != nil {
+	if err := c.ValidateFileIndexURL(); err != nil {
 		return err
 	}
 
-	if c.file == "" {
-		return errFilesRequired
-	}
-
-	if err := c.validateSigningKey(); err != nil {
+	if err := c.ConfigureHTTPClient(); err != nil {
 		return err
 	}
 
-	if err := c.validateNextUpdateKey(); err != nil {
-		return err
+	if c.file == "" && c.dir == "" {
+		return errFilesRequired
 	}
 
-	if c.contentAuthToken == "" {
-		c.contentAuthToken = c.authToken
+	if c.parallel < 1 {
+		return errInvalidParallel
 	}
 
-	return nil
-}
+	switch common.UploadMode(c.uploadMode) {
+	case common.UploadModeJSON, common.UploadModeStream, common.UploadModeChunked:
+	default:
+		return errors.Errorf("invalid upload mode (--uploadmode) [%s] - expecting one of: %s, %s, %s", c.uploadMode, common.UploadModeJSON, common.UploadModeStream, common.UploadModeChunked)
+	}
 
-func (c *command) validateAndProcessURL() error {
-	if c.url == "" {
-		return errURLRequired
+	if c.chunkSize < common.MinChunkSize {
+		return errInvalidChunkSize
 	}
 
-	u, err := url.Parse(c.url)
+	resolver, err := c.contentTypes.Resolver()
 	if err != nil {
-		return errors.WithMessagef(err, "invalid URL [%s]", c.url)
+		return err
 	}
 
-	if u.Path == "" {
-		return errors.New("invalid URL - no base path found")
+	c.resolver = resolver
+
+	if err := c.ValidatePublishWait(); err != nil {
+		return err
 	}
 
-	c.basePath = u.Path
+	if err := c.ValidateKeys(); err != nil {
+		return err
+	}
 
 	return nil
 }
 
-func (c *command) validateAndProcessFileIdxURL() error {
-	if c.fileIndexURL == "" {
-		return errFileIndexURLRequired
+func (c *command) validateAndProcessURL() error {
+	if c.url == "" {
+		return errURLRequired
 	}
 
-	pos := strings.LastIndex(c.fileIndexURL, "/identifiers")
-	if pos == -1 {
-		return errors.Errorf("invalid file index URL: [%s] - the file index ID must be prefixed by identifiers/", c.fileIndexURL)
+	basePath, err := common.GetBasePath(c.url)
+	if err != nil {
+		return err
 	}
 
-	c.fileIndexUpdateURL = fmt.Sprintf("%s/operations", c.fileIndexURL[0:pos])
+	c.basePath = basePath
 
 	return nil
 }
@@ -263,213 +248,53 @@ func (c *command) run() error {
 		return err
 	}
 
-	if !c.noPrompt {
-		confirmed, e := c.confirmUpload(c.url, f)
-		if e != nil {
-			return e
-		}
-
-		if !confirmed {
-			return c.Fprintln(msgAborted)
-		}
-	}
-
-	for _, file := range f {
-		id, e := c.upload(file.ContentType, file.Content)
-		if e != nil {
-			return e
-		}
-
-		file.ID = id
-	}
-
-	err = c.updateIndexFile(fileIdx, f)
+	confirmed, err := c.Confirm(fmt.Sprintf("Uploading the following files to [%s]\n%s", c.url, f))
 	if err != nil {
 		return err
 	}
 
-	return c.Fprint(f.String())
-}
-
-// confirmUpload prompts the user for confirmation of the upload
-func (c *command) confirmUpload(url string, files files) (bool, error) {
-	prompt := fmt.Sprintf("Uploading the following files to [%s]\n%s\n%s", url, files, msgContinueOrAbort)
-
-	err := c.Fprintln(prompt)
-	if err != nil {
-		return false, err
+	if !confirmed {
+		return c.Fprintln(common.MsgAborted)
 	}
 
-	return strings.ToLower(c.Prompt()) == "y", nil
-}
-
-func (c *command) upload(contentType string, fileBytes []byte) (string, error) {
-	req := &uploadFile{
-		ContentType: contentType,
-		Content:     fileBytes,
+	uploaded, uploadErr := c.newUploader().Upload(f)
+	if len(uploaded) == 0 {
+		return uploadErr
 	}
 
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return "", err
-	}
+	// Add the files that were uploaded successfully to the index even if some of the uploads failed
+	// so that only the failed files need to be uploaded again.
+	patches := common.GetMappingPatches(fileIdx, uploaded)
+	patches = append(patches, c.ChunkRemovalPatches(c.url, fileIdx, nil, uploaded)...)
 
-	var reqOpts []httpclient.RequestOpt
-	if c.contentAuthToken != "" {
-		reqOpts = append(reqOpts, httpclient.WithAuthToken(c.contentAuthToken))
-	}
-
-	resp, err := c.client.Post(c.url, reqBytes, reqOpts...)
-	if err != nil {
-		return "", err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusUnauthorized {
-			return "", errors.Errorf("status code %d: %s - Did you provide an authorization token (--contentauthtoken)?", resp.StatusCode, resp.ErrorMsg)
-		}
-
-		return "", errors.Errorf("status code %d: %s", resp.StatusCode, resp.ErrorMsg)
-	}
-
-	var fileID string
-	err = json.Unmarshal(resp.Payload, &fileID)
-	if err != nil {
-		return "", err
-	}
-
-	return fileID, nil
-}
-
-func (c *command) updateIndexFile(fileIdx *model.FileIndex, files files) error {
-	patch, err := getUpdatePatch(fileIdx, files)
+	err = c.UpdateFileIndex(patches)
 	if err != nil {
 		return err
 	}
 
-	req, err := c.getUpdateRequest(patch)
-	if err != nil {
+	if err = c.Fprint(uploaded.String()); err != nil {
 		return err
 	}
 
-	var reqOpts []httpclient.RequestOpt
-	if c.authToken != "" {
-		reqOpts = append(reqOpts, httpclient.WithAuthToken(c.authToken))
+	if uploadErr != nil {
+		return errors.WithMessagef(uploadErr, "the file index document was updated with the %d file(s) that were uploaded successfully - re-run the command with the files that failed", len(uploaded))
 	}
 
-	resp, err := c.client.Post(c.fileIndexUpdateURL, req, reqOpts...)
-	if err != nil {
-		return err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusUnauthorized {
-			return errors.Errorf("error updating file index document. Status code %d: %s - Did you provide an authorization token (--authtoken)?", resp.StatusCode, resp.ErrorMsg)
-		}
-
-		return errors.Errorf("error updating file index document. Status code %d: %s", resp.StatusCode, resp.ErrorMsg)
-	}
-
-	return err
-}
-
-func (c *command) getFiles() (files, error) {
-	var f files
-	for _, filePath := range strings.Split(c.file, ";") {
-		fileInfo, err := getFileInfo(filePath)
-		if err != nil {
-			return nil, err
-		}
-
-		f = append(f, fileInfo)
-	}
-
-	return f, nil
+	return nil
 }
 
-func (c *command) getUpdateRequest(patchStr string) ([]byte, error) {
-	uniqueSuffix, err := getUniqueSuffix(c.fileIndexURL)
+func (c *command) getFiles() (common.Files, error) {
+	local, err := common.GetLocalFiles(c.file, c.dir, c.include, c.exclude)
 	if err != nil {
 		return nil, err
 	}
 
-	updatePatch, err := patch.NewJSONPatch(patchStr)
-	if err != nil {
-		return nil, err
-	}
-
-	updateKeySigner, err := c.updateKeySigner()
-	if err != nil {
-		return nil, err
-	}
-
-	updateKeyPublic, err := c.updateKeyPublic()
-	if err != nil {
-		return nil, err
-	}
-
-	nextUpdateKeyPublic, err := c.nextUpdateKeyPublic()
-	if err != nil {
-		return nil, err
-	}
-
-	updateCommitment, err := commitment.GetCommitment(nextUpdateKeyPublic, sha2_256)
-	if err != nil {
-		return nil, err
-	}
-
-	revealValue, err := commitment.GetRevealValue(updateKeyPublic, sha2_256)
-	if err != nil {
-		return nil, err
-	}
-
-	return client.NewUpdateRequest(&client.UpdateRequestInfo{
-		DidSuffix:        uniqueSuffix,
-		RevealValue:      revealValue,
-		UpdateCommitment: updateCommitment,
-		UpdateKey:        updateKeyPublic,
-		Patches:          []patch.Patch{updatePatch},
-		MultihashCode:    sha2_256,
-		Signer:           updateKeySigner,
-	})
+	// The content of the files is read when they are uploaded so that large files are not held in memory
+	return common.NewFiles(local, c.resolver)
 }
 
 func (c *command) getFileIndex() (*model.FileIndex, error) {
-	var reqOpts []httpclient.RequestOpt
-	if c.authToken != "" {
-		reqOpts = append(reqOpts, httpclient.WithAuthToken(c.authToken))
-	}
-
-	resp, err := c.client.Get(c.fileIndexURL, reqOpts...)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusNotFound {
-			return nil, errors.Errorf("file index document [%s] not found", c.fileIndexURL)
-		}
-
-		if resp.StatusCode == http.StatusUnauthorized {
-			return nil, errors.Errorf("error retrieving file index document [%s]. Status code %d: %s - Did you provide an authorization token (--authtoken)?", c.fileIndexURL, resp.StatusCode, resp.ErrorMsg)
-		}
-
-		return nil, errors.Errorf("error retrieving file index document [%s] status code %d: %s", c.fileIndexURL, resp.StatusCode, resp.ErrorMsg)
-	}
-
-	var r model.DIDResolution
-	if errUnmarshal := json.Unmarshal(resp.Payload, &r); errUnmarshal != nil {
-		return nil, fmt.Errorf("unmarshal data return from sidtree %w", errUnmarshal)
-	}
-
-	didDocBytes := resp.Payload
-	// check if data is did resolution
-	if len(r.DIDDocument) != 0 {
-		didDocBytes = r.DIDDocument
-	}
-
-	fileIdxDoc := &model.FileIndexDoc{}
-	err = json.Unmarshal(didDocBytes, fileIdxDoc)
+	fileIdxDoc, err := c.GetFileIndexDoc()
 	if err != nil {
 		return nil, err
 	}
@@ -482,194 +307,11 @@ func (c *command) getFileIndex() (*model.FileIndex, error) {
 	return &fileIdxDoc.FileIndex, nil
 }
 
-func (c *command) updateKeySigner() (client.Signer, error) {
-	privateKey, err := c.signingPrivateKey()
-	if err != nil {
-		return nil, err
-	}
-
-	return ecsigner.New(privateKey, signingAlgorithm, ""), nil
-}
-
-func (c *command) updateKeyPublic() (*jws.JWK, error) {
-	privateKey, err := c.signingPrivateKey()
-	if err != nil {
-		return nil, err
-	}
-
-	return pubkey.GetPublicKeyJWK(&privateKey.PublicKey)
-}
-
-func (c *command) nextUpdateKeyPublic() (*jws.JWK, error) {
-	pubKey, err := c.nextUpdateKey()
-	if err != nil {
-		return nil, err
-	}
-
-	return pubkey.GetPublicKeyJWK(pubKey)
-}
-
-func (c *command) nextUpdateKey() (crypto.PublicKey, error) {
-	if c.fileIndexNextUpdateKeyFile != "" {
-		return publicKeyFromFile(c.fileIndexNextUpdateKeyFile)
-	}
-
-	return publicKeyFromPEM([]byte(c.fileIndexNextUpdateKeyString))
-}
-
-func publicKeyFromFile(file string) (crypto.PublicKey, error) {
-	keyBytes, err := ioutil.ReadFile(filepath.Clean(file))
-	if err != nil {
-		return nil, err
-	}
-
-	return publicKeyFromPEM(keyBytes)
-}
-
-func publicKeyFromPEM(pubKeyPEM []byte) (crypto.PublicKey, error) {
-	block, _ := pem.Decode(pubKeyPEM)
-	if block == nil {
-		return nil, errPublicKeyNotFoundInPEM
-	}
-
-	key, err := x509.ParsePKIXPublicKey(block.Bytes)
-	if err != nil {
-		return nil, err
-	}
-
-	publicKey, ok := key.(crypto.PublicKey)
-	if !ok {
-		return nil, errors.New("invalid public key")
-	}
-
-	return publicKey, nil
-}
-
-func (c *command) signingPrivateKey() (*ecdsa.PrivateKey, error) {
-	if c.fileIndexSigningKeyFile != "" {
-		return privateKeyFromFile(c.fileIndexSigningKeyFile)
-	}
-
-	return privateKeyFromPEM([]byte(c.fileIndexSigningKeyString))
-}
-
-func (c *command) validateSigningKey() error {
-	if c.fileIndexSigningKeyFile == "" && c.fileIndexSigningKeyString == "" {
-		return errSigningKeyOrFileRequired
-	}
-
-	if c.fileIndexSigningKeyFile != "" && c.fileIndexSigningKeyString != "" {
-		return errOnlyOneOfSigningKeyOrFileRequired
-	}
-
-	return nil
-}
-
-func (c *command) validateNextUpdateKey() error {
-	if c.fileIndexNextUpdateKeyFile == "" && c.fileIndexNextUpdateKeyString == "" {
-		return errNextUpdateKeyOrFileRequired
-	}
-
-	if c.fileIndexNextUpdateKeyFile != "" && c.fileIndexNextUpdateKeyString != "" {
-		return errOnlyOneOfNextUpdateKeyOrFileRequired
-	}
-
-	return nil
-}
-
-func getFileInfo(path string) (*fileInfo, error) {
-	var fileName string
-	p := strings.LastIndex(path, "/")
-	if p == -1 {
-		fileName = path
-	} else {
-		fileName = path[p+1:]
-	}
-
-	contentType, err := contentTypeFromFileName(fileName)
-	if err != nil {
-		return nil, err
-	}
-
-	content, err := ioutil.ReadFile(filepath.Clean(path))
-	if err != nil {
-		return nil, err
-	}
-
-	return &fileInfo{
-		Name:        fileName,
-		Content:     content,
-		ContentType: contentType,
-	}, nil
-}
-
-func contentTypeFromFileName(fileName string) (string, error) {
-	p := strings.LastIndex(fileName, ".")
-	if p == -1 {
-		return "", errNoFileExtension
-	}
-
-	contentType := mime.TypeByExtension(fileName[p:])
-	if contentType == "" {
-		return "", errors.WithMessagef(errUnknownExtension, fileName)
-	}
-
-	return contentType, nil
-}
-
-func getUpdatePatch(fileIdx *model.FileIndex, files files) (string, error) {
-	var patch []jsonPatch
-	for _, f := range files {
-		p := jsonPatch{
-			Path:  jsonPatchBasePath + f.Name,
-			Value: f.ID,
-		}
-
-		if _, ok := fileIdx.Mappings[f.Name]; ok {
-			p.Op = jsonPatchReplaceOp
-		} else {
-			p.Op = jsonPatchAddOp
-		}
-
-		patch = append(patch, p)
-	}
-
-	patchBytes, err := json.Marshal(patch)
-	if err != nil {
-		return "", err
-	}
-
-	return string(patchBytes), nil
-}
-
-func getUniqueSuffix(id string) (string, error) {
-	p := strings.LastIndex(id, ":")
-	if p == -1 {
-		return "", errors.Errorf("unique suffix not provided in URL [%s]", id)
-	}
-
-	return id[p+1:], nil
-}
-
-func privateKeyFromFile(file string) (*ecdsa.PrivateKey, error) {
-	keyBytes, err := ioutil.ReadFile(filepath.Clean(file))
-	if err != nil {
-		return nil, err
-	}
-
-	return privateKeyFromPEM(keyBytes)
-}
-
-func privateKeyFromPEM(privateKeyPEM []byte) (*ecdsa.PrivateKey, error) {
-	privBlock, _ := pem.Decode(privateKeyPEM)
-	if privBlock == nil {
-		return nil, errPrivateKeyNotFoundInPEM
-	}
-
-	privKey, err := x509.ParseECPrivateKey(privBlock.Bytes)
-	if err != nil {
-		return nil, err
-	}
-
-	return privKey, nil
+func (c *command) newUploader() *common.Uploader {
+	return common.NewUploader(c.client, c.url, c.ContentAuthToken,
+		common.WithParallel(c.parallel),
+		common.WithMode(common.UploadMode(c.uploadMode)),
+		common.WithChunkSize(c.chunkSize),
+		common.WithProgress(c.Settings.Streams.Err),
+	)
 }
@@ -7,17 +7,28 @@ SPDX-License-Identifier: Apache-2.0
 package uploadcmd
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
 	"encoding/json"
 	"errors"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/commitment"
+	"github.com/trustbloc/sidetree-core-go/pkg/util/pubkey"
+	sidetreemodel "github.com/trustbloc/sidetree-core-go/pkg/versions/1_0/model"
 
 	"github.com/hyperledger/fabric-cli/pkg/environment"
 
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
 	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
 	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
 	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
@@ -66,7 +77,7 @@ func TestUloadCmd_InvalidOptions(t *testing.T) {
 	})
 
 	t.Run("No --idxurl", func(t *testing.T) {
-		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, filesFlag, files).Execute(), errFileIndexURLRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, filesFlag, files).Execute(), common.ErrFileIndexURLRequired.Error())
 	})
 
 	t.Run("No --files", func(t *testing.T) {
@@ -79,20 +90,36 @@ func TestUloadCmd_InvalidOptions(t *testing.T) {
 		require.Contains(t, err.Error(), "invalid file index URL")
 	})
 
+	t.Run("Invalid --header", func(t *testing.T) {
+		err := newMockCmd(t, nil, urlFlag, url, filesFlag, files, idxUrlFlag, idxUrl, "--header", "X-Api-Key").Execute()
+		require.EqualError(t, err, "invalid header (--header) [X-Api-Key] - expecting 'Name: value'")
+	})
+
+	t.Run("Invalid --authtoken-env", func(t *testing.T) {
+		err := newMockCmd(t, nil, urlFlag, url, filesFlag, files, idxUrlFlag, idxUrl, "--authtoken-env", "UPLOADCMD_TEST_XXX").Execute()
+		require.EqualError(t, err, "the environment variable [UPLOADCMD_TEST_XXX] (--authtoken-env) is not set")
+	})
+
+	t.Run("Invalid --tls-cacert", func(t *testing.T) {
+		err := newMockCmd(t, nil, urlFlag, url, filesFlag, files, idxUrlFlag, idxUrl, "--tls-cacert", "./xxx.crt").Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error reading CA certificate file [./xxx.crt]")
+	})
+
 	t.Run("Next update key required", func(t *testing.T) {
-		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, filesFlag, files, idxUrlFlag, idxUrl, signingkeyFlag, signingKey).Execute(), errNextUpdateKeyOrFileRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, filesFlag, files, idxUrlFlag, idxUrl, signingkeyFlag, signingKey).Execute(), common.ErrNextUpdateKeyOrFileRequired.Error())
 	})
 
 	t.Run("Next update key and file specified", func(t *testing.T) {
-		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, filesFlag, files, idxUrlFlag, idxUrl, nextUpdateKeyFlag, nextUpdateKey, nextUpdateKeyFileFlag, "./pub_key", signingkeyFlag, signingKey).Execute(), errOnlyOneOfNextUpdateKeyOrFileRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, filesFlag, files, idxUrlFlag, idxUrl, nextUpdateKeyFlag, nextUpdateKey, nextUpdateKeyFileFlag, "./pub_key", signingkeyFlag, signingKey).Execute(), common.ErrOnlyOneOfNextUpdateKeyOrFileRequired.Error())
 	})
 
 	t.Run("Update key required", func(t *testing.T) {
-		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, filesFlag, files, idxUrlFlag, idxUrl, nextUpdateKeyFlag, nextUpdateKey, nextUpdateKeyFileFlag, "./pub_key").Execute(), errSigningKeyOrFileRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, filesFlag, files, idxUrlFlag, idxUrl, nextUpdateKeyFlag, nextUpdateKey, nextUpdateKeyFileFlag, "./pub_key").Execute(), common.ErrSigningKeyOrFileRequired.Error())
 	})
 
 	t.Run("Update key and file specified", func(t *testing.T) {
-		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, filesFlag, files, idxUrlFlag, idxUrl, nextUpdateKeyFileFlag, "./pub_key", signingkeyFlag, signingKey, signingkeyfileFlag, "./key").Execute(), errOnlyOneOfSigningKeyOrFileRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, urlFlag, url, filesFlag, files, idxUrlFlag, idxUrl, nextUpdateKeyFileFlag, "./pub_key", signingkeyFlag, signingKey, signingkeyfileFlag, "./key").Execute(), common.ErrOnlyOneOfSigningKeyOrFileRequired.Error())
 	})
 }
 
@@ -145,7 +172,7 @@ func TestUploadCmd(t *testing.T) {
 
 		c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("Y\n")}, w, transport, args...)
 		require.NoError(t, c.Execute())
-		require.Contains(t, w.Written(), msgContinueOrAbort)
+		require.Contains(t, w.Written(), common.MsgContinueOrAbort)
 		require.Contains(t, w.Written(), resp)
 	})
 
@@ -154,7 +181,7 @@ func TestUploadCmd(t *testing.T) {
 
 		c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("Y\n")}, w, transport, append(args, "--noprompt")...)
 		require.NoError(t, c.Execute())
-		require.NotContains(t, w.Written(), msgContinueOrAbort)
+		require.NotContains(t, w.Written(), common.MsgContinueOrAbort)
 		require.Contains(t, w.Written(), resp)
 	})
 
@@ -165,7 +192,7 @@ func TestUploadCmd(t *testing.T) {
 		c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("Y\n")}, w, transport, args...)
 		err := c.Execute()
 		require.Error(t, err)
-		require.Contains(t, err.Error(), errPrivateKeyNotFoundInPEM.Error())
+		require.Contains(t, err.Error(), common.ErrPrivateKeyNotFoundInPEM.Error())
 		require.Contains(t, w.Written(), err.Error())
 	})
 
@@ -176,7 +203,7 @@ func TestUploadCmd(t *testing.T) {
 			w := &mocks.Writer{}
 			c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("Y\n")}, w, transport, append(args, "--signingkeyfile", "../testdata/update2_private.key")...)
 			require.NoError(t, c.Execute())
-			require.NotContains(t, w.Written(), msgContinueOrAbort)
+			require.NotContains(t, w.Written(), common.MsgContinueOrAbort)
 			require.Contains(t, w.Written(), resp)
 		})
 
@@ -198,7 +225,7 @@ func TestUploadCmd(t *testing.T) {
 			w := &mocks.Writer{}
 			c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("Y\n")}, w, transport, append(args, "--nextupdatekeyfile", "../testdata/update2_public.key")...)
 			require.NoError(t, c.Execute())
-			require.NotContains(t, w.Written(), msgContinueOrAbort)
+			require.NotContains(t, w.Written(), common.MsgContinueOrAbort)
 			require.Contains(t, w.Written(), resp)
 		})
 
@@ -218,8 +245,8 @@ func TestUploadCmd(t *testing.T) {
 
 		c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("N\n")}, w, transport, args...)
 		require.NoError(t, c.Execute())
-		require.Contains(t, w.Written(), msgContinueOrAbort)
-		require.Contains(t, w.Written(), msgAborted)
+		require.Contains(t, w.Written(), common.MsgContinueOrAbort)
+		require.Contains(t, w.Written(), common.MsgAborted)
 		require.NotContains(t, w.Written(), resp)
 	})
 
@@ -292,6 +319,38 @@ func TestUploadCmd(t *testing.T) {
 		require.Contains(t, err.Error(), "not found")
 	})
 
+	t.Run("Transient error retrieving file IDX doc -> retried", func(t *testing.T) {
+		transport := mocks.NewTransport().
+			WithOrderedGetResponses(
+				&http.Response{
+					StatusCode: http.StatusBadGateway,
+					Header:     header,
+					Body:       mocks.NewResponseBody([]byte("bad gateway")),
+				},
+			).
+			WithGetResponse(
+				&http.Response{
+					StatusCode: http.StatusOK,
+					Header:     header,
+					Body:       mocks.NewResponseBody(didResolutionBytes),
+				},
+			).
+			WithPostResponse(
+				&http.Response{
+					StatusCode: http.StatusOK,
+					Header:     header,
+					Body:       mocks.NewResponseBody([]byte(dcasIDJSON)),
+				},
+			)
+
+		w := &mocks.Writer{}
+
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, transport, append(args, "--noprompt", "--http-retry-backoff", "1ms")...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), resp)
+		require.Len(t, transport.GetRequests, 2)
+	})
+
 	t.Run("Unuathorized", func(t *testing.T) {
 		transport := mocks.NewTransport().WithGetResponse(
 			&http.Response{
@@ -335,23 +394,463 @@ func TestUploadCmd(t *testing.T) {
 	})
 }
 
-func TestContentTypeFromFileName(t *testing.T) {
-	t.Run("Success", func(t *testing.T) {
-		contentType, err := contentTypeFromFileName("file.json")
+func TestUploadCmd_PartialFailure(t *testing.T) {
+	const (
+		url    = "http://localhost:48326/content"
+		idxUrl = "http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+	)
+
+	fileIdxDocBytes, err := json.Marshal(&model.FileIndexDoc{
+		ID:        "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==",
+		FileIndex: model.FileIndex{BasePath: "/content"},
+	})
+	require.NoError(t, err)
+
+	args := []string{"--url", url, "--idxurl", idxUrl, "--nextupdatekey", nextUpdateKey, "--signingkey", signingKey, "--noprompt", "--dir", "./testdata/schemas", "--include", "*.json"}
+
+	newResponse := func(status int, body string) *http.Response {
+		return &http.Response{StatusCode: status, Body: mocks.NewResponseBody([]byte(body))}
+	}
+
+	t.Run("Index updated with successful uploads", func(t *testing.T) {
+		transport := mocks.NewTransport().
+			WithGetResponse(newResponse(http.StatusOK, string(fileIdxDocBytes))).
+			WithPostResponses(
+				newResponse(http.StatusOK, `"id1"`),
+				newResponse(http.StatusBadRequest, "bad request"),
+				newResponse(http.StatusOK, `"id3"`),
+			).
+			WithPostResponse(newResponse(http.StatusOK, ""))
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, transport, args...)
+
+		err := c.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "the file index document was updated with the 2 file(s) that were uploaded successfully")
+		require.Contains(t, err.Error(), "[v1/person.schema.json]: status code 400: bad request")
+		require.Contains(t, w.Written(), `"Name":"v1/address.schema.json","ID":"id1"`)
+		require.Contains(t, w.Written(), `"Name":"v2/person.schema.json","ID":"id3"`)
+
+		// Three uploads plus the update of the file index
+		require.Len(t, transport.PostRequests, 4)
+		require.Contains(t, string(transport.PostRequests[3]), "v1~1address.schema.json")
+		require.NotContains(t, string(transport.PostRequests[3]), "v1~1person.schema.json")
+		require.Contains(t, string(transport.PostRequests[3]), "v2~1person.schema.json")
+	})
+
+	t.Run("All uploads failed", func(t *testing.T) {
+		transport := mocks.NewTransport().
+			WithGetResponse(newResponse(http.StatusOK, string(fileIdxDocBytes))).
+			WithPostResponse(newResponse(http.StatusBadRequest, "bad request"))
+
+		err := newMockCmd(t, transport, append(args, "--parallel", "2")...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error uploading 3 file(s)")
+
+		// The file index must not be updated
+		require.Len(t, transport.PostRequests, 3)
+	})
+
+	t.Run("Invalid --parallel", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, append(args, "--parallel", "0")...).Execute(), errInvalidParallel.Error())
+	})
+}
+
+func TestUploadCmd_UploadMode(t *testing.T) {
+	const (
+		url    = "http://localhost:48326/content"
+		idxUrl = "http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+		file   = "./testdata/schemas/v1/address.schema.json"
+	)
+
+	fileIdxDocBytes, err := json.Marshal(&model.FileIndexDoc{
+		ID:        "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==",
+		FileIndex: model.FileIndex{BasePath: "/content"},
+	})
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+
+	args := []string{"--url", url, "--idxurl", idxUrl, "--nextupdatekey", nextUpdateKey, "--signingkey", signingKey, "--noprompt", "--files", file}
+
+	// A file that is larger than two chunks of the minimum size
+	dir, err := ioutil.TempDir("", "upload")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	largeFile := filepath.Join(dir, "address.schema.json")
+	largeContent := bytes.Repeat(content, 2*common.MinChunkSize/len(content)+1)
+	require.NoError(t, ioutil.WriteFile(largeFile, largeContent, 0600))
+
+	chunkedArgs := []string{"--url", url, "--idxurl", idxUrl, "--nextupdatekey", nextUpdateKey, "--signingkey", signingKey, "--noprompt", "--files", largeFile}
+	chunkSize := (len(largeContent) + 1) / 2
+
+	newResponse := func(body string) *http.Response {
+		return &http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody([]byte(body))}
+	}
+
+	t.Run("Stream", func(t *testing.T) {
+		transport := mocks.NewTransport().
+			WithGetResponse(newResponse(string(fileIdxDocBytes))).
+			WithPostResponse(newResponse(`"id"`))
+
+		require.NoError(t, newMockCmd(t, transport, append(args, "--uploadmode", "stream")...).Execute())
+
+		// The upload plus the update of the file index
+		require.Len(t, transport.PostRequests, 2)
+		require.Equal(t, "application/json", transport.PostContentTypes[0])
+		require.Equal(t, content, transport.PostRequests[0])
+	})
+
+	t.Run("Chunked", func(t *testing.T) {
+		transport := mocks.NewTransport().
+			WithGetResponse(newResponse(string(fileIdxDocBytes))).
+			WithPostResponses(
+				newResponse(`"chunk1"`),
+				newResponse(`"chunk2"`),
+				newResponse(`"manifest"`),
+			).
+			WithPostResponse(newResponse(""))
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, transport, append(chunkedArgs, "--uploadmode", "chunked", "--chunksize", strconv.Itoa(chunkSize))...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), `"Name":"address.schema.json","ID":"manifest"`)
+
+		// Two chunks and the manifest plus the update of the file index
+		require.Len(t, transport.PostRequests, 4)
+
+		updateReq := string(transport.PostRequests[3])
+		require.Contains(t, updateReq, `{"op":"add","path":"/fileIndex/mappings/.chunks~1chunk1","value":"chunk1"}`)
+		require.Contains(t, updateReq, `{"op":"add","path":"/fileIndex/mappings/.chunks~1chunk2","value":"chunk2"}`)
+		require.Contains(t, updateReq, `{"op":"add","path":"/fileIndex/mappings/address.schema.json","value":"manifest"}`)
+	})
+
+	t.Run("Chunked - replace", func(t *testing.T) {
+		oldManifestBytes, err := json.Marshal(&model.FileManifest{ContentType: "application/json", Size: 10, Chunks: []string{"oldchunk", "chunk1"}})
+		require.NoError(t, err)
+
+		oldManifestID, err := common.GetDCASID(model.ManifestContentType, oldManifestBytes)
+		require.NoError(t, err)
+
+		docBytes, err := json.Marshal(&model.FileIndexDoc{
+			ID: "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==",
+			FileIndex: model.FileIndex{
+				BasePath: "/content",
+				Mappings: map[string]string{
+					"address.schema.json":               oldManifestID,
+					common.ChunkMappingName("oldchunk"): "oldchunk",
+					common.ChunkMappingName("chunk1"):   "chunk1",
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		transport := mocks.NewTransport().
+			WithGetResponse(newResponse(string(docBytes))).
+			WithGetResponseForURL(url+"/address.schema.json", &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     map[string][]string{"Content-Type": {model.ManifestContentType}},
+				Body:       mocks.NewResponseBody(oldManifestBytes),
+			}).
+			WithPostResponses(
+				newResponse(`"chunk1"`),
+				newResponse(`"chunk2"`),
+				newResponse(`"manifest"`),
+			).
+			WithPostResponse(newResponse(""))
+
+		require.NoError(t, newMockCmd(t, transport, append(chunkedArgs, "--uploadmode", "chunked", "--chunksize", strconv.Itoa(chunkSize))...).Execute())
+
+		require.Len(t, transport.PostRequests, 4)
+
+		// The mapping of the old chunk is removed after the file is replaced. The mapping of chunk1 is kept
+		// since it is also a chunk of the new file.
+		updateReq := string(transport.PostRequests[3])
+		require.Contains(t, updateReq, `{"op":"add","path":"/fileIndex/mappings/.chunks~1chunk2","value":"chunk2"},`+
+			`{"op":"replace","path":"/fileIndex/mappings/address.schema.json","value":"manifest"},`+
+			`{"op":"remove","path":"/fileIndex/mappings/.chunks~1oldchunk"}`)
+		require.NotContains(t, updateReq, `.chunks~1chunk1"}`)
+	})
+
+	t.Run("Invalid --uploadmode", func(t *testing.T) {
+		err := newMockCmd(t, nil, append(args, "--uploadmode", "xxx")...).Execute()
+		require.EqualError(t, err, "invalid upload mode (--uploadmode) [xxx] - expecting one of: json, stream, chunked")
+	})
+
+	t.Run("Invalid --chunksize", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, append(args, "--chunksize", strconv.Itoa(common.MinChunkSize-1))...).Execute(), errInvalidChunkSize.Error())
+	})
+}
+
+func TestUploadCmd_ContentType(t *testing.T) {
+	const (
+		url    = "http://localhost:48326/content"
+		idxUrl = "http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+	)
+
+	dir, err := ioutil.TempDir("", "uploadcmd")
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM golang:1.14\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "context.jsonld"), []byte(`{"@context":{}}`), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "did"), []byte(`{"@context":"https://www.w3.org/ns/did/v1"}`), 0600))
+
+	fileIdxDocBytes, err := json.Marshal(&model.FileIndexDoc{
+		ID:        "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==",
+		FileIndex: model.FileIndex{BasePath: "/content"},
+	})
+	require.NoError(t, err)
+
+	newResponse := func(body string) *http.Response {
+		return &http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody([]byte(body))}
+	}
+
+	args := []string{"--url", url, "--idxurl", idxUrl, "--nextupdatekey", nextUpdateKey, "--signingkey", signingKey, "--noprompt", "--dir", dir}
+
+	t.Run("Detected", func(t *testing.T) {
+		transport := mocks.NewTransport().
+			WithGetResponse(newResponse(string(fileIdxDocBytes))).
+			WithPostResponse(newResponse(`"id"`))
+
+		w := &mocks.Writer{}
+		require.NoError(t, newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, transport, args...).Execute())
+		require.Contains(t, w.Written(), `{"Name":"Dockerfile","ID":"id","ContentType":"text/plain; charset=utf-8"}`)
+		require.Contains(t, w.Written(), `{"Name":"context.jsonld","ID":"id","ContentType":"application/ld+json"}`)
+		require.Contains(t, w.Written(), `{"Name":"did","ID":"id","ContentType":"application/ld+json"}`)
+	})
+
+	t.Run("Override", func(t *testing.T) {
+		transport := mocks.NewTransport().
+			WithGetResponse(newResponse(string(fileIdxDocBytes))).
+			WithPostResponse(newResponse(`"id"`))
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, transport, append(args, "--content-type", "Dockerfile=text/x-dockerfile", "--content-type", "did=application/did+ld+json")...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), `{"Name":"Dockerfile","ID":"id","ContentType":"text/x-dockerfile"}`)
+		require.Contains(t, w.Written(), `{"Name":"did","ID":"id","ContentType":"application/did+ld+json"}`)
+	})
+
+	t.Run("Invalid --content-type", func(t *testing.T) {
+		err := newMockCmd(t, nil, append(args, "--content-type", "Dockerfile")...).Execute()
+		require.EqualError(t, err, "invalid content type (--content-type) [Dockerfile] - expecting pattern=type")
+	})
+
+	t.Run("Invalid --mime-map", func(t *testing.T) {
+		err := newMockCmd(t, nil, append(args, "--mime-map", filepath.Join(dir, "xxx.types"))...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error reading mime map (--mime-map)")
+	})
+}
+
+func TestUploadCmd_KeyStore(t *testing.T) {
+	const (
+		url          = "http://localhost:48326/content"
+		idxUrl       = "http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+		uniqueSuffix = "EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+	)
+
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	fileIdxDocBytes, err := json.Marshal(&model.FileIndexDoc{
+		ID:        "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==",
+		FileIndex: model.FileIndex{BasePath: "/content"},
+	})
+	require.NoError(t, err)
+
+	args := []string{"--url", url, "--idxurl", idxUrl, "--keystore", dir, "--noprompt", "--files", "./testdata/schemas/v1/address.schema.json"}
+
+	newTransport := func(updateStatus int) *mocks.MockTransport {
+		return mocks.NewTransport().
+			WithGetResponse(&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody(fileIdxDocBytes)}).
+			WithPostResponses(&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody([]byte(`"id"`))}).
+			WithPostResponse(&http.Response{StatusCode: updateStatus, Body: mocks.NewResponseBody([]byte("update response"))})
+	}
+
+	// getRevealValue returns the reveal value of the update request that was sent to the given transport
+	getRevealValue := func(t *testing.T, transport *mocks.MockTransport) string {
+		require.Len(t, transport.PostRequests, 2)
+
+		req := &sidetreemodel.UpdateRequest{}
+		require.NoError(t, json.Unmarshal(transport.PostRequests[1], req))
+
+		return req.RevealValue
+	}
+
+	revealValue := func(t *testing.T, key crypto.PrivateKey) string {
+		jwk, err := pubkey.GetPublicKeyJWK(&key.(*ecdsa.PrivateKey).PublicKey)
+		require.NoError(t, err)
+
+		rv, err := commitment.GetRevealValue(jwk, 18)
 		require.NoError(t, err)
-		require.Equal(t, "application/json", contentType)
+
+		return rv
+	}
+
+	keyStore := common.NewKeyStore(dir)
+
+	t.Run("Keys not imported", func(t *testing.T) {
+		transport := newTransport(http.StatusOK)
+
+		err := newMockCmd(t, transport, args...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "must first be imported into key store")
+		require.Empty(t, transport.PostRequests)
+	})
+
+	t.Run("Key store with keys", func(t *testing.T) {
+		err := newMockCmd(t, nil, append(args, "--signingkey", signingKey)...).Execute()
+		require.EqualError(t, err, common.ErrKeyStoreAndKeysSpecified.Error())
+	})
+
+	updateKey, err := common.PrivateKeyFromPEM([]byte(signingKey))
+	require.NoError(t, err)
+
+	require.NoError(t, keyStore.Import(uniqueSuffix, updateKey))
+
+	_, nextUpdateKey, err := keyStore.Get(uniqueSuffix)
+	require.NoError(t, err)
+
+	t.Run("Update failed -> keys not rotated", func(t *testing.T) {
+		transport := newTransport(http.StatusBadRequest)
+
+		err := newMockCmd(t, transport, args...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Status code 400: update response")
+		require.Equal(t, revealValue(t, updateKey), getRevealValue(t, transport))
+
+		current, next, err := keyStore.Get(uniqueSuffix)
+		require.NoError(t, err)
+		require.Equal(t, updateKey, current)
+		require.Equal(t, nextUpdateKey, next)
+	})
+
+	t.Run("Success -> keys rotated", func(t *testing.T) {
+		transport := newTransport(http.StatusOK)
+
+		require.NoError(t, newMockCmd(t, transport, args...).Execute())
+		require.Equal(t, revealValue(t, updateKey), getRevealValue(t, transport))
+
+		current, _, err := keyStore.Get(uniqueSuffix)
+		require.NoError(t, err)
+		require.Equal(t, nextUpdateKey, current)
+
+		// The next update is signed with the promoted key
+		transport = newTransport(http.StatusOK)
+
+		require.NoError(t, newMockCmd(t, transport, args...).Execute())
+		require.Equal(t, revealValue(t, nextUpdateKey), getRevealValue(t, transport))
+	})
+}
+
+func TestUploadCmd_Dir(t *testing.T) {
+	const (
+		url        = "http://localhost:48326/content"
+		idxUrl     = "http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+		dcasIDJSON = `"TbVyraOqG00TacPQH5WwWGnxkszpYSEhBKRyX_f25JI="`
+	)
+
+	fileIdxDocBytes, err := json.Marshal(&model.FileIndexDoc{
+		ID:        "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==",
+		FileIndex: model.FileIndex{BasePath: "/content", Mappings: map[string]string{"v1/person.schema.json": "xxx"}},
+	})
+	require.NoError(t, err)
+
+	args := []string{"--url", url, "--idxurl", idxUrl, "--nextupdatekey", nextUpdateKey, "--signingkey", signingKey, "--noprompt"}
+
+	newTransport := func() *mocks.MockTransport {
+		return mocks.NewTransport().
+			WithGetResponse(&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody(fileIdxDocBytes)}).
+			WithPostResponse(&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody([]byte(dcasIDJSON))})
+	}
+
+	getNames := func(t *testing.T, w *mocks.Writer) []string {
+		var result []*common.File
+		require.NoError(t, json.Unmarshal(w.Bytes, &result))
+
+		var names []string
+		for _, f := range result {
+			names = append(names, f.Name)
+		}
+
+		return names
+	}
+
+	t.Run("Directory with include", func(t *testing.T) {
+		transport := newTransport()
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, transport, append(args, "--dir", "./testdata/schemas", "--include", "*.json")...)
+		require.NoError(t, c.Execute())
+		require.Equal(t, []string{"v1/address.schema.json", "v1/person.schema.json", "v2/person.schema.json"}, getNames(t, w))
+
+		// Three uploads plus the update of the file index
+		require.Len(t, transport.PostRequests, 4)
+
+		updateReq := string(transport.PostRequests[3])
+		require.Contains(t, updateReq, `{"op":"add","path":"/fileIndex/mappings/v1~1address.schema.json"`)
+		require.Contains(t, updateReq, `{"op":"replace","path":"/fileIndex/mappings/v1~1person.schema.json"`)
+		require.Contains(t, updateReq, `{"op":"add","path":"/fileIndex/mappings/v2~1person.schema.json"`)
 	})
 
-	t.Run("No extension -> error", func(t *testing.T) {
-		contentType, err := contentTypeFromFileName("file")
-		require.EqualError(t, err, errNoFileExtension.Error())
-		require.Empty(t, contentType)
+	t.Run("Directory with exclude", func(t *testing.T) {
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, newTransport(), append(args, "--dir", "./testdata/schemas", "--exclude", "v2/*,*.md")...)
+		require.NoError(t, c.Execute())
+		require.Equal(t, []string{"v1/address.schema.json", "v1/person.schema.json"}, getNames(t, w))
 	})
 
-	t.Run("No extension -> error", func(t *testing.T) {
-		contentType, err := contentTypeFromFileName("file.xxx")
-		require.EqualError(t, err, "file.xxx: content type cannot be deduced from extension")
-		require.Empty(t, contentType)
+	t.Run("Glob", func(t *testing.T) {
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, newTransport(), append(args, "--files", "./testdata/schemas/v1/*.json")...)
+		require.NoError(t, c.Execute())
+		require.Equal(t, []string{"address.schema.json", "person.schema.json"}, getNames(t, w))
+	})
+
+	t.Run("Duplicate names", func(t *testing.T) {
+		c := newMockCmd(t, newTransport(), append(args, "--files", "./testdata/schemas/v*/person.schema.json")...)
+		err := c.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "would both be indexed as [person.schema.json]")
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		c := newMockCmd(t, newTransport(), append(args, "--files", "./testdata/schemas/*.xml")...)
+		err := c.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no files match pattern")
+	})
+
+	t.Run("All excluded", func(t *testing.T) {
+		c := newMockCmd(t, newTransport(), append(args, "--dir", "./testdata/schemas", "--include", "*.xml")...)
+		require.EqualError(t, c.Execute(), common.ErrNoFilesMatched.Error())
+	})
+
+	t.Run("Invalid pattern", func(t *testing.T) {
+		c := newMockCmd(t, newTransport(), append(args, "--dir", "./testdata/schemas", "--include", "[")...)
+		err := c.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid pattern [[]")
+	})
+
+	t.Run("Directory not found", func(t *testing.T) {
+		c := newMockCmd(t, newTransport(), append(args, "--dir", "./testdata/xxx")...)
+		err := c.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error reading directory [./testdata/xxx]")
 	})
 }
 
@@ -374,3 +873,66 @@ func newMockCmdWithReaderWriter(t *testing.T, in io.Reader, w io.Writer, transpo
 
 	return c
 }
+
+func TestUploadCmd_WaitPublished(t *testing.T) {
+	const (
+		url    = "http://localhost:48326/content"
+		idxUrl = "http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+	)
+
+	fileIdxDocBytes, err := json.Marshal(&model.FileIndexDoc{
+		ID:        "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==",
+		FileIndex: model.FileIndex{BasePath: "/content"},
+	})
+	require.NoError(t, err)
+
+	nextKey, err := common.PublicKeyFromPEM([]byte(nextUpdateKey))
+	require.NoError(t, err)
+
+	nextKeyJWK, err := pubkey.GetPublicKeyJWK(nextKey)
+	require.NoError(t, err)
+
+	updateCommitment, err := commitment.GetCommitment(nextKeyJWK, 18)
+	require.NoError(t, err)
+
+	newResolution := func(t *testing.T, metadata string) *http.Response {
+		resolutionBytes, err := json.Marshal(model.DIDResolution{DIDDocument: fileIdxDocBytes, MethodMetadata: []byte(metadata)})
+		require.NoError(t, err)
+
+		return &http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody(resolutionBytes)}
+	}
+
+	newTransport := func(getResponses ...*http.Response) *mocks.MockTransport {
+		return mocks.NewTransport().
+			WithOrderedGetResponses(getResponses...).
+			WithPostResponses(&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody([]byte(`"id"`))}).
+			WithPostResponse(&http.Response{StatusCode: http.StatusOK, Body: mocks.NewResponseBody([]byte("{}"))})
+	}
+
+	args := []string{"--url", url, "--idxurl", idxUrl, "--nextupdatekey", nextUpdateKey, "--signingkey", signingKey, "--noprompt",
+		"--files", "./testdata/schemas/v1/address.schema.json", "--wait-published"}
+
+	t.Run("Published", func(t *testing.T) {
+		transport := newTransport(
+			newResolution(t, `{"published":true,"updateCommitment":"previous"}`),
+			newResolution(t, `{"published":true,"updateCommitment":"`+updateCommitment+`"}`),
+		)
+
+		require.NoError(t, newMockCmd(t, transport, args...).Execute())
+		require.Equal(t, []string{idxUrl, idxUrl}, transport.GetRequests)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		transport := newTransport(newResolution(t, `{"published":true,"updateCommitment":"previous"}`)).
+			WithGetResponse(newResolution(t, `{"published":false,"updateCommitment":"`+updateCommitment+`"}`))
+
+		err := newMockCmd(t, transport, append(args, "--timeout", "10ms")...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "timed out after 10ms waiting for the operation on file index document")
+	})
+
+	t.Run("Invalid timeout", func(t *testing.T) {
+		err := newMockCmd(t, nil, append(args, "--timeout", "0s")...).Execute()
+		require.EqualError(t, err, "invalid timeout (--timeout) [0s] - the timeout must be greater than 0")
+	})
+}
@@ -0,0 +1,373 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifycmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+)
+
+const (
+	use      = "verify"
+	desc     = "Verify that the files in a file index document are retrievable and match their IDs"
+	longDesc = `
+The verify command checks the health of a file index document. Every file that is mapped in the file index document (including the chunks of files that were uploaded in chunks) is retrieved concurrently from the content endpoint and its content is verified against its DCAS ID. The response is a JSON document that contains the number of files that were verified along with the files that are missing (not found), mismatched (the content does not match the ID), unauthorized (access was denied) or failed (any other error).
+
+The command exits with an error if any of the files is not healthy so that it may be used for monitoring.
+`
+	examples = `
+- Verify all of the files in a file index document:
+    $ ./fabric file verify --idxurl http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==
+
+	Response:
+		{
+		  "FileIndex": "http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==",
+		  "Total": 3,
+		  "Healthy": 2,
+		  "Missing": [
+			{
+			  "Name": "person.schema.json",
+			  "ID": "TbVyraOqG00TacPQH5WwWGnxkszpYSEhBKRyX_f25JI=",
+			  "Error": "status code 404: not found"
+			}
+		  ]
+		}
+		Error: file index document [http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA==] is not healthy: 1 missing, 0 mismatched, 0 unauthorized, 0 failed
+`
+)
+
+const (
+	fileIndexURLFlag  = "idxurl"
+	fileIndexURLUsage = "The URL of the file index Sidetree document. Example: --idxurl http://localhost:48326/file/identifiers/file:idx:1234"
+
+	urlFlag  = "url"
+	urlUsage = "The URL of the content endpoint from which to retrieve the files. If not specified then the URL is derived from the host of --idxurl and the base path of the file index. Example: --url http://localhost:48326/content"
+
+	parallelFlag  = "parallel"
+	parallelUsage = "The maximum number of files that are retrieved concurrently. Example: --parallel 4"
+
+	defaultParallel = 10
+)
+
+var (
+	errFileIndexURLRequired = errors.New("file index URL (--idxurl) is required")
+	errInvalidParallel      = errors.New("the number of concurrent requests (--parallel) must be at least 1")
+)
+
+type httpClient interface {
+	Get(url string, opts ...httpclient.RequestOpt) (*httpclient.HTTPResponse, error)
+	GetStream(url string, opts ...httpclient.RequestOpt) (*httpclient.StreamResponse, error)
+}
+
+// New returns the file verify sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	return newCmd(settings, httpclient.New())
+}
+
+func newCmd(settings *environment.Settings, client httpClient) *cobra.Command {
+	c := &command{
+		Command: basecmd.New(settings, nil),
+		client:  client,
+	}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   desc,
+		Long:    longDesc,
+		Example: examples,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return c.validate()
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	c.Settings = settings
+	cmd.SetOutput(c.Settings.Streams.Out)
+	cmd.SilenceUsage = true
+
+	cmd.Flags().StringVar(&c.fileIndexURL, fileIndexURLFlag, "", fileIndexURLUsage)
+	cmd.Flags().StringVar(&c.url, urlFlag, "", urlUsage)
+	cmd.Flags().IntVar(&c.parallel, parallelFlag, defaultParallel, parallelUsage)
+	c.authOpts.AddFlags(cmd)
+	c.authOpts.AddContentFlags(cmd)
+	c.httpOpts.AddFlags(cmd)
+
+	return cmd
+}
+
+// command implements the verify command
+type command struct {
+	*basecmd.Command
+	client httpClient
+
+	// Flags
+	fileIndexURL string
+	url          string
+	parallel     int
+	httpOpts     common.HTTPClientOptions
+	authOpts     common.AuthOptions
+}
+
+// status is the result of verifying a single file
+type status int
+
+const (
+	statusHealthy status = iota
+	statusMissing
+	statusMismatched
+	statusUnauthorized
+	statusFailed
+)
+
+// fileResult contains the result of verifying a file
+type fileResult struct {
+	Name       string
+	ID         string
+	ComputedID string `json:",omitempty"`
+	Error      string `json:",omitempty"`
+
+	status status
+}
+
+// report contains the results of verifying the files in a file index document
+type report struct {
+	FileIndex    string
+	Total        int
+	Healthy      int
+	Missing      []*fileResult `json:",omitempty"`
+	Mismatched   []*fileResult `json:",omitempty"`
+	Unauthorized []*fileResult `json:",omitempty"`
+	Failed       []*fileResult `json:",omitempty"`
+}
+
+func (r *report) add(result *fileResult) {
+	switch result.status {
+	case statusHealthy:
+		r.Healthy++
+	case statusMissing:
+		r.Missing = append(r.Missing, result)
+	case statusMismatched:
+		r.Mismatched = append(r.Mismatched, result)
+	case statusUnauthorized:
+		r.Unauthorized = append(r.Unauthorized, result)
+	default:
+		r.Failed = append(r.Failed, result)
+	}
+}
+
+func (r *report) isHealthy() bool {
+	return r.Healthy == r.Total
+}
+
+func (c *command) validate() error {
+	if c.fileIndexURL == "" {
+		return errFileIndexURLRequired
+	}
+
+	if _, err := url.Parse(c.fileIndexURL); err != nil {
+		return errors.WithMessagef(err, "invalid file index URL [%s]", c.fileIndexURL)
+	}
+
+	if c.parallel < 1 {
+		return errInvalidParallel
+	}
+
+	if err := c.httpOpts.Configure(c.client, c.Settings.Streams.Err); err != nil {
+		return err
+	}
+
+	if err := c.authOpts.Configure(c.client); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *command) run() error {
+	fileIdxDoc, err := common.GetFileIndexDoc(c.client, c.fileIndexURL, c.authOpts.Token)
+	if err != nil {
+		return err
+	}
+
+	contentURL, err := c.getContentURL(fileIdxDoc.FileIndex.BasePath)
+	if err != nil {
+		return err
+	}
+
+	r := c.verify(contentURL, fileIdxDoc.FileIndex.Mappings)
+
+	reportBytes, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Fprint(string(reportBytes)); err != nil {
+		return err
+	}
+
+	if !r.isHealthy() {
+		return errors.Errorf("file index document [%s] is not healthy: %d missing, %d mismatched, %d unauthorized, %d failed",
+			c.fileIndexURL, len(r.Missing), len(r.Mismatched), len(r.Unauthorized), len(r.Failed))
+	}
+
+	return nil
+}
+
+// getContentURL returns the URL of the content endpoint. If the URL wasn't provided then it's
+// derived from the file index URL and the base path of the file index.
+func (c *command) getContentURL(basePath string) (string, error) {
+	if c.url != "" {
+		return c.url, nil
+	}
+
+	return common.GetContentURL(c.fileIndexURL, basePath)
+}
+
+// verify concurrently verifies each of the mapped files (including chunks) using a bounded pool of workers
+func (c *command) verify(contentURL string, mappings map[string]string) *report {
+	var names []string
+	for name := range mappings {
+		if name != common.BasePathMapping {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	results := make([]*fileResult, len(names))
+	indexes := make(chan int)
+
+	parallel := c.parallel
+	if parallel > len(names) {
+		parallel = len(names)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(parallel)
+
+	for w := 0; w < parallel; w++ {
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				results[i] = c.verifyFile(contentURL, names[i], mappings[names[i]])
+			}
+		}()
+	}
+
+	for i := range names {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	r := &report{
+		FileIndex: c.fileIndexURL,
+		Total:     len(names),
+	}
+
+	for _, result := range results {
+		r.add(result)
+	}
+
+	return r
+}
+
+// verifyFile retrieves the given file from the content endpoint and verifies that its content matches the given DCAS ID
+func (c *command) verifyFile(contentURL, name, id string) *fileResult {
+	result := &fileResult{Name: name, ID: id}
+
+	var reqOpts []httpclient.RequestOpt
+	if c.authOpts.ContentToken != "" {
+		reqOpts = append(reqOpts, httpclient.WithAuthToken(c.authOpts.ContentToken))
+	}
+
+	fileURL := common.GetFileURL(contentURL, name)
+
+	resp, err := c.client.GetStream(fileURL, reqOpts...)
+	if err != nil {
+		result.status = statusFromError(err)
+		result.Error = err.Error()
+
+		return result
+	}
+
+	defer c.closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		err = common.NewStatusError("", fileURL, &resp.HTTPResponse, common.ContentAuthTokenFlag)
+
+		result.status = statusFromError(err)
+		result.Error = err.Error()
+
+		return result
+	}
+
+	// The content is hashed as it's read so that large files aren't loaded into memory
+	h := common.NewDCASHash(resp.ContentType)
+
+	if _, err = io.Copy(h, resp.Body); err != nil {
+		result.status = statusFailed
+		result.Error = err.Error()
+
+		return result
+	}
+
+	computedID, err := h.ID()
+	if err != nil {
+		result.status = statusFailed
+		result.Error = err.Error()
+
+		return result
+	}
+
+	if computedID != id {
+		result.status = statusMismatched
+		result.ComputedID = computedID
+
+		return result
+	}
+
+	result.status = statusHealthy
+
+	return result
+}
+
+func (c *command) closeBody(body io.Closer) {
+	if err := body.Close(); err != nil {
+		fmt.Fprintf(c.Settings.Streams.Err, "error closing HTTP response: %s\n", err)
+	}
+}
+
+// statusFromError returns the status of a file that could not be retrieved due to the given error
+func statusFromError(err error) status {
+	switch {
+	case errors.Is(err, httpclient.ErrNotFound):
+		return statusMissing
+	case errors.Is(err, httpclient.ErrUnauthorized), errors.Is(err, httpclient.ErrForbidden):
+		return statusUnauthorized
+	default:
+		return statusFailed
+	}
+}
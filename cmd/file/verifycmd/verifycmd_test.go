@@ -0,0 +1,190 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifycmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/httpclient"
+	"github.com/trustbloc/fabric-cli-ext/cmd/file/model"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+const (
+	idxURL     = "http://localhost:48326/file/identifiers/file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+	contentURL = "http://localhost:48326/content"
+)
+
+func TestVerifyCmd_New(t *testing.T) {
+	require.NotNil(t, New(environment.NewDefaultSettings()))
+}
+
+func TestVerifyCmd_InvalidOptions(t *testing.T) {
+	t.Run("No options", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil).Execute(), errFileIndexURLRequired.Error())
+	})
+
+	t.Run("Invalid parallel", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, "--idxurl", idxURL, "--parallel", "0").Execute(), errInvalidParallel.Error())
+	})
+}
+
+func TestVerifyCmd(t *testing.T) {
+	const (
+		jsonContent = `{"name":"file1"}`
+		textContent = "file2 content"
+		chunk       = "chunk content"
+	)
+
+	jsonID, err := common.GetDCASID("application/json", []byte(jsonContent))
+	require.NoError(t, err)
+
+	textID, err := common.GetDCASID("text/plain", []byte(textContent))
+	require.NoError(t, err)
+
+	chunkID, err := common.GetDCASID(common.ChunkContentType, []byte(chunk))
+	require.NoError(t, err)
+
+	newResponse := func(status int, contentType string, payload []byte) *http.Response {
+		return &http.Response{
+			StatusCode: status,
+			Header:     map[string][]string{"Content-Type": {contentType}},
+			Body:       mocks.NewResponseBody(payload),
+		}
+	}
+
+	newTransport := func(mappings map[string]string) *mocks.MockTransport {
+		mappings["."] = "/content"
+
+		fileIdxDocBytes, err := json.Marshal(&model.FileIndexDoc{FileIndex: model.FileIndex{BasePath: "/content", Mappings: mappings}})
+		require.NoError(t, err)
+
+		didResolutionBytes, err := json.Marshal(model.DIDResolution{DIDDocument: fileIdxDocBytes})
+		require.NoError(t, err)
+
+		return mocks.NewTransport().
+			WithGetResponseForURL(idxURL, newResponse(http.StatusOK, "application/json", didResolutionBytes)).
+			WithGetResponseForURL(contentURL+"/file1.json", newResponse(http.StatusOK, "application/json", []byte(jsonContent))).
+			WithGetResponseForURL(contentURL+"/v1/file2", newResponse(http.StatusOK, "text/plain", []byte(textContent))).
+			WithGetResponseForURL(common.GetFileURL(contentURL, common.ChunkMappingName(chunkID)), newResponse(http.StatusOK, common.ChunkContentType, []byte(chunk))).
+			WithGetResponseForURL(contentURL+"/bad.json", newResponse(http.StatusOK, "application/json", []byte(jsonContent))).
+			WithGetResponseForURL(contentURL+"/private.json", newResponse(http.StatusUnauthorized, "text/plain", []byte("unauthorized"))).
+			WithGetResponseForURL(contentURL+"/forbidden.json", newResponse(http.StatusForbidden, "text/plain", []byte("forbidden"))).
+			WithGetResponseForURL(contentURL+"/error.json", newResponse(http.StatusInternalServerError, "text/plain", []byte("server error"))).
+			WithGetResponse(newResponse(http.StatusNotFound, "text/plain", []byte("not found")))
+	}
+
+	t.Run("Healthy", func(t *testing.T) {
+		transport := newTransport(map[string]string{
+			"file1.json":                     jsonID,
+			"v1/file2":                       textID,
+			common.ChunkMappingName(chunkID): chunkID,
+		})
+
+		w := &mocks.Writer{}
+		require.NoError(t, newMockCmdWithWriter(t, w, transport, "--idxurl", idxURL, "--parallel", "2").Execute())
+
+		r := &report{}
+		require.NoError(t, json.Unmarshal([]byte(w.Written()), r))
+		require.Equal(t, idxURL, r.FileIndex)
+		require.Equal(t, 3, r.Total)
+		require.Equal(t, 3, r.Healthy)
+		require.Empty(t, r.Missing)
+		require.Empty(t, r.Mismatched)
+		require.Empty(t, r.Unauthorized)
+		require.Empty(t, r.Failed)
+
+		// The index plus each of the mapped files (excluding the base path)
+		require.Len(t, transport.GetRequests, 4)
+	})
+
+	t.Run("Not healthy", func(t *testing.T) {
+		transport := newTransport(map[string]string{
+			"file1.json":     jsonID,
+			"bad.json":       textID,
+			"missing.json":   jsonID,
+			"private.json":   jsonID,
+			"forbidden.json": jsonID,
+			"error.json":     jsonID,
+		})
+
+		w := &mocks.Writer{}
+		err := newMockCmdWithWriter(t, w, transport, "--idxurl", idxURL).Execute()
+		require.EqualError(t, err, "file index document ["+idxURL+"] is not healthy: 1 missing, 1 mismatched, 2 unauthorized, 1 failed")
+
+		r := &report{}
+		// The report is followed by the error
+		require.NoError(t, json.NewDecoder(strings.NewReader(w.Written())).Decode(r))
+		require.Equal(t, 6, r.Total)
+		require.Equal(t, 1, r.Healthy)
+
+		require.Len(t, r.Missing, 1)
+		require.Equal(t, "missing.json", r.Missing[0].Name)
+		require.Equal(t, jsonID, r.Missing[0].ID)
+		require.Equal(t, "status code 404: not found", r.Missing[0].Error)
+
+		require.Len(t, r.Mismatched, 1)
+		require.Equal(t, "bad.json", r.Mismatched[0].Name)
+		require.Equal(t, textID, r.Mismatched[0].ID)
+		require.Equal(t, jsonID, r.Mismatched[0].ComputedID)
+
+		require.Len(t, r.Unauthorized, 2)
+		require.Equal(t, "forbidden.json", r.Unauthorized[0].Name)
+		require.Equal(t, "private.json", r.Unauthorized[1].Name)
+		require.Equal(t, "status code 401: unauthorized - Did you provide an authorization token (--contentauthtoken)?", r.Unauthorized[1].Error)
+
+		require.Len(t, r.Failed, 1)
+		require.Equal(t, "error.json", r.Failed[0].Name)
+		require.Contains(t, r.Failed[0].Error, "server error")
+	})
+
+	t.Run("With content URL", func(t *testing.T) {
+		transport := newTransport(map[string]string{"file1.json": jsonID})
+
+		err := newMockCmd(t, transport, "--idxurl", idxURL, "--url", "http://localhost:48326/other").Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "1 missing")
+	})
+
+	t.Run("Index not found", func(t *testing.T) {
+		transport := mocks.NewTransport().WithGetResponse(newResponse(http.StatusNotFound, "text/plain", []byte("not found")))
+
+		err := newMockCmd(t, transport, "--idxurl", idxURL).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+	})
+}
+
+func newMockCmd(t *testing.T, rt http.RoundTripper, args ...string) *cobra.Command {
+	return newMockCmdWithWriter(t, &mocks.Writer{}, rt, args...)
+}
+
+func newMockCmdWithWriter(t *testing.T, w io.Writer, transport http.RoundTripper, args ...string) *cobra.Command {
+	settings := environment.NewDefaultSettings()
+	settings.Streams.Out = w
+	settings.Streams.In = &mocks.Reader{}
+
+	settings.Config.CurrentContext = "testctx"
+	settings.Config.Contexts[settings.Config.CurrentContext] = &environment.Context{}
+
+	c := newCmd(settings, httpclient.New(httpclient.WithTransport(transport)))
+	require.NotNil(t, c)
+
+	c.SetArgs(args)
+
+	return c
+}
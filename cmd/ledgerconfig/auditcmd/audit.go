@@ -0,0 +1,384 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auditcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+)
+
+// configKey identifies an application (or application component) configuration independent of the peer
+type configKey struct {
+	appName          string
+	appVersion       string
+	componentName    string
+	componentVersion string
+}
+
+func (k configKey) String() string {
+	if k.componentName == "" {
+		return fmt.Sprintf("app [%s] version [%s]", k.appName, k.appVersion)
+	}
+
+	return fmt.Sprintf("component [%s] version [%s] of app [%s] version [%s]",
+		k.componentName, k.componentVersion, k.appName, k.appVersion)
+}
+
+// finding describes a single inconsistency between peers
+type finding struct {
+	msg     string
+	details []string
+}
+
+func (f *finding) String() string {
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf("- %s", f.msg))
+
+	for _, d := range f.details {
+		s.WriteString(fmt.Sprintf("\n    %s", d))
+	}
+
+	return s.String()
+}
+
+// peerSet is a set of peer IDs
+type peerSet map[string]struct{}
+
+func (s peerSet) add(peerID string) {
+	s[peerID] = struct{}{}
+}
+
+// missing returns the given peers that are not in the set
+func (s peerSet) missing(peers []string) []string {
+	var missing []string
+
+	for _, peerID := range peers {
+		if _, ok := s[peerID]; !ok {
+			missing = append(missing, peerID)
+		}
+	}
+
+	return missing
+}
+
+func (s peerSet) sorted() []string {
+	peers := make([]string, 0, len(s))
+	for peerID := range s {
+		peers = append(peers, peerID)
+	}
+
+	sort.Strings(peers)
+
+	return peers
+}
+
+// audit compares the peer-specific key-values across the given peers and returns the inconsistencies.
+// Key-values that are not associated with a peer are ignored.
+func audit(peers []string, kvs []*common.KeyValue) []*finding {
+	appPeers := make(map[string]peerSet)
+	appVersionPeers := make(map[configKey]peerSet)
+	configs := make(map[configKey]map[string]*common.KeyValue)
+
+	for _, kv := range kvs {
+		if kv.PeerID == "" {
+			continue
+		}
+
+		appKey := configKey{appName: kv.AppName, appVersion: kv.AppVersion}
+		key := configKey{appName: kv.AppName, appVersion: kv.AppVersion, componentName: kv.ComponentName, componentVersion: kv.ComponentVersion}
+
+		if _, ok := appPeers[kv.AppName]; !ok {
+			appPeers[kv.AppName] = make(peerSet)
+		}
+
+		appPeers[kv.AppName].add(kv.PeerID)
+
+		if _, ok := appVersionPeers[appKey]; !ok {
+			appVersionPeers[appKey] = make(peerSet)
+		}
+
+		appVersionPeers[appKey].add(kv.PeerID)
+
+		peerConfigs, ok := configs[key]
+		if !ok {
+			peerConfigs = make(map[string]*common.KeyValue)
+			configs[key] = peerConfigs
+		}
+
+		peerConfigs[kv.PeerID] = kv
+	}
+
+	var findings []*finding
+
+	for _, appName := range sortedAppNames(appPeers) {
+		if missing := appPeers[appName].missing(peers); len(missing) > 0 {
+			findings = append(findings, &finding{
+				msg: fmt.Sprintf("App [%s] is missing on peers %s", appName, missing),
+			})
+		}
+	}
+
+	for _, key := range sortedKeys(appVersionPeers) {
+		if missing := appVersionPeers[key].missing(appPeers[key.appName].sorted()); len(missing) > 0 {
+			findings = append(findings, &finding{
+				msg: fmt.Sprintf("Version [%s] of app [%s] only exists on peers %s", key.appVersion, key.appName, appVersionPeers[key].sorted()),
+			})
+		}
+	}
+
+	for _, key := range sortedConfigKeys(configs) {
+		findings = append(findings, auditConfig(key, appVersionPeers, configs[key])...)
+	}
+
+	return findings
+}
+
+// auditConfig compares the configuration for the given key across the peers that have the application version
+func auditConfig(key configKey, appVersionPeers map[configKey]peerSet, peerConfigs map[string]*common.KeyValue) []*finding {
+	var findings []*finding
+
+	holders := make(peerSet)
+	for peerID := range peerConfigs {
+		holders.add(peerID)
+	}
+
+	if key.componentName != "" {
+		appKey := configKey{appName: key.appName, appVersion: key.appVersion}
+
+		if missing := holders.missing(appVersionPeers[appKey].sorted()); len(missing) > 0 {
+			findings = append(findings, &finding{
+				msg: fmt.Sprintf("The %s is missing on peers %s", key, missing),
+			})
+		}
+	}
+
+	peers := holders.sorted()
+	if len(peers) < 2 {
+		return findings
+	}
+
+	fieldValues := make(map[string]map[string]string)
+
+	for _, peerID := range peers {
+		for field, value := range decodeFields(peerConfigs[peerID]) {
+			values, ok := fieldValues[field]
+			if !ok {
+				values = make(map[string]string)
+				fieldValues[field] = values
+			}
+
+			values[peerID] = value
+		}
+	}
+
+	fields := make([]string, 0, len(fieldValues))
+	for field := range fieldValues {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		if details, differs := compareField(peers, fieldValues[field]); differs {
+			var msg string
+			if field == "" {
+				msg = fmt.Sprintf("Config of %s differs between peers:", key)
+			} else {
+				msg = fmt.Sprintf("Field [%s] of %s differs between peers:", field, key)
+			}
+
+			findings = append(findings, &finding{msg: msg, details: details})
+		}
+	}
+
+	return findings
+}
+
+// compareField returns the value of a field on each peer and true if the value is not the same on all peers
+func compareField(peers []string, values map[string]string) ([]string, bool) {
+	differs := false
+	details := make([]string, len(peers))
+
+	for i, peerID := range peers {
+		value, ok := values[peerID]
+		if !ok {
+			value = "<missing>"
+		}
+
+		if !ok || value != values[peers[0]] {
+			differs = true
+		}
+
+		details[i] = fmt.Sprintf("%s: %s", peerID, value)
+	}
+
+	return details, differs
+}
+
+// decodeFields decodes the configuration of the given key-value according to its format and returns
+// the flattened fields mapped to their JSON-encoded values. If the configuration is not in JSON or YAML
+// format (or it cannot be decoded) then the entire configuration is returned as a single field with an
+// empty name.
+func decodeFields(kv *common.KeyValue) map[string]string {
+	var v interface{}
+	var err error
+
+	switch common.Format(strings.ToUpper(string(kv.Format))) {
+	case common.JSONFormat:
+		err = decodeJSON(kv.Config, &v)
+	case common.YAMLFormat:
+		err = yaml.Unmarshal([]byte(kv.Config), &v)
+	default:
+		return map[string]string{"": kv.Config}
+	}
+
+	if err != nil {
+		return map[string]string{"": kv.Config}
+	}
+
+	fields := make(map[string]string)
+	flatten("", v, fields)
+
+	return fields
+}
+
+// decodeJSON decodes the given JSON document. Numbers are decoded as json.Number so that large integers
+// that differ are not rounded to the same float64.
+func decodeJSON(doc string, v interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(doc))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("unexpected data after top-level value")
+	}
+
+	return nil
+}
+
+// flatten adds the leaf values of the given value to fields, keyed by their path. Empty maps and slices
+// are leaf values so that an empty value is distinguished from a missing one.
+func flatten(prefix string, v interface{}, fields map[string]string) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		if len(value) == 0 {
+			fields[prefix] = "{}"
+		}
+
+		for k, fv := range value {
+			flatten(join(prefix, k), fv, fields)
+		}
+	case map[interface{}]interface{}:
+		if len(value) == 0 {
+			fields[prefix] = "{}"
+		}
+
+		for k, fv := range value {
+			flatten(join(prefix, fmt.Sprint(k)), fv, fields)
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			fields[prefix] = "[]"
+		}
+
+		for i, fv := range value {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), fv, fields)
+		}
+	default:
+		valueBytes, err := json.Marshal(value)
+		if err != nil {
+			fields[prefix] = fmt.Sprint(value)
+		} else {
+			fields[prefix] = string(valueBytes)
+		}
+	}
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + "." + name
+}
+
+func sortedAppNames(m map[string]peerSet) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func sortedKeys(m map[configKey]peerSet) []configKey {
+	keys := make([]configKey, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sortConfigKeys(keys)
+
+	return keys
+}
+
+func sortedConfigKeys(m map[configKey]map[string]*common.KeyValue) []configKey {
+	keys := make([]configKey, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sortConfigKeys(keys)
+
+	return keys
+}
+
+func sortConfigKeys(keys []configKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		ki, kj := keys[i], keys[j]
+
+		if ki.appName != kj.appName {
+			return ki.appName < kj.appName
+		}
+
+		if ki.appVersion != kj.appVersion {
+			return ki.appVersion < kj.appVersion
+		}
+
+		if ki.componentName != kj.componentName {
+			return ki.componentName < kj.componentName
+		}
+
+		return ki.componentVersion < kj.componentVersion
+	})
+}
+
+// peerIDs returns the sorted, unique IDs of the peers in the given key-values
+func peerIDs(kvs []*common.KeyValue) []string {
+	peers := make(peerSet)
+
+	for _, kv := range kvs {
+		if kv.PeerID != "" {
+			peers.add(kv.PeerID)
+		}
+	}
+
+	return peers.sorted()
+}
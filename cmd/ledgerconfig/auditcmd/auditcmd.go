@@ -0,0 +1,158 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auditcmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+)
+
+const (
+	use      = "audit"
+	desc     = "Audit peer-specific ledger configuration for consistency"
+	longDesc = `
+The audit command retrieves the peer-specific configuration that matches the given search criteria and compares the
+configuration of each application (and application component) across all of the peers in the MSP. The following
+inconsistencies are reported:
+
+* Peers that are missing an application (or component) that is configured on other peers
+* Application versions that exist on some peers but not on others
+* Configuration fields that differ between peers (JSON and YAML configuration is compared field by field)
+
+The command exits with an error if any inconsistencies are found so that it may be used in automated checks. The
+criteria consists of:
+
+* MspID (mandatory)           - The MSP ID of the organization
+* AppName (optional)          - The application name
+* AppVersion (optional)       - The application version
+* ComponentName (optional)    - The component name
+* ComponentVersion (optional) - The component version
+
+Criteria may be specified as a JSON string (using the --criteria option) or it may be specified using the options:
+	--mspid, --appname, --appver, --componentname and --componentver
+`
+	examples = `
+- Audit the configuration of a particular application across all peers in Org1MSP:
+
+    $ ./fabric ledgerconfig audit --mspid Org1MSP --appname app1
+
+... results in the following output if the configuration has drifted:
+
+	Configuration drift detected:
+
+	- Field [app1config.key1] of app [app1] version [v1] differs between peers:
+	    peer0.org1.com: "value1 for org1-peer0-app1"
+	    peer1.org1.com: "value1 for org1-peer1-app1"
+
+- Audit all peer-specific configuration in Org1MSP:
+
+    $ ./fabric ledgerconfig audit --mspid Org1MSP
+`
+)
+
+const (
+	msgNoConfig = "No peer-specific configuration matches the given criteria"
+	msgNoDrift  = "No configuration drift detected"
+	msgDrift    = "Configuration drift detected:"
+)
+
+// New returns the ledgerconfig audit sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	return newCmd(settings, nil)
+}
+
+func newCmd(settings *environment.Settings, p basecmd.FactoryProvider) *cobra.Command {
+	c := &command{}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   desc,
+		Long:    longDesc,
+		Example: examples,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return c.Validate()
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+	c.CriteriaBaseCommand = common.NewCriteriaBaseCommand(settings, p, cmd)
+
+	return cmd
+}
+
+// command implements the audit command
+type command struct {
+	*common.CriteriaBaseCommand
+}
+
+func (c *command) run() error {
+	criteriaBytes, err := c.GetCriteriaBytes()
+	if err != nil {
+		return err
+	}
+
+	criteria := &common.Criteria{}
+	if err = json.Unmarshal(criteriaBytes, criteria); err != nil {
+		return errors.WithMessage(err, "invalid criteria")
+	}
+
+	kvs, err := c.GetKeyValues(criteriaBytes)
+	if err != nil {
+		return err
+	}
+
+	peers, err := c.getPeers(criteria)
+	if err != nil {
+		return err
+	}
+
+	if len(peers) == 0 {
+		return c.Fprintln(msgNoConfig)
+	}
+
+	findings := audit(peers, kvs)
+	if len(findings) == 0 {
+		return c.Fprintln(msgNoDrift)
+	}
+
+	var report strings.Builder
+	for _, f := range findings {
+		report.WriteString(fmt.Sprintf("\n%s", f))
+	}
+
+	if err = c.Fprintln(fmt.Sprintf("%s\n%s", msgDrift, report.String())); err != nil {
+		return err
+	}
+
+	return errors.Errorf("configuration drift detected: %d inconsistencies found", len(findings))
+}
+
+// getPeers returns the IDs of all of the peers in the MSP that have peer-specific configuration. All of the MSP's
+// configuration is queried (rather than only the configuration that matches the criteria) so that peers that are
+// missing an application entirely are also detected.
+func (c *command) getPeers(criteria *common.Criteria) ([]string, error) {
+	criteriaBytes, err := json.Marshal(&common.Criteria{MspID: criteria.MspID, PeerID: criteria.PeerID})
+	if err != nil {
+		return nil, err
+	}
+
+	kvs, err := c.GetKeyValues(criteriaBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return peerIDs(kvs), nil
+}
@@ -0,0 +1,221 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auditcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/hyperledger/fabric-cli/pkg/fabric"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+const (
+	msp   = "Org1MSP"
+	peer0 = "peer0.org1.com"
+	peer1 = "peer1.org1.com"
+	peer2 = "peer2.org1.com"
+)
+
+func TestNew(t *testing.T) {
+	require.NotNil(t, New(environment.NewDefaultSettings()))
+}
+
+func TestAuditCmd_InvalidOptions(t *testing.T) {
+	err := newMockCmd(t, &mocks.Writer{}, nil).Execute()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "--mspid must be specified")
+}
+
+func TestAuditCmd(t *testing.T) {
+	t.Run("No drift", func(t *testing.T) {
+		kvs := []*common.KeyValue{
+			newKV(peer0, "app1", "v1", "", common.YAMLFormat, "key1: value1\n"),
+			newKV(peer1, "app1", "v1", "", common.YAMLFormat, "key1: value1\n"),
+			newKV("", "app2", "v1", "", common.JSONFormat, `{"key1":"value1"}`),
+		}
+
+		w := &mocks.Writer{}
+		require.NoError(t, newMockCmd(t, w, newProvider(t, kvs), "--mspid", msp, "--appname", "app1").Execute())
+		require.Contains(t, w.Written(), msgNoDrift)
+	})
+
+	t.Run("Drift", func(t *testing.T) {
+		kvs := []*common.KeyValue{
+			newKV(peer0, "app1", "v1", "", common.YAMLFormat, "app1config:\n  key1: value1 for peer0\n  key2: value2\n"),
+			newKV(peer1, "app1", "v1", "", common.YAMLFormat, "app1config:\n  key1: value1 for peer1\n  key2: value2\n"),
+			newKV(peer1, "app1", "v2", "", common.YAMLFormat, "app1config:\n  key1: value1\n"),
+			newKV(peer2, "app2", "v1", "", common.OtherFormat, "some config"),
+		}
+
+		w := &mocks.Writer{}
+		err := newMockCmd(t, w, newProvider(t, kvs), "--mspid", msp, "--appname", "app1").Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "configuration drift detected: 3 inconsistencies found")
+
+		require.Contains(t, w.Written(), msgDrift)
+		require.Contains(t, w.Written(), "App [app1] is missing on peers [peer2.org1.com]")
+		require.Contains(t, w.Written(), "Version [v2] of app [app1] only exists on peers [peer1.org1.com]")
+		require.Contains(t, w.Written(), "Field [app1config.key1] of app [app1] version [v1] differs between peers:")
+		require.Contains(t, w.Written(), `peer0.org1.com: "value1 for peer0"`)
+		require.Contains(t, w.Written(), `peer1.org1.com: "value1 for peer1"`)
+		require.NotContains(t, w.Written(), "app1config.key2")
+	})
+
+	t.Run("No peer-specific config", func(t *testing.T) {
+		w := &mocks.Writer{}
+		require.NoError(t, newMockCmd(t, w, newProvider(t, nil), "--mspid", msp).Execute())
+		require.Contains(t, w.Written(), msgNoConfig)
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		errExpected := errors.New("injected query error")
+
+		factory := &mocks.Factory{}
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{}, errExpected)
+		factory.ChannelReturns(ch, nil)
+
+		p := func(config *environment.Config) (fabric.Factory, error) { return factory, nil }
+
+		require.EqualError(t, newMockCmd(t, &mocks.Writer{}, p, "--mspid", msp).Execute(), errExpected.Error())
+	})
+}
+
+func TestAudit(t *testing.T) {
+	peers := []string{peer0, peer1}
+
+	t.Run("Components", func(t *testing.T) {
+		kvs := []*common.KeyValue{
+			newKV(peer0, "app1", "v1", "", common.JSONFormat, `{}`),
+			newKV(peer1, "app1", "v1", "", common.JSONFormat, `{}`),
+			newKV(peer0, "app1", "v1", "comp1", common.JSONFormat, `{"list":[1,2],"nested":{"key":true}}`),
+			newKV(peer1, "app1", "v1", "comp1", common.JSONFormat, `{"list":[1,3],"nested":{"key":true},"extra":"x"}`),
+			newKV(peer0, "app1", "v1", "comp2", common.JSONFormat, `{}`),
+		}
+
+		findings := audit(peers, kvs)
+		require.Len(t, findings, 3)
+		require.Equal(t, "- Field [extra] of component [comp1] version [v1] of app [app1] version [v1] differs between peers:\n"+
+			"    peer0.org1.com: <missing>\n"+
+			`    peer1.org1.com: "x"`, findings[0].String())
+		require.Contains(t, findings[1].String(), "Field [list[1]] of component [comp1]")
+		require.Equal(t, "- The component [comp2] version [v1] of app [app1] version [v1] is missing on peers [peer1.org1.com]", findings[2].String())
+	})
+
+	t.Run("Large integers", func(t *testing.T) {
+		// The values differ only past 2^53 so they are equal when decoded as float64
+		kvs := []*common.KeyValue{
+			newKV(peer0, "app1", "v1", "", common.JSONFormat, `{"id":9007199254740993}`),
+			newKV(peer1, "app1", "v1", "", common.JSONFormat, `{"id":9007199254740992}`),
+		}
+
+		findings := audit(peers, kvs)
+		require.Len(t, findings, 1)
+		require.Contains(t, findings[0].String(), "peer0.org1.com: 9007199254740993")
+		require.Contains(t, findings[0].String(), "peer1.org1.com: 9007199254740992")
+	})
+
+	t.Run("Empty containers", func(t *testing.T) {
+		kvs := []*common.KeyValue{
+			newKV(peer0, "app1", "v1", "", common.JSONFormat, `{"key":"value","settings":{},"list":[]}`),
+			newKV(peer1, "app1", "v1", "", common.JSONFormat, `{"key":"value"}`),
+			newKV(peer0, "app2", "v1", "", common.YAMLFormat, "key: value\nsettings: {}\n"),
+			newKV(peer1, "app2", "v1", "", common.YAMLFormat, "key: value\n"),
+		}
+
+		findings := audit(peers, kvs)
+		require.Len(t, findings, 3)
+		require.Equal(t, "- Field [list] of app [app1] version [v1] differs between peers:\n"+
+			"    peer0.org1.com: []\n"+
+			"    peer1.org1.com: <missing>", findings[0].String())
+		require.Contains(t, findings[1].String(), "Field [settings] of app [app1] version [v1] differs between peers:")
+		require.Contains(t, findings[2].String(), "Field [settings] of app [app2] version [v1] differs between peers:")
+	})
+
+	t.Run("Other format", func(t *testing.T) {
+		kvs := []*common.KeyValue{
+			newKV(peer0, "app1", "v1", "", common.OtherFormat, "config1"),
+			newKV(peer1, "app1", "v1", "", common.OtherFormat, "config2"),
+		}
+
+		findings := audit(peers, kvs)
+		require.Len(t, findings, 1)
+		require.Contains(t, findings[0].String(), "Config of app [app1] version [v1] differs between peers:")
+	})
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		kvs := []*common.KeyValue{
+			newKV(peer0, "app1", "v1", "", common.JSONFormat, `{"key":`),
+			newKV(peer1, "app1", "v1", "", common.JSONFormat, `{"key":`),
+		}
+
+		require.Empty(t, audit(peers, kvs))
+	})
+}
+
+func newKV(peerID, appName, appVersion, componentName string, format common.Format, config string) *common.KeyValue {
+	key := &common.Key{MspID: msp, PeerID: peerID, AppName: appName, AppVersion: appVersion}
+	if componentName != "" {
+		key.ComponentName = componentName
+		key.ComponentVersion = "v1"
+	}
+
+	return &common.KeyValue{
+		Key:   key,
+		Value: &common.Value{TxID: "tx1", Format: format, Config: config},
+	}
+}
+
+// newProvider returns a factory provider whose channel returns the given key-values that match the app name in the query criteria
+func newProvider(t *testing.T, kvs []*common.KeyValue) basecmd.FactoryProvider {
+	factory := &mocks.Factory{}
+	ch := &mocks.Channel{}
+	ch.QueryStub = func(req channel.Request, _ ...channel.RequestOption) (channel.Response, error) {
+		criteria := &common.Criteria{}
+		require.NoError(t, json.Unmarshal(req.Args[0], criteria))
+
+		var matching []*common.KeyValue
+		for _, kv := range kvs {
+			if criteria.AppName == "" || criteria.AppName == kv.AppName {
+				matching = append(matching, kv)
+			}
+		}
+
+		payload, err := json.Marshal(matching)
+		require.NoError(t, err)
+
+		return channel.Response{Payload: payload}, nil
+	}
+	factory.ChannelReturns(ch, nil)
+
+	return func(config *environment.Config) (fabric.Factory, error) { return factory, nil }
+}
+
+func newMockCmd(t *testing.T, w io.Writer, p basecmd.FactoryProvider, args ...string) *cobra.Command {
+	settings := environment.NewDefaultSettings()
+	settings.Streams.Out = w
+
+	settings.Config.CurrentContext = "testctx"
+	settings.Config.Contexts[settings.Config.CurrentContext] = &environment.Context{}
+
+	c := newCmd(settings, p)
+	require.NotNil(t, c)
+
+	c.SetArgs(args)
+
+	return c
+}
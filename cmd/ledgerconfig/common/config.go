@@ -9,6 +9,15 @@ package common
 // Format specifies the format of the configuration
 type Format string
 
+const (
+	// JSONFormat indicates that the configuration is in JSON format
+	JSONFormat Format = "JSON"
+	// YAMLFormat indicates that the configuration is in YAML format
+	YAMLFormat Format = "YAML"
+	// OtherFormat indicates that the configuration is in some other format
+	OtherFormat Format = "Other"
+)
+
 // Config contains zero or more application configurations and zero or more peer-specific application configurations
 type Config struct {
 	// MspID is the ID of the MSP
@@ -137,3 +137,18 @@ func (c *CriteriaBaseCommand) GetConfig(criteria []byte) ([]byte, error) {
 
 	return resp.Payload, nil
 }
+
+// GetKeyValues returns the key-values that match the given criteria
+func (c *CriteriaBaseCommand) GetKeyValues(criteria []byte) ([]*KeyValue, error) {
+	config, err := c.GetConfig(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	var kvs []*KeyValue
+	if err := json.Unmarshal(config, &kvs); err != nil {
+		return nil, errors.WithMessage(err, "error unmarshalling configuration")
+	}
+
+	return kvs, nil
+}
@@ -131,6 +131,56 @@ func TestCriteriaBaseCommand_GetConfig(t *testing.T) {
 	})
 }
 
+func TestCriteriaBaseCommand_GetKeyValues(t *testing.T) {
+	factory := &mocks.Factory{}
+	p := func(config *environment.Config) (fabric.Factory, error) { return factory, nil }
+
+	t.Run("GetKeyValues => valid", func(t *testing.T) {
+		const payload = `[{"MspID":"msp1","AppName":"app1","AppVersion":"v1","Format":"JSON","Config":"{}"}]`
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: []byte(payload)}, nil)
+		factory.ChannelReturns(ch, nil)
+
+		mc := &testCmd{}
+		c := newMockCriteriaCmd(t, mc, p)
+		require.NotNil(t, c)
+
+		kvs, err := mc.GetKeyValues([]byte(`{"MspID":"msp1"}`))
+		require.NoError(t, err)
+		require.Len(t, kvs, 1)
+		require.Equal(t, "app1", kvs[0].AppName)
+		require.Equal(t, "{}", kvs[0].Config)
+	})
+
+	t.Run("GetKeyValues => no config", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: []byte("null")}, nil)
+		factory.ChannelReturns(ch, nil)
+
+		mc := &testCmd{}
+		c := newMockCriteriaCmd(t, mc, p)
+		require.NotNil(t, c)
+
+		kvs, err := mc.GetKeyValues([]byte(`{"MspID":"msp1"}`))
+		require.NoError(t, err)
+		require.Empty(t, kvs)
+	})
+
+	t.Run("GetKeyValues => invalid payload", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: []byte("{")}, nil)
+		factory.ChannelReturns(ch, nil)
+
+		mc := &testCmd{}
+		c := newMockCriteriaCmd(t, mc, p)
+		require.NotNil(t, c)
+
+		_, err := mc.GetKeyValues([]byte(`{"MspID":"msp1"}`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error unmarshalling configuration")
+	})
+}
+
 type testCmd struct {
 	*CriteriaBaseCommand
 }
@@ -0,0 +1,174 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-cli/pkg/fabric"
+	"github.com/pkg/errors"
+)
+
+const (
+	// FileHandlerAppName is the name of the file handler application in ledger config
+	FileHandlerAppName = "file-handler"
+	// FileHandlerAppVersion is the version of the file handler application config
+	FileHandlerAppVersion = "1"
+	// FileHandlerComponentVersion is the version of the file handler component config
+	FileHandlerComponentVersion = "1"
+)
+
+var (
+	namespaceRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+(:[a-zA-Z0-9_-]+)*$`)
+	tokenNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+)
+
+// FileHandlerAuthConfig contains the authorization configuration for a file handler
+type FileHandlerAuthConfig struct {
+	// ReadTokens contains a set of names of tokens for authorizing read requests
+	ReadTokens []string
+	// WriteTokens contains a set of names of tokens for authorizing write requests
+	WriteTokens []string
+}
+
+// FileHandlerConfig contains the configuration of a file handler for a given base path. The configuration
+// is stored as a component of the file-handler application where the component name is the base path.
+type FileHandlerConfig struct {
+	Authorization FileHandlerAuthConfig
+
+	BasePath       string
+	ChaincodeName  string
+	Collection     string
+	IndexNamespace string
+	IndexDocID     string
+}
+
+// Validate validates the file handler configuration
+func (cfg *FileHandlerConfig) Validate() error {
+	if cfg.BasePath == "" || cfg.BasePath[0:1] != "/" {
+		return errors.Errorf("base path [%s] must begin with '/'", cfg.BasePath)
+	}
+
+	if cfg.ChaincodeName == "" {
+		return errors.New("chaincode name is required")
+	}
+
+	if cfg.Collection == "" {
+		return errors.New("collection is required")
+	}
+
+	if err := ValidateIndexNamespace(cfg.IndexNamespace); err != nil {
+		return err
+	}
+
+	if cfg.IndexDocID != "" && !strings.HasPrefix(cfg.IndexDocID, cfg.IndexNamespace+":") {
+		return errors.Errorf("file index ID must begin with [%s:]", cfg.IndexNamespace)
+	}
+
+	if err := ValidateTokenNames(cfg.Authorization.ReadTokens); err != nil {
+		return errors.WithMessage(err, "invalid read token")
+	}
+
+	if err := ValidateTokenNames(cfg.Authorization.WriteTokens); err != nil {
+		return errors.WithMessage(err, "invalid write token")
+	}
+
+	return nil
+}
+
+// ValidateIndexNamespace ensures that the given index namespace consists of one or more colon-separated
+// segments, e.g. file:idx
+func ValidateIndexNamespace(ns string) error {
+	if !namespaceRegex.MatchString(ns) {
+		return errors.Errorf("invalid index namespace [%s] - expecting colon-separated segments, for example file:idx", ns)
+	}
+
+	return nil
+}
+
+// ValidateTokenNames ensures that the given token names are valid
+func ValidateTokenNames(names []string) error {
+	for _, name := range names {
+		if !tokenNameRegex.MatchString(name) {
+			return errors.Errorf("invalid token name [%s]", name)
+		}
+	}
+
+	return nil
+}
+
+// NewFileHandlerCriteria returns the search criteria for the file handler of the given base path. If peerID
+// and/or basePath are empty then the criteria matches all peers and/or base paths.
+func NewFileHandlerCriteria(mspID, peerID, basePath string) *Criteria {
+	criteria := &Criteria{
+		MspID:   mspID,
+		PeerID:  peerID,
+		AppName: FileHandlerAppName,
+	}
+
+	if basePath != "" {
+		criteria.AppVersion = FileHandlerAppVersion
+		criteria.ComponentName = basePath
+		criteria.ComponentVersion = FileHandlerComponentVersion
+	}
+
+	return criteria
+}
+
+// NewFileHandlerPeerConfig returns the peer config for the file handler configuration of the given base path
+func NewFileHandlerPeerConfig(peerID, basePath string, handlerCfg *FileHandlerConfig) (*Peer, error) {
+	cfgBytes, err := json.Marshal(handlerCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Peer{
+		PeerID: peerID,
+		Apps: []*App{
+			{
+				AppName: FileHandlerAppName,
+				Version: FileHandlerAppVersion,
+				Components: []*Component{
+					{
+						Name:    basePath,
+						Version: FileHandlerComponentVersion,
+						Format:  JSONFormat,
+						Config:  string(cfgBytes),
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// UnmarshalFileHandlerConfig unmarshals the file handler configuration from the given key-value
+func UnmarshalFileHandlerConfig(kv *KeyValue) (*FileHandlerConfig, error) {
+	cfg := &FileHandlerConfig{}
+
+	if err := json.Unmarshal([]byte(kv.Config), cfg); err != nil {
+		return nil, errors.WithMessagef(err, "invalid file handler config for %s", kv.Key)
+	}
+
+	return cfg, nil
+}
+
+// QueryFileHandlerConfig returns the file handler configuration of the given peer and base path. If
+// the configuration is not found then nil is returned.
+func QueryFileHandlerConfig(ch fabric.Channel, mspID, peerID, basePath string) (*FileHandlerConfig, error) {
+	kvs, err := QueryKeyValues(ch, NewFileHandlerCriteria(mspID, peerID, basePath))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(kvs) == 0 {
+		return nil, nil
+	}
+
+	return UnmarshalFileHandlerConfig(kvs[0])
+}
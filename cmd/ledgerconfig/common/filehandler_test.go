@@ -0,0 +1,124 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+func TestFileHandlerConfig_Validate(t *testing.T) {
+	newCfg := func() *FileHandlerConfig {
+		return &FileHandlerConfig{
+			Authorization: FileHandlerAuthConfig{
+				ReadTokens:  []string{"content_r"},
+				WriteTokens: []string{"content_w"},
+			},
+			BasePath:       "/content",
+			ChaincodeName:  "files",
+			Collection:     "consortium",
+			IndexNamespace: "file:idx",
+			IndexDocID:     "file:idx:1234",
+		}
+	}
+
+	require.NoError(t, newCfg().Validate())
+
+	cfg := newCfg()
+	cfg.BasePath = "content"
+	require.EqualError(t, cfg.Validate(), "base path [content] must begin with '/'")
+
+	cfg = newCfg()
+	cfg.ChaincodeName = ""
+	require.EqualError(t, cfg.Validate(), "chaincode name is required")
+
+	cfg = newCfg()
+	cfg.Collection = ""
+	require.EqualError(t, cfg.Validate(), "collection is required")
+
+	cfg = newCfg()
+	cfg.IndexNamespace = "file idx"
+	require.Error(t, cfg.Validate())
+
+	cfg = newCfg()
+	cfg.IndexDocID = "file:idx1234"
+	require.EqualError(t, cfg.Validate(), "file index ID must begin with [file:idx:]")
+
+	cfg = newCfg()
+	cfg.Authorization.ReadTokens = []string{"content r"}
+	require.EqualError(t, cfg.Validate(), "invalid read token: invalid token name [content r]")
+
+	cfg = newCfg()
+	cfg.Authorization.WriteTokens = []string{""}
+	require.EqualError(t, cfg.Validate(), "invalid write token: invalid token name []")
+}
+
+func TestValidateIndexNamespace(t *testing.T) {
+	require.NoError(t, ValidateIndexNamespace("file:idx"))
+	require.NoError(t, ValidateIndexNamespace("did_file"))
+	require.Error(t, ValidateIndexNamespace(""))
+	require.Error(t, ValidateIndexNamespace("file:"))
+	require.Error(t, ValidateIndexNamespace(":idx"))
+	require.Error(t, ValidateIndexNamespace("file::idx"))
+}
+
+func TestNewFileHandlerCriteria(t *testing.T) {
+	criteria := NewFileHandlerCriteria("Org1MSP", "peer0", "/content")
+	require.Equal(t, FileHandlerAppName, criteria.AppName)
+	require.Equal(t, FileHandlerAppVersion, criteria.AppVersion)
+	require.Equal(t, "/content", criteria.ComponentName)
+	require.Equal(t, FileHandlerComponentVersion, criteria.ComponentVersion)
+
+	criteria = NewFileHandlerCriteria("Org1MSP", "", "")
+	require.Equal(t, FileHandlerAppName, criteria.AppName)
+	require.Empty(t, criteria.AppVersion)
+	require.Empty(t, criteria.ComponentName)
+}
+
+func TestQueryFileHandlerConfig(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: []byte(`[{"MspID":"Org1MSP","Config":"{\"BasePath\":\"/content\"}"}]`)}, nil)
+
+		cfg, err := QueryFileHandlerConfig(ch, "Org1MSP", "peer0", "/content")
+		require.NoError(t, err)
+		require.NotNil(t, cfg)
+		require.Equal(t, "/content", cfg.BasePath)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: []byte("null")}, nil)
+
+		cfg, err := QueryFileHandlerConfig(ch, "Org1MSP", "peer0", "/content")
+		require.NoError(t, err)
+		require.Nil(t, cfg)
+	})
+
+	t.Run("Invalid config", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: []byte(`[{"MspID":"Org1MSP","Config":"{"}]`)}, nil)
+
+		_, err := QueryFileHandlerConfig(ch, "Org1MSP", "peer0", "/content")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid file handler config")
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		errExpected := errors.New("injected query error")
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{}, errExpected)
+
+		_, err := QueryFileHandlerConfig(ch, "Org1MSP", "peer0", "/content")
+		require.EqualError(t, err, errExpected.Error())
+	})
+}
@@ -0,0 +1,236 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric-cli/pkg/fabric"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/pkg/errors"
+)
+
+// PeerFileHandlerConfig contains the result of loading the file handler configuration of a peer
+type PeerFileHandlerConfig struct {
+	PeerID string
+	Config *FileHandlerConfig
+	Err    error
+}
+
+// FileIndexUpdateResults contains the peers whose file handler configuration is to be updated with the
+// ID of a file index document, skipped (since the file index ID is already set) or failed
+type FileIndexUpdateResults struct {
+	Peers   []string
+	Updated map[string]*FileHandlerConfig
+	Skipped []string
+	Failed  []*PeerFileHandlerConfig
+}
+
+// NewFileIndexUpdateResults determines which of the given peer configurations are to be updated with the
+// given file index ID
+func NewFileIndexUpdateResults(peers []string, configs []*PeerFileHandlerConfig, fileIndexID string) *FileIndexUpdateResults {
+	results := &FileIndexUpdateResults{
+		Peers:   peers,
+		Updated: make(map[string]*FileHandlerConfig),
+	}
+
+	for _, r := range configs {
+		switch {
+		case r.Err != nil:
+			results.Failed = append(results.Failed, r)
+		case r.Config.IndexDocID == fileIndexID:
+			// Index already set for peerID. Skip this peerID
+			results.Skipped = append(results.Skipped, r.PeerID)
+		default:
+			// Validate the updated config in the same way as 'filehandler set'
+			updated := *r.Config
+			updated.IndexDocID = fileIndexID
+
+			if err := updated.Validate(); err != nil {
+				r.Err = err
+				results.Failed = append(results.Failed, r)
+
+				continue
+			}
+
+			results.Updated[r.PeerID] = &updated
+		}
+	}
+
+	return results
+}
+
+// UpdatedPeers returns the peers that are to be updated in the order in which the peers were specified
+func (r *FileIndexUpdateResults) UpdatedPeers() []string {
+	var updated []string
+
+	for _, peerID := range r.Peers {
+		if _, ok := r.Updated[peerID]; ok {
+			updated = append(updated, peerID)
+		}
+	}
+
+	return updated
+}
+
+// String returns a report of the updated, skipped and failed peers
+func (r *FileIndexUpdateResults) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Updated peers: %s\n", r.UpdatedPeers()))
+	sb.WriteString(fmt.Sprintf("Skipped peers (file index ID already set): %s\n", r.Skipped))
+	sb.WriteString("Failed peers:")
+
+	if len(r.Failed) == 0 {
+		sb.WriteString(" []\n")
+	} else {
+		sb.WriteString("\n")
+
+		for _, f := range r.Failed {
+			sb.WriteString(fmt.Sprintf("  %s: %s\n", f.PeerID, f.Err))
+		}
+	}
+
+	return sb.String()
+}
+
+// FailedString returns the errors of the failed peers on a single line
+func (r *FileIndexUpdateResults) FailedString() string {
+	errs := make([]string, len(r.Failed))
+	for i, f := range r.Failed {
+		errs[i] = fmt.Sprintf("[%s: %s]", f.PeerID, f.Err)
+	}
+
+	return strings.Join(errs, ", ")
+}
+
+// FileIndexUpdater updates the ID of the file index document in the file handler configuration of the
+// peers of an MSP for a given base path
+type FileIndexUpdater struct {
+	ch       fabric.Channel
+	mspID    string
+	basePath string
+}
+
+// NewFileIndexUpdater returns a FileIndexUpdater
+func NewFileIndexUpdater(ch fabric.Channel, mspID, basePath string) *FileIndexUpdater {
+	return &FileIndexUpdater{
+		ch:       ch,
+		mspID:    mspID,
+		basePath: basePath,
+	}
+}
+
+// GetPeers returns the given semi-colon-separated peers or, if allPeers is true, the peers in
+// the MSP that have a file handler for the base path
+func (u *FileIndexUpdater) GetPeers(peers string, allPeers bool) ([]string, error) {
+	if !allPeers {
+		return strings.Split(peers, ";"), nil
+	}
+
+	kvs, err := QueryKeyValues(u.ch, NewFileHandlerCriteria(u.mspID, "", u.basePath))
+	if err != nil {
+		return nil, errors.WithMessage(err, "error discovering peers")
+	}
+
+	peerMap := make(map[string]struct{})
+	for _, kv := range kvs {
+		if kv.PeerID != "" {
+			peerMap[kv.PeerID] = struct{}{}
+		}
+	}
+
+	if len(peerMap) == 0 {
+		return nil, errors.Errorf("no peers in [%s] have a file handler for [%s]", u.mspID, u.basePath)
+	}
+
+	var peerIDs []string
+	for peerID := range peerMap {
+		peerIDs = append(peerIDs, peerID)
+	}
+
+	sort.Strings(peerIDs)
+
+	return peerIDs, nil
+}
+
+// LoadPeerConfigs concurrently loads the file handler config of each of the given peers
+func (u *FileIndexUpdater) LoadPeerConfigs(peers []string) []*PeerFileHandlerConfig {
+	results := make([]*PeerFileHandlerConfig, len(peers))
+
+	var wg sync.WaitGroup
+
+	for i, peerID := range peers {
+		wg.Add(1)
+
+		go func(i int, peerID string) {
+			defer wg.Done()
+
+			cfg, err := u.loadPeerConfig(peerID)
+
+			results[i] = &PeerFileHandlerConfig{PeerID: peerID, Config: cfg, Err: err}
+		}(i, peerID)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// GetConfigBytes returns the ledger config that sets the given file index ID in the file handler
+// configuration of the peers that are to be updated
+func (u *FileIndexUpdater) GetConfigBytes(results *FileIndexUpdateResults, fileIndexID string) ([]byte, error) {
+	cfg := &Config{
+		MspID: u.mspID,
+	}
+
+	for peerID, handlerCfg := range results.Updated {
+		handlerCfg.IndexDocID = fileIndexID
+
+		peerCfg, err := NewFileHandlerPeerConfig(peerID, u.basePath, handlerCfg)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.Peers = append(cfg.Peers, peerCfg)
+	}
+
+	sort.Slice(cfg.Peers, func(i, j int) bool { return cfg.Peers[i].PeerID < cfg.Peers[j].PeerID })
+
+	return json.Marshal(cfg)
+}
+
+// Save saves the given ledger config
+func (u *FileIndexUpdater) Save(configBytes []byte) error {
+	req := channel.Request{
+		ChaincodeID: ConfigSCC,
+		Fcn:         "save",
+		Args:        [][]byte{configBytes},
+	}
+
+	_, err := u.ch.Execute(req, channel.WithRetry(retry.DefaultChannelOpts))
+
+	return err
+}
+
+func (u *FileIndexUpdater) loadPeerConfig(peerID string) (*FileHandlerConfig, error) {
+	cfg, err := QueryFileHandlerConfig(u.ch, u.mspID, peerID, u.basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg == nil {
+		return nil, errors.Errorf("config not found for file handler [%s]", u.basePath)
+	}
+
+	return cfg, nil
+}
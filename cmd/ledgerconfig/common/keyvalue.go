@@ -7,7 +7,12 @@ SPDX-License-Identifier: Apache-2.0
 package common
 
 import (
+	"encoding/json"
 	"fmt"
+
+	"github.com/hyperledger/fabric-cli/pkg/fabric"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/pkg/errors"
 )
 
 // Key is used to uniquely identify a specific application configuration and is used as the
@@ -59,3 +64,99 @@ type KeyValue struct {
 func (kv *KeyValue) String() string {
 	return fmt.Sprintf("[%s]=[%s]", kv.Key, kv.Value)
 }
+
+// NewConfig returns a Config which contains the given key-values. All of the key-values must belong to the same MSP.
+func NewConfig(kvs []*KeyValue) (*Config, error) {
+	if len(kvs) == 0 {
+		return nil, errors.New("no configuration provided")
+	}
+
+	cfg := &Config{MspID: kvs[0].MspID}
+
+	for _, kv := range kvs {
+		if kv.MspID != cfg.MspID {
+			return nil, errors.Errorf("all configuration must belong to the same MSP: [%s] != [%s]", kv.MspID, cfg.MspID)
+		}
+
+		if kv.PeerID == "" {
+			cfg.Apps = addKeyValue(cfg.Apps, kv)
+
+			continue
+		}
+
+		peer := getPeer(cfg, kv.PeerID)
+		peer.Apps = addKeyValue(peer.Apps, kv)
+	}
+
+	return cfg, nil
+}
+
+func getPeer(cfg *Config, peerID string) *Peer {
+	for _, p := range cfg.Peers {
+		if p.PeerID == peerID {
+			return p
+		}
+	}
+
+	p := &Peer{PeerID: peerID}
+	cfg.Peers = append(cfg.Peers, p)
+
+	return p
+}
+
+func addKeyValue(apps []*App, kv *KeyValue) []*App {
+	var app *App
+	for _, a := range apps {
+		if a.AppName == kv.AppName && a.Version == kv.AppVersion {
+			app = a
+			break
+		}
+	}
+
+	if app == nil {
+		app = &App{AppName: kv.AppName, Version: kv.AppVersion}
+		apps = append(apps, app)
+	}
+
+	if kv.ComponentName == "" {
+		app.Format = kv.Format
+		app.Config = kv.Config
+		app.Tags = kv.Tags
+
+		return apps
+	}
+
+	app.Components = append(app.Components, &Component{
+		Name:    kv.ComponentName,
+		Version: kv.ComponentVersion,
+		Format:  kv.Format,
+		Config:  kv.Config,
+		Tags:    kv.Tags,
+	})
+
+	return apps
+}
+
+// QueryKeyValues returns the key-values that match the given criteria
+func QueryKeyValues(ch fabric.Channel, criteria *Criteria) ([]*KeyValue, error) {
+	criteriaBytes, err := json.Marshal(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ch.Query(channel.Request{
+		ChaincodeID: ConfigSCC,
+		Fcn:         "get",
+		Args:        [][]byte{criteriaBytes},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var kvs []*KeyValue
+	if err := json.Unmarshal(resp.Payload, &kvs); err != nil {
+		return nil, err
+	}
+
+	return kvs, nil
+}
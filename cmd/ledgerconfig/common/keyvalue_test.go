@@ -0,0 +1,74 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfig(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		kvs := []*KeyValue{
+			{
+				Key:   &Key{MspID: "Org1MSP", PeerID: "peer0", AppName: "app1", AppVersion: "v1"},
+				Value: &Value{Format: JSONFormat, Config: "{}"},
+			},
+			{
+				Key:   &Key{MspID: "Org1MSP", PeerID: "peer0", AppName: "app1", AppVersion: "v1", ComponentName: "comp1", ComponentVersion: "v1"},
+				Value: &Value{Format: YAMLFormat, Config: "comp1"},
+			},
+			{
+				Key:   &Key{MspID: "Org1MSP", AppName: "app2", AppVersion: "v1", ComponentName: "comp1", ComponentVersion: "v1"},
+				Value: &Value{Format: OtherFormat, Config: "comp1", Tags: []string{"tag1"}},
+			},
+			{
+				Key:   &Key{MspID: "Org1MSP", AppName: "app2", AppVersion: "v1", ComponentName: "comp2", ComponentVersion: "v1"},
+				Value: &Value{Format: OtherFormat, Config: "comp2"},
+			},
+		}
+
+		cfg, err := NewConfig(kvs)
+		require.NoError(t, err)
+		require.Equal(t, "Org1MSP", cfg.MspID)
+
+		require.Len(t, cfg.Peers, 1)
+		require.Equal(t, "peer0", cfg.Peers[0].PeerID)
+		require.Len(t, cfg.Peers[0].Apps, 1)
+
+		app := cfg.Peers[0].Apps[0]
+		require.Equal(t, "app1", app.AppName)
+		require.Equal(t, "{}", app.Config)
+		require.Len(t, app.Components, 1)
+
+		require.Len(t, cfg.Apps, 1)
+		app = cfg.Apps[0]
+		require.Equal(t, "app2", app.AppName)
+		require.Empty(t, app.Config)
+		require.Len(t, app.Components, 2)
+		require.Equal(t, []string{"tag1"}, app.Components[0].Tags)
+	})
+
+	t.Run("No key-values", func(t *testing.T) {
+		cfg, err := NewConfig(nil)
+		require.Error(t, err)
+		require.Nil(t, cfg)
+	})
+
+	t.Run("Mixed MSPs", func(t *testing.T) {
+		kvs := []*KeyValue{
+			{Key: &Key{MspID: "Org1MSP", AppName: "app1", AppVersion: "v1"}, Value: &Value{}},
+			{Key: &Key{MspID: "Org2MSP", AppName: "app1", AppVersion: "v1"}, Value: &Value{}},
+		}
+
+		cfg, err := NewConfig(kvs)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "same MSP")
+		require.Nil(t, cfg)
+	})
+}
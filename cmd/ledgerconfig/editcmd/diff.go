@@ -0,0 +1,72 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package editcmd
+
+import (
+	"strings"
+)
+
+// diff returns a line-by-line diff of the original and updated strings. Removed lines are
+// prefixed with '-', added lines with '+' and unchanged lines with a space.
+func diff(original, updated string) string {
+	a := strings.Split(original, "\n")
+	b := strings.Split(updated, "\n")
+
+	// lcs[i][j] contains the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			writeLine(&sb, " ", a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			writeLine(&sb, "-", a[i])
+			i++
+		default:
+			writeLine(&sb, "+", b[j])
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		writeLine(&sb, "-", a[i])
+	}
+
+	for ; j < len(b); j++ {
+		writeLine(&sb, "+", b[j])
+	}
+
+	return sb.String()
+}
+
+func writeLine(sb *strings.Builder, prefix, line string) {
+	sb.WriteString(prefix)
+	sb.WriteString(" ")
+	sb.WriteString(line)
+	sb.WriteString("\n")
+}
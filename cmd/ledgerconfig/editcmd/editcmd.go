@@ -0,0 +1,363 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package editcmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+)
+
+const (
+	use      = "edit"
+	desc     = "Edit ledger configuration"
+	longDesc = `
+The edit command retrieves the configuration that matches the given search criteria and opens each configuration
+in an editor (specified by the EDITOR environment variable or the --editor option) in its native format. Once the
+editor is closed, the edited configuration is validated and the differences are displayed. If the configuration is
+invalid then the error is displayed and the editor is re-opened; exiting the editor without making further changes
+discards the changes to that configuration. Only the configuration that was changed is submitted. The criteria consists of:
+
+* MspID (mandatory)           - The MSP ID of the organization
+* PeerID (optional)           - The ID of the peer
+* AppName (optional)          - The application name
+* AppVersion (optional)       - The application version
+* ComponentName (optional)    - The component name
+* ComponentVersion (optional) - The component version
+
+Criteria may be specified as a JSON string (using the --criteria option) or it may be specified using the options:
+	--mspid, --peerid, --appname, --appver, --componentname and --componentver
+`
+	examples = `
+- Edit the configuration of a particular application on a specified peer:
+
+    $ ./fabric ledgerconfig edit --mspid Org1MSP --peerid peer0.org1.com --appname app1 --appver v1
+
+- Edit the configuration of a file handler using a specific editor:
+
+    $ ./fabric ledgerconfig edit --mspid Org1MSP --appname file-handler --componentname /content --editor nano
+`
+)
+
+const (
+	editorFlag  = "editor"
+	editorUsage = "The editor used to edit the configuration. If not specified then the EDITOR environment variable is used, otherwise vi. Example: --editor nano"
+
+	noPromptFlag  = "noprompt"
+	noPromptUsage = "If specified then the update operation will not prompt for confirmation. Example: --noprompt"
+
+	editorEnvVar  = "EDITOR"
+	defaultEditor = "vi"
+
+	msgConfigUpdated   = "Configuration successfully updated!"
+	msgAborted         = "Operation aborted"
+	msgContinueOrAbort = "Enter Y to continue or N to abort "
+	msgNoConfig        = "No configuration matches the given criteria"
+	msgNoChanges       = "No changes were made to the configuration"
+	msgInvalidConfig   = "Invalid configuration for %s: %s\nThe editor will be re-opened. Exit the editor without making changes to discard the changes to this configuration."
+	msgDiscarded       = "The changes to %s were discarded"
+)
+
+var errEditorRequired = errors.New("an editor is required (--editor or the EDITOR environment variable)")
+
+// editorLauncher opens the given file in the given editor and returns after the editor exits
+type editorLauncher func(editor, file string, streams environment.Streams) error
+
+// New returns the ledgerconfig edit sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	return newCmd(settings, nil, launchEditor)
+}
+
+func newCmd(settings *environment.Settings, p basecmd.FactoryProvider, launcher editorLauncher) *cobra.Command {
+	c := &command{
+		launchEditor: launcher,
+	}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   desc,
+		Long:    longDesc,
+		Example: examples,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return c.Validate()
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+	c.CriteriaBaseCommand = common.NewCriteriaBaseCommand(settings, p, cmd)
+
+	cmd.Flags().StringVar(&c.editor, editorFlag, "", editorUsage)
+	cmd.Flags().BoolVar(&c.noPrompt, noPromptFlag, false, noPromptUsage)
+
+	return cmd
+}
+
+// command implements the edit command
+type command struct {
+	*common.CriteriaBaseCommand
+	launchEditor editorLauncher
+
+	// Flags
+	editor   string
+	noPrompt bool
+}
+
+// editedValue holds the original and edited configuration of a single key-value
+type editedValue struct {
+	kv      *common.KeyValue
+	updated string
+}
+
+func (c *command) run() error {
+	criteriaBytes, err := c.GetCriteriaBytes()
+	if err != nil {
+		return err
+	}
+
+	kvs, err := c.GetKeyValues(criteriaBytes)
+	if err != nil {
+		return err
+	}
+
+	if len(kvs) == 0 {
+		return c.Fprintln(msgNoConfig)
+	}
+
+	dir, err := ioutil.TempDir("", "ledgerconfig")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if e := os.RemoveAll(dir); e != nil {
+			c.FprintlnOrPanic(fmt.Sprintf("Error removing temporary directory [%s]: %s", dir, e))
+		}
+	}()
+
+	changed, err := c.edit(dir, kvs)
+	if err != nil {
+		return err
+	}
+
+	if len(changed) == 0 {
+		return c.Fprintln(msgNoChanges)
+	}
+
+	if !c.noPrompt {
+		confirmed, e := c.confirmUpdate(changed)
+		if e != nil {
+			return e
+		}
+
+		if !confirmed {
+			return c.Fprintln(msgAborted)
+		}
+	}
+
+	return c.save(changed)
+}
+
+// edit writes each of the given key-values to a file, opens the file in the editor and
+// returns the key-values that were changed
+func (c *command) edit(dir string, kvs []*common.KeyValue) ([]*editedValue, error) {
+	editor := c.getEditor()
+
+	var changed []*editedValue
+
+	for i, kv := range kvs {
+		file := filepath.Join(dir, fileNameForKey(i, kv))
+
+		if err := ioutil.WriteFile(file, []byte(kv.Config), 0600); err != nil {
+			return nil, err
+		}
+
+		updated, err := c.editFile(editor, file, kv)
+		if err != nil {
+			return nil, err
+		}
+
+		if updated == kv.Config {
+			continue
+		}
+
+		changed = append(changed, &editedValue{kv: kv, updated: updated})
+	}
+
+	return changed, nil
+}
+
+// editFile opens the given file in the editor and returns the edited configuration. If the edited configuration
+// is invalid then the error is displayed and the editor is re-opened. If the editor exits without any further
+// changes to the invalid configuration then the changes are discarded and the original configuration is returned.
+func (c *command) editFile(editor, file string, kv *common.KeyValue) (string, error) {
+	previous := kv.Config
+
+	for {
+		if err := c.launchEditor(editor, file, c.Settings.Streams); err != nil {
+			return "", errors.WithMessagef(err, "error launching editor [%s]", editor)
+		}
+
+		updatedBytes, err := ioutil.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return "", err
+		}
+
+		updated := string(updatedBytes)
+		if updated == kv.Config {
+			return updated, nil
+		}
+
+		err = validate(kv.Format, updatedBytes)
+		if err == nil {
+			return updated, nil
+		}
+
+		if updated == previous {
+			return kv.Config, c.Fprintln(fmt.Sprintf(msgDiscarded, kv.Key))
+		}
+
+		if e := c.Fprintln(fmt.Sprintf(msgInvalidConfig, kv.Key, err)); e != nil {
+			return "", e
+		}
+
+		previous = updated
+	}
+}
+
+func (c *command) save(changed []*editedValue) error {
+	kvs := make([]*common.KeyValue, len(changed))
+	for i, v := range changed {
+		value := *v.kv.Value
+		value.Config = v.updated
+
+		kvs[i] = &common.KeyValue{Key: v.kv.Key, Value: &value}
+	}
+
+	cfg, err := common.NewConfig(kvs)
+	if err != nil {
+		return err
+	}
+
+	configBytes, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	req := channel.Request{
+		ChaincodeID: common.ConfigSCC,
+		Fcn:         "save",
+		Args:        [][]byte{configBytes},
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		return err
+	}
+
+	_, err = ch.Execute(req, channel.WithRetry(retry.DefaultChannelOpts))
+	if err != nil {
+		return err
+	}
+
+	return c.Fprintln(msgConfigUpdated)
+}
+
+func (c *command) getEditor() string {
+	if c.editor != "" {
+		return c.editor
+	}
+
+	if editor := os.Getenv(editorEnvVar); editor != "" {
+		return editor
+	}
+
+	return defaultEditor
+}
+
+// confirmUpdate displays the differences and prompts the user for confirmation of the update
+func (c *command) confirmUpdate(changed []*editedValue) (bool, error) {
+	var diffs strings.Builder
+	for _, v := range changed {
+		diffs.WriteString(fmt.Sprintf("%s\n%s\n", v.kv.Key, diff(v.kv.Config, v.updated)))
+	}
+
+	prompt := fmt.Sprintf("The following configuration will be updated:\n\n%s\n%s", diffs.String(), msgContinueOrAbort)
+
+	err := c.Fprintln(prompt)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.ToLower(c.Prompt()) == "y", nil
+}
+
+func validate(format common.Format, cfg []byte) error {
+	switch common.Format(strings.ToUpper(string(format))) {
+	case common.JSONFormat:
+		var v interface{}
+		return json.Unmarshal(cfg, &v)
+	case common.YAMLFormat:
+		var v interface{}
+		return yaml.Unmarshal(cfg, &v)
+	default:
+		return nil
+	}
+}
+
+var invalidFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// fileNameForKey returns a file name for the given key with an extension that matches the format
+func fileNameForKey(i int, kv *common.KeyValue) string {
+	parts := []string{strconv.Itoa(i)}
+	for _, p := range []string{kv.PeerID, kv.AppName, kv.ComponentName} {
+		if p != "" {
+			parts = append(parts, invalidFileChars.ReplaceAllString(p, "_"))
+		}
+	}
+
+	name := strings.Join(parts, "-")
+
+	switch common.Format(strings.ToUpper(string(kv.Format))) {
+	case common.JSONFormat:
+		return name + ".json"
+	case common.YAMLFormat:
+		return name + ".yaml"
+	default:
+		return name + ".txt"
+	}
+}
+
+func launchEditor(editor, file string, streams environment.Streams) error {
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		return errEditorRequired
+	}
+
+	cmd := exec.Command(args[0], append(args[1:], file)...) //nolint: gosec
+	cmd.Stdin = streams.In
+	cmd.Stdout = streams.Out
+	cmd.Stderr = streams.Err
+
+	return cmd.Run()
+}
@@ -0,0 +1,273 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package editcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/hyperledger/fabric-cli/pkg/fabric"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+const (
+	jsonCfg = `{"key1":"value1"}`
+	yamlCfg = "key1: value1\n"
+)
+
+func TestNew(t *testing.T) {
+	require.NotNil(t, New(environment.NewDefaultSettings()))
+}
+
+func TestEditCmd_InvalidOptions(t *testing.T) {
+	t.Run("No options", func(t *testing.T) {
+		err := newMockCmd(t, nil, nil).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "--mspid must be specified")
+	})
+}
+
+func TestEditCmd(t *testing.T) {
+	kvs := []*common.KeyValue{
+		{
+			Key:   &common.Key{MspID: "Org1MSP", PeerID: "peer0.org1.com", AppName: "app1", AppVersion: "v1"},
+			Value: &common.Value{TxID: "tx1", Format: common.JSONFormat, Config: jsonCfg},
+		},
+		{
+			Key:   &common.Key{MspID: "Org1MSP", AppName: "app2", AppVersion: "v1", ComponentName: "comp1", ComponentVersion: "v1"},
+			Value: &common.Value{TxID: "tx1", Format: common.YAMLFormat, Config: yamlCfg},
+		},
+	}
+
+	kvsBytes, err := json.Marshal(kvs)
+	require.NoError(t, err)
+
+	factory := &mocks.Factory{}
+	ch := &mocks.Channel{}
+	ch.QueryReturns(channel.Response{Payload: kvsBytes}, nil)
+	factory.ChannelReturns(ch, nil)
+
+	p := func(config *environment.Config) (fabric.Factory, error) { return factory, nil }
+
+	args := []string{"--mspid", "Org1MSP"}
+
+	t.Run("No changes", func(t *testing.T) {
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, p, newMockEditor(nil), args...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgNoChanges)
+	})
+
+	t.Run("With prompt - Y", func(t *testing.T) {
+		executeCount := ch.ExecuteCallCount()
+
+		w := &mocks.Writer{}
+		editor := newMockEditor(map[string]string{".json": `{"key1":"value2"}`})
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("Y\n")}, w, p, editor, args...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgContinueOrAbort)
+		require.Contains(t, w.Written(), `- {"key1":"value1"}`)
+		require.Contains(t, w.Written(), `+ {"key1":"value2"}`)
+		require.Contains(t, w.Written(), msgConfigUpdated)
+		require.Equal(t, executeCount+1, ch.ExecuteCallCount())
+
+		req, _ := ch.ExecuteArgsForCall(executeCount)
+		cfg := &common.Config{}
+		require.NoError(t, json.Unmarshal(req.Args[0], cfg))
+		require.Len(t, cfg.Peers, 1)
+		require.Empty(t, cfg.Apps)
+		require.Equal(t, `{"key1":"value2"}`, cfg.Peers[0].Apps[0].Config)
+	})
+
+	t.Run("With prompt - N", func(t *testing.T) {
+		w := &mocks.Writer{}
+		editor := newMockEditor(map[string]string{".yaml": "key1: value2\n"})
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("N\n")}, w, p, editor, args...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgContinueOrAbort)
+		require.Contains(t, w.Written(), msgAborted)
+		require.NotContains(t, w.Written(), msgConfigUpdated)
+	})
+
+	t.Run("With --noprompt", func(t *testing.T) {
+		executeCount := ch.ExecuteCallCount()
+
+		w := &mocks.Writer{}
+		editor := newMockEditor(map[string]string{".yaml": "key1: value2\n"})
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, p, editor, append(args, "--noprompt")...)
+		require.NoError(t, c.Execute())
+		require.NotContains(t, w.Written(), msgContinueOrAbort)
+		require.Contains(t, w.Written(), msgConfigUpdated)
+
+		req, _ := ch.ExecuteArgsForCall(executeCount)
+		cfg := &common.Config{}
+		require.NoError(t, json.Unmarshal(req.Args[0], cfg))
+		require.Empty(t, cfg.Peers)
+		require.Len(t, cfg.Apps, 1)
+		require.Len(t, cfg.Apps[0].Components, 1)
+		require.Equal(t, "key1: value2\n", cfg.Apps[0].Components[0].Config)
+	})
+
+	t.Run("Invalid JSON -> re-edited", func(t *testing.T) {
+		executeCount := ch.ExecuteCallCount()
+
+		// The first edit is invalid and the second edit fixes it
+		var edits int
+		editor := func(editor, file string, streams environment.Streams) error {
+			if !strings.HasSuffix(file, ".json") {
+				return nil
+			}
+
+			edits++
+			if edits == 1 {
+				return ioutil.WriteFile(file, []byte(`{"key1":`), 0600)
+			}
+
+			return ioutil.WriteFile(file, []byte(`{"key1":"value3"}`), 0600)
+		}
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, p, editor, append(args, "--noprompt")...)
+		require.NoError(t, c.Execute())
+		require.Equal(t, 2, edits)
+		require.Contains(t, w.Written(), "Invalid configuration for")
+		require.Contains(t, w.Written(), msgConfigUpdated)
+
+		req, _ := ch.ExecuteArgsForCall(executeCount)
+		cfg := &common.Config{}
+		require.NoError(t, json.Unmarshal(req.Args[0], cfg))
+		require.Equal(t, `{"key1":"value3"}`, cfg.Peers[0].Apps[0].Config)
+	})
+
+	t.Run("Invalid YAML -> discarded", func(t *testing.T) {
+		executeCount := ch.ExecuteCallCount()
+
+		// The YAML is left invalid when the editor is re-opened so its changes are discarded while
+		// the changes to the JSON configuration are kept
+		editor := newMockEditor(map[string]string{".json": `{"key1":"value2"}`, ".yaml": "key1: [value2"})
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, p, editor, append(args, "--noprompt")...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), "Invalid configuration for")
+		require.Contains(t, w.Written(), "were discarded")
+		require.Contains(t, w.Written(), msgConfigUpdated)
+
+		req, _ := ch.ExecuteArgsForCall(executeCount)
+		cfg := &common.Config{}
+		require.NoError(t, json.Unmarshal(req.Args[0], cfg))
+		require.Len(t, cfg.Peers, 1)
+		require.Empty(t, cfg.Apps)
+		require.Equal(t, `{"key1":"value2"}`, cfg.Peers[0].Apps[0].Config)
+	})
+
+	t.Run("Invalid edit discarded -> no changes", func(t *testing.T) {
+		w := &mocks.Writer{}
+		editor := newMockEditor(map[string]string{".yaml": "key1: [value2"})
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, p, editor, append(args, "--noprompt")...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgNoChanges)
+	})
+
+	t.Run("Editor error", func(t *testing.T) {
+		errExpected := errors.New("injected editor error")
+		editor := func(editor, file string, streams environment.Streams) error { return errExpected }
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, &mocks.Writer{}, p, editor, append(args, "--editor", "myeditor")...)
+		err := c.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+		require.Contains(t, err.Error(), "myeditor")
+	})
+
+	t.Run("No config", func(t *testing.T) {
+		factory := &mocks.Factory{}
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: []byte("null")}, nil)
+		factory.ChannelReturns(ch, nil)
+
+		p := func(config *environment.Config) (fabric.Factory, error) { return factory, nil }
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, p, newMockEditor(nil), args...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgNoConfig)
+	})
+
+	t.Run("Channel error", func(t *testing.T) {
+		errExpected := errors.New("channel error")
+		p := func(config *environment.Config) (fabric.Factory, error) { return nil, errExpected }
+
+		c := newMockCmd(t, p, newMockEditor(nil), args...)
+		require.EqualError(t, c.Execute(), errExpected.Error())
+	})
+}
+
+func TestLaunchEditor(t *testing.T) {
+	require.Equal(t, errEditorRequired, launchEditor("  ", "file.json", environment.Streams{}))
+}
+
+func TestDiff(t *testing.T) {
+	require.Equal(t, "  a\n- b\n+ x\n  c\n", diff("a\nb\nc", "a\nx\nc"))
+	require.Equal(t, "  a\n+ b\n", diff("a", "a\nb"))
+	require.Equal(t, "  a\n- b\n", diff("a\nb", "a"))
+}
+
+func TestFileNameForKey(t *testing.T) {
+	kv := &common.KeyValue{
+		Key:   &common.Key{MspID: "Org1MSP", AppName: "file-handler", AppVersion: "1", ComponentName: "/content", ComponentVersion: "1"},
+		Value: &common.Value{Format: "json"},
+	}
+	require.Equal(t, "0-file-handler-_content.json", fileNameForKey(0, kv))
+
+	kv.Format = common.OtherFormat
+	require.Equal(t, "1-file-handler-_content.txt", fileNameForKey(1, kv))
+}
+
+// newMockEditor returns an editor launcher that replaces the contents of files that have the given
+// extensions with the corresponding content
+func newMockEditor(contents map[string]string) editorLauncher {
+	return func(editor, file string, streams environment.Streams) error {
+		for ext, content := range contents {
+			if strings.HasSuffix(file, ext) {
+				return ioutil.WriteFile(file, []byte(content), 0600)
+			}
+		}
+
+		return nil
+	}
+}
+
+func newMockCmd(t *testing.T, p basecmd.FactoryProvider, launcher editorLauncher, args ...string) *cobra.Command {
+	return newMockCmdWithReaderWriter(t, &mocks.Reader{}, &mocks.Writer{}, p, launcher, args...)
+}
+
+func newMockCmdWithReaderWriter(t *testing.T, in io.Reader, w io.Writer, p basecmd.FactoryProvider, launcher editorLauncher, args ...string) *cobra.Command {
+	settings := environment.NewDefaultSettings()
+	settings.Streams.Out = w
+	settings.Streams.In = in
+
+	settings.Config.CurrentContext = "testctx"
+	settings.Config.Contexts[settings.Config.CurrentContext] = &environment.Context{}
+
+	c := newCmd(settings, p, launcher)
+	require.NotNil(t, c)
+
+	c.SetArgs(args)
+
+	return c
+}
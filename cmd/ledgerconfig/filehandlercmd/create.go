@@ -0,0 +1,150 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package filehandlercmd
+
+import (
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+)
+
+const (
+	createUse      = "create"
+	createDesc     = "Create a file handler for a base path"
+	createLongDesc = `
+The create command adds a file handler for the given base path to the ledger configuration of each of the given peers. The command fails if any of the peers already has a file handler for the base path.
+`
+	createExamples = `
+- Create a file handler for the '/content' path on two peers in Org1MSP:
+    $ ./fabric ledgerconfig filehandler create --msp Org1MSP --peers peer0.org1.example.com;peer1.org1.example.com --path /content --chaincode files --collection consortium --idxns file:idx --readtokens content_r --writetokens content_w
+`
+
+	msgHandlerCreated = "File handler successfully created!"
+)
+
+var (
+	errChaincodeRequired      = errors.New("chaincode (--chaincode) is required")
+	errCollectionRequired     = errors.New("collection (--collection) is required")
+	errIndexNamespaceRequired = errors.New("index namespace (--idxns) is required")
+)
+
+func newCreateCmd(settings *environment.Settings, p basecmd.FactoryProvider) *cobra.Command {
+	c := &createCommand{}
+
+	cmd := &cobra.Command{
+		Use:     createUse,
+		Short:   createDesc,
+		Long:    createLongDesc,
+		Example: createExamples,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return c.validate()
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	c.command = newCommand(settings, p, cmd)
+
+	cmd.Flags().StringVar(&c.chaincode, chaincodeFlag, "", chaincodeUsage)
+	cmd.Flags().StringVar(&c.collection, collectionFlag, "", collectionUsage)
+	cmd.Flags().StringVar(&c.indexNamespace, indexNamespaceFlag, "", indexNamespaceUsage)
+	cmd.Flags().StringVar(&c.fileIndexID, fileIndexIDFlag, "", fileIndexIDUsage)
+	cmd.Flags().StringVar(&c.readTokens, readTokensFlag, "", readTokensUsage)
+	cmd.Flags().StringVar(&c.writeTokens, writeTokensFlag, "", writeTokensUsage)
+	cmd.Flags().BoolVar(&c.noPrompt, noPromptFlag, false, noPromptUsage)
+
+	return cmd
+}
+
+// createCommand implements the filehandler create command
+type createCommand struct {
+	*command
+
+	// Flags
+	chaincode      string
+	collection     string
+	indexNamespace string
+	fileIndexID    string
+	readTokens     string
+	writeTokens    string
+}
+
+func (c *createCommand) validate() error {
+	if err := c.command.validate(); err != nil {
+		return err
+	}
+
+	if c.chaincode == "" {
+		return errChaincodeRequired
+	}
+
+	if c.collection == "" {
+		return errCollectionRequired
+	}
+
+	if c.indexNamespace == "" {
+		return errIndexNamespaceRequired
+	}
+
+	return c.handlerConfig().Validate()
+}
+
+func (c *createCommand) run() error {
+	existing, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	for peerID := range existing {
+		return errors.Errorf("a file handler for [%s] already exists on peer [%s]", c.basePath, peerID)
+	}
+
+	cfgMap := make(map[string]*common.FileHandlerConfig)
+	for _, peerID := range c.peerIDs() {
+		cfgMap[peerID] = c.handlerConfig()
+	}
+
+	configBytes, err := c.getConfigBytes(cfgMap)
+	if err != nil {
+		return err
+	}
+
+	if !c.noPrompt {
+		confirmed, e := c.confirm("Creating the file handler configuration", configBytes)
+		if e != nil {
+			return e
+		}
+
+		if !confirmed {
+			return c.Fprintln(msgAborted)
+		}
+	}
+
+	if err := c.execute("save", configBytes); err != nil {
+		return err
+	}
+
+	return c.Fprintln(msgHandlerCreated)
+}
+
+func (c *createCommand) handlerConfig() *common.FileHandlerConfig {
+	return &common.FileHandlerConfig{
+		Authorization: common.FileHandlerAuthConfig{
+			ReadTokens:  splitTokens(c.readTokens),
+			WriteTokens: splitTokens(c.writeTokens),
+		},
+		BasePath:       c.basePath,
+		ChaincodeName:  c.chaincode,
+		Collection:     c.collection,
+		IndexNamespace: c.indexNamespace,
+		IndexDocID:     c.fileIndexID,
+	}
+}
@@ -0,0 +1,250 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package filehandlercmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+)
+
+const (
+	use      = "filehandler"
+	desc     = "Manages file handler configuration"
+	longDesc = `
+The filehandler command allows a client to create, show, update and remove the file handler configuration of one or more peers.
+A file handler serves files (stored in DCAS) for a given base path and the files are resolved by name using a Sidetree file index document.
+`
+)
+
+const (
+	mspIDFlag  = "msp"
+	mspIDUsage = `The ID of the MSP. Example: --msp Org1MSP`
+
+	peersFlag  = "peers"
+	peersUsage = "A semi-colon-separated list of peers. Example: --peers peer0.org1.com;peer1.org1.com"
+
+	basePathFlag  = "path"
+	basePathUsage = "The file handler path. Example: --path /content"
+
+	chaincodeFlag  = "chaincode"
+	chaincodeUsage = "The name of the chaincode that stores the files. Example: --chaincode files"
+
+	collectionFlag  = "collection"
+	collectionUsage = "The name of the DCAS collection that stores the files. Example: --collection consortium"
+
+	indexNamespaceFlag  = "idxns"
+	indexNamespaceUsage = "The namespace of the file index Sidetree documents. Example: --idxns file:idx"
+
+	fileIndexIDFlag  = "idxid"
+	fileIndexIDUsage = "The ID of the file index Sidetree document. Example: --idxid file:idx:1234"
+
+	readTokensFlag  = "readtokens"
+	readTokensUsage = "A comma-separated list of the names of the tokens used to authorize read requests. Example: --readtokens content_r"
+
+	writeTokensFlag  = "writetokens"
+	writeTokensUsage = "A comma-separated list of the names of the tokens used to authorize write requests. Example: --writetokens content_w"
+
+	noPromptFlag  = "noprompt"
+	noPromptUsage = "If specified then the operation will not prompt for confirmation. Example: --noprompt"
+
+	msgAborted         = "Operation aborted"
+	msgContinueOrAbort = "Enter Y to continue or N to abort "
+)
+
+var (
+	errMSPRequired      = errors.New("msp (--msp) is required")
+	errPeersRequired    = errors.New("peers (--peers) is required")
+	errBasePathRequired = errors.New("base path (--path) is required")
+	errInvalidBasePath  = errors.New("base path (--path) must begin with '/'")
+)
+
+// New returns the ledgerconfig filehandler sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	return newCmd(settings, nil)
+}
+
+func newCmd(settings *environment.Settings, p basecmd.FactoryProvider) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: desc,
+		Long:  longDesc,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	cmd.AddCommand(
+		newCreateCmd(settings, p),
+		newShowCmd(settings, p),
+		newSetCmd(settings, p),
+		newRemoveCmd(settings, p),
+	)
+
+	return cmd
+}
+
+// command contains the flags and functions that are common to all file handler sub-commands
+type command struct {
+	*basecmd.Command
+
+	// Flags
+	mspID    string
+	peers    string
+	basePath string
+	noPrompt bool
+}
+
+func newCommand(settings *environment.Settings, p basecmd.FactoryProvider, cmd *cobra.Command) *command {
+	c := &command{
+		Command: basecmd.New(settings, p),
+	}
+
+	c.Settings = settings
+	cmd.SetOutput(c.Settings.Streams.Out)
+	cmd.SilenceUsage = true
+
+	cmd.Flags().StringVar(&c.mspID, mspIDFlag, "", mspIDUsage)
+	cmd.Flags().StringVar(&c.peers, peersFlag, "", peersUsage)
+	cmd.Flags().StringVar(&c.basePath, basePathFlag, "", basePathUsage)
+
+	return c
+}
+
+func (c *command) validate() error {
+	if c.mspID == "" {
+		return errMSPRequired
+	}
+
+	if c.peers == "" {
+		return errPeersRequired
+	}
+
+	if c.basePath == "" {
+		return errBasePathRequired
+	}
+
+	if c.basePath[0:1] != "/" {
+		return errInvalidBasePath
+	}
+
+	return nil
+}
+
+func (c *command) peerIDs() []string {
+	if c.peers == "" {
+		return nil
+	}
+
+	return strings.Split(c.peers, ";")
+}
+
+// loadConfig loads the file handler configuration for each of the peers. If a peer doesn't have
+// a file handler for the base path then the peer is not included in the returned map.
+func (c *command) loadConfig() (map[string]*common.FileHandlerConfig, error) {
+	ch, err := c.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	cfgMap := make(map[string]*common.FileHandlerConfig)
+
+	for _, peerID := range c.peerIDs() {
+		cfg, err := common.QueryFileHandlerConfig(ch, c.mspID, peerID, c.basePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg != nil {
+			cfgMap[peerID] = cfg
+		}
+	}
+
+	return cfgMap, nil
+}
+
+// getConfigBytes returns the marshalled ledger config for the given file handler configurations (mapped by peer ID)
+func (c *command) getConfigBytes(cfgMap map[string]*common.FileHandlerConfig) ([]byte, error) {
+	cfg := &common.Config{
+		MspID: c.mspID,
+	}
+
+	for _, peerID := range c.peerIDs() {
+		handlerCfg, ok := cfgMap[peerID]
+		if !ok {
+			continue
+		}
+
+		peerCfg, err := common.NewFileHandlerPeerConfig(peerID, c.basePath, handlerCfg)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.Peers = append(cfg.Peers, peerCfg)
+	}
+
+	return json.Marshal(cfg)
+}
+
+// execute invokes the given function on the configuration system chaincode
+func (c *command) execute(fcn string, arg []byte) error {
+	req := channel.Request{
+		ChaincodeID: common.ConfigSCC,
+		Fcn:         fcn,
+		Args:        [][]byte{arg},
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		return err
+	}
+
+	_, err = ch.Execute(req, channel.WithRetry(retry.DefaultChannelOpts))
+
+	return err
+}
+
+// confirm prompts the user for confirmation of the operation
+func (c *command) confirm(msg string, config []byte) (bool, error) {
+	displayedJSON, err := common.FormatJSON(config)
+	if err != nil {
+		return false, err
+	}
+
+	prompt := fmt.Sprintf("%s:\n\n%s\n\n%s", msg, displayedJSON, msgContinueOrAbort)
+
+	err = c.Fprintln(prompt)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.ToLower(c.Prompt()) == "y", nil
+}
+
+func splitTokens(tokens string) []string {
+	if tokens == "" {
+		return nil
+	}
+
+	var names []string
+	for _, name := range strings.Split(tokens, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,316 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package filehandlercmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/hyperledger/fabric-cli/pkg/fabric"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+const (
+	msp   = "Org1MSP"
+	peer0 = "peer0.org1.example.com"
+	peer1 = "peer1.org1.example.com"
+	path  = "/content"
+
+	handlerCfg = `{"BasePath":"/content","ChaincodeName":"files","Collection":"consortium","IndexNamespace":"file:idx","Authorization":{"ReadTokens":["content_r"]}}`
+)
+
+func TestNew(t *testing.T) {
+	settings := environment.NewDefaultSettings()
+
+	cmd := New(settings)
+	require.NotNil(t, cmd)
+
+	w := &mocks.Writer{}
+	cmd.SetOutput(w)
+
+	require.NoError(t, cmd.Execute())
+	require.Contains(t, w.Written(), createUse)
+	require.Contains(t, w.Written(), showUse)
+	require.Contains(t, w.Written(), setUse)
+	require.Contains(t, w.Written(), removeUse)
+}
+
+func TestCreateCmd(t *testing.T) {
+	args := []string{"create", "--msp", msp, "--peers", peer0 + ";" + peer1, "--path", path, "--chaincode", "files", "--collection", "consortium", "--idxns", "file:idx", "--readtokens", "content_r", "--writetokens", "content_w"}
+
+	t.Run("Invalid options", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, "create").Execute(), errMSPRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, "create", "--msp", msp).Execute(), errPeersRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, "create", "--msp", msp, "--peers", peer0).Execute(), errBasePathRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, "create", "--msp", msp, "--peers", peer0, "--path", "content").Execute(), errInvalidBasePath.Error())
+		require.EqualError(t, newMockCmd(t, nil, "create", "--msp", msp, "--peers", peer0, "--path", path).Execute(), errChaincodeRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, "create", "--msp", msp, "--peers", peer0, "--path", path, "--chaincode", "files").Execute(), errCollectionRequired.Error())
+		require.EqualError(t, newMockCmd(t, nil, "create", "--msp", msp, "--peers", peer0, "--path", path, "--chaincode", "files", "--collection", "consortium").Execute(), errIndexNamespaceRequired.Error())
+
+		err := newMockCmd(t, nil, "create", "--msp", msp, "--peers", peer0, "--path", path, "--chaincode", "files", "--collection", "consortium", "--idxns", "file:").Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid index namespace")
+
+		err = newMockCmd(t, nil, append(args, "--idxid", "xxx:1234")...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "file index ID must begin with [file:idx:]")
+
+		err = newMockCmd(t, nil, "create", "--msp", msp, "--peers", peer0, "--path", path, "--chaincode", "files", "--collection", "consortium", "--idxns", "file:idx", "--writetokens", "a b").Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid write token")
+	})
+
+	t.Run("With --noprompt", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: []byte("null")}, nil)
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, newMockProvider(ch), append(args, "--noprompt")...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgHandlerCreated)
+		require.Equal(t, 1, ch.ExecuteCallCount())
+
+		req, _ := ch.ExecuteArgsForCall(0)
+		require.Equal(t, "save", req.Fcn)
+
+		cfg := &common.Config{}
+		require.NoError(t, json.Unmarshal(req.Args[0], cfg))
+		require.Equal(t, msp, cfg.MspID)
+		require.Len(t, cfg.Peers, 2)
+		require.Equal(t, path, cfg.Peers[0].Apps[0].Components[0].Name)
+
+		handlerCfg := &common.FileHandlerConfig{}
+		require.NoError(t, json.Unmarshal([]byte(cfg.Peers[0].Apps[0].Components[0].Config), handlerCfg))
+		require.Equal(t, "files", handlerCfg.ChaincodeName)
+		require.Equal(t, []string{"content_r"}, handlerCfg.Authorization.ReadTokens)
+		require.Equal(t, []string{"content_w"}, handlerCfg.Authorization.WriteTokens)
+	})
+
+	t.Run("With prompt - N", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: []byte("null")}, nil)
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("N\n")}, w, newMockProvider(ch), args...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgContinueOrAbort)
+		require.Contains(t, w.Written(), msgAborted)
+		require.Equal(t, 0, ch.ExecuteCallCount())
+	})
+
+	t.Run("Already exists", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: newKeyValuesPayload(t, peer0)}, nil)
+
+		err := newMockCmd(t, newMockProvider(ch), append(args, "--noprompt")...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "already exists")
+	})
+}
+
+func TestShowCmd(t *testing.T) {
+	t.Run("No MSP", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, "show").Execute(), errMSPRequired.Error())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: newKeyValuesPayload(t, peer0, peer1)}, nil)
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, newMockProvider(ch), "show", "--msp", msp)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), peer0)
+		require.Contains(t, w.Written(), peer1)
+		require.Contains(t, w.Written(), `"ChaincodeName": "files"`)
+
+		req, _ := ch.QueryArgsForCall(0)
+		criteria := &common.Criteria{}
+		require.NoError(t, json.Unmarshal(req.Args[0], criteria))
+		require.Equal(t, common.FileHandlerAppName, criteria.AppName)
+		require.Empty(t, criteria.PeerID)
+		require.Empty(t, criteria.ComponentName)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: []byte("null")}, nil)
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, newMockProvider(ch), "show", "--msp", msp, "--peers", peer0, "--path", path)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgNoHandlers)
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		errExpected := errors.New("injected query error")
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{}, errExpected)
+
+		require.EqualError(t, newMockCmd(t, newMockProvider(ch), "show", "--msp", msp).Execute(), errExpected.Error())
+	})
+}
+
+func TestSetCmd(t *testing.T) {
+	args := []string{"set", "--msp", msp, "--peers", peer0 + ";" + peer1, "--path", path}
+
+	t.Run("Nothing to set", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, args...).Execute(), errNothingToSet.Error())
+	})
+
+	t.Run("With --noprompt", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: newKeyValuesPayload(t, peer0)}, nil)
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, newMockProvider(ch), append(args, "--collection", "consortium2", "--readtokens", "", "--noprompt")...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgHandlerUpdated)
+
+		req, _ := ch.ExecuteArgsForCall(0)
+		cfg := &common.Config{}
+		require.NoError(t, json.Unmarshal(req.Args[0], cfg))
+		require.Len(t, cfg.Peers, 2)
+
+		handlerCfg := &common.FileHandlerConfig{}
+		require.NoError(t, json.Unmarshal([]byte(cfg.Peers[1].Apps[0].Components[0].Config), handlerCfg))
+		require.Equal(t, "consortium2", handlerCfg.Collection)
+		require.Equal(t, "files", handlerCfg.ChaincodeName)
+		require.Empty(t, handlerCfg.Authorization.ReadTokens)
+	})
+
+	t.Run("No changes", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: newKeyValuesPayload(t, peer0)}, nil)
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, newMockProvider(ch), append(args, "--collection", "consortium", "--noprompt")...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgNoChanges)
+		require.Equal(t, 0, ch.ExecuteCallCount())
+	})
+
+	t.Run("Invalid value", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: newKeyValuesPayload(t, peer0)}, nil)
+
+		err := newMockCmd(t, newMockProvider(ch), append(args, "--idxid", "did:xxx:1234", "--noprompt")...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "file index ID must begin with")
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: []byte("null")}, nil)
+
+		err := newMockCmd(t, newMockProvider(ch), append(args, "--chaincode", "files2", "--noprompt")...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "config not found for file handler")
+	})
+}
+
+func TestRemoveCmd(t *testing.T) {
+	args := []string{"remove", "--msp", msp, "--peers", peer0 + ";" + peer1, "--path", path}
+
+	t.Run("With prompt - Y", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: newKeyValuesPayload(t, peer0)}, nil)
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("Y\n")}, w, newMockProvider(ch), args...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgContinueOrAbort)
+		require.Contains(t, w.Written(), msgHandlerRemoved)
+		require.Equal(t, 2, ch.ExecuteCallCount())
+
+		req, _ := ch.ExecuteArgsForCall(1)
+		require.Equal(t, "delete", req.Fcn)
+
+		criteria := &common.Criteria{}
+		require.NoError(t, json.Unmarshal(req.Args[0], criteria))
+		require.Equal(t, peer1, criteria.PeerID)
+		require.Equal(t, path, criteria.ComponentName)
+	})
+
+	t.Run("Nothing to remove", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: []byte("null")}, nil)
+
+		w := &mocks.Writer{}
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, newMockProvider(ch), append(args, "--noprompt")...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgNoHandlers)
+		require.Equal(t, 0, ch.ExecuteCallCount())
+	})
+
+	t.Run("Execute error", func(t *testing.T) {
+		errExpected := errors.New("injected execute error")
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: newKeyValuesPayload(t, peer0)}, nil)
+		ch.ExecuteReturns(channel.Response{}, errExpected)
+
+		require.EqualError(t, newMockCmd(t, newMockProvider(ch), append(args, "--noprompt")...).Execute(), errExpected.Error())
+	})
+}
+
+func newKeyValuesPayload(t *testing.T, peerIDs ...string) []byte {
+	var kvs []*common.KeyValue
+	for _, peerID := range peerIDs {
+		kvs = append(kvs, &common.KeyValue{
+			Key: &common.Key{
+				MspID:            msp,
+				PeerID:           peerID,
+				AppName:          common.FileHandlerAppName,
+				AppVersion:       common.FileHandlerAppVersion,
+				ComponentName:    path,
+				ComponentVersion: common.FileHandlerComponentVersion,
+			},
+			Value: &common.Value{TxID: "tx1", Format: common.JSONFormat, Config: handlerCfg},
+		})
+	}
+
+	payload, err := json.Marshal(kvs)
+	require.NoError(t, err)
+
+	return payload
+}
+
+func newMockProvider(ch *mocks.Channel) basecmd.FactoryProvider {
+	factory := &mocks.Factory{}
+	factory.ChannelReturns(ch, nil)
+
+	return func(config *environment.Config) (fabric.Factory, error) { return factory, nil }
+}
+
+func newMockCmd(t *testing.T, p basecmd.FactoryProvider, args ...string) *cobra.Command {
+	return newMockCmdWithReaderWriter(t, &mocks.Reader{}, &mocks.Writer{}, p, args...)
+}
+
+func newMockCmdWithReaderWriter(t *testing.T, in io.Reader, w io.Writer, p basecmd.FactoryProvider, args ...string) *cobra.Command {
+	settings := environment.NewDefaultSettings()
+	settings.Streams.Out = w
+	settings.Streams.In = in
+
+	settings.Config.CurrentContext = "testctx"
+	settings.Config.Contexts[settings.Config.CurrentContext] = &environment.Context{}
+
+	c := newCmd(settings, p)
+	require.NotNil(t, c)
+
+	c.SetArgs(args)
+
+	return c
+}
@@ -0,0 +1,103 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package filehandlercmd
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+)
+
+const (
+	removeUse      = "remove"
+	removeDesc     = "Remove the file handler of a base path"
+	removeLongDesc = `
+The remove command deletes the file handler configuration for the given base path from each of the given peers. Peers that don't have a file handler for the base path are ignored.
+`
+	removeExamples = `
+- Remove the '/content' file handler from two peers in Org1MSP:
+    $ ./fabric ledgerconfig filehandler remove --msp Org1MSP --peers peer0.org1.example.com;peer1.org1.example.com --path /content
+`
+
+	msgHandlerRemoved = "File handler successfully removed!"
+)
+
+func newRemoveCmd(settings *environment.Settings, p basecmd.FactoryProvider) *cobra.Command {
+	c := &removeCommand{}
+
+	cmd := &cobra.Command{
+		Use:     removeUse,
+		Short:   removeDesc,
+		Long:    removeLongDesc,
+		Example: removeExamples,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return c.validate()
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	c.command = newCommand(settings, p, cmd)
+
+	cmd.Flags().BoolVar(&c.noPrompt, noPromptFlag, false, noPromptUsage)
+
+	return cmd
+}
+
+// removeCommand implements the filehandler remove command
+type removeCommand struct {
+	*command
+}
+
+func (c *removeCommand) run() error {
+	cfgMap, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if len(cfgMap) == 0 {
+		return c.Fprintln(msgNoHandlers)
+	}
+
+	if !c.noPrompt {
+		configBytes, e := c.getConfigBytes(cfgMap)
+		if e != nil {
+			return e
+		}
+
+		confirmed, e := c.confirm("The following configuration will be deleted", configBytes)
+		if e != nil {
+			return e
+		}
+
+		if !confirmed {
+			return c.Fprintln(msgAborted)
+		}
+	}
+
+	for _, peerID := range c.peerIDs() {
+		if _, ok := cfgMap[peerID]; !ok {
+			continue
+		}
+
+		criteriaBytes, err := json.Marshal(common.NewFileHandlerCriteria(c.mspID, peerID, c.basePath))
+		if err != nil {
+			return err
+		}
+
+		if err := c.execute("delete", criteriaBytes); err != nil {
+			return err
+		}
+	}
+
+	return c.Fprintln(msgHandlerRemoved)
+}
@@ -0,0 +1,177 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package filehandlercmd
+
+import (
+	"reflect"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+)
+
+const (
+	setUse      = "set"
+	setDesc     = "Update the file handler configuration of a base path"
+	setLongDesc = `
+The set command updates one or more fields of the file handler configuration for the given base path on each of the given peers. Only the fields that are specified on the command-line are updated. A token list may be cleared by specifying an empty value, e.g. --readtokens "".
+`
+	setExamples = `
+- Change the collection and the write tokens of the '/content' file handler on two peers in Org1MSP:
+    $ ./fabric ledgerconfig filehandler set --msp Org1MSP --peers peer0.org1.example.com;peer1.org1.example.com --path /content --collection consortium2 --writetokens content_w,admin_w --noprompt
+`
+
+	msgHandlerUpdated = "File handler successfully updated!"
+	msgNoChanges      = "The file handler configuration is already up to date"
+)
+
+var errNothingToSet = errors.New("at least one of --chaincode, --collection, --idxns, --idxid, --readtokens or --writetokens must be specified")
+
+func newSetCmd(settings *environment.Settings, p basecmd.FactoryProvider) *cobra.Command {
+	c := &setCommand{}
+
+	cmd := &cobra.Command{
+		Use:     setUse,
+		Short:   setDesc,
+		Long:    setLongDesc,
+		Example: setExamples,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return c.validate(cmd)
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return c.run(cmd)
+		},
+	}
+
+	c.command = newCommand(settings, p, cmd)
+
+	cmd.Flags().StringVar(&c.chaincode, chaincodeFlag, "", chaincodeUsage)
+	cmd.Flags().StringVar(&c.collection, collectionFlag, "", collectionUsage)
+	cmd.Flags().StringVar(&c.indexNamespace, indexNamespaceFlag, "", indexNamespaceUsage)
+	cmd.Flags().StringVar(&c.fileIndexID, fileIndexIDFlag, "", fileIndexIDUsage)
+	cmd.Flags().StringVar(&c.readTokens, readTokensFlag, "", readTokensUsage)
+	cmd.Flags().StringVar(&c.writeTokens, writeTokensFlag, "", writeTokensUsage)
+	cmd.Flags().BoolVar(&c.noPrompt, noPromptFlag, false, noPromptUsage)
+
+	return cmd
+}
+
+// setCommand implements the filehandler set command
+type setCommand struct {
+	*command
+
+	// Flags
+	chaincode      string
+	collection     string
+	indexNamespace string
+	fileIndexID    string
+	readTokens     string
+	writeTokens    string
+}
+
+func (c *setCommand) validate(cmd *cobra.Command) error {
+	if err := c.command.validate(); err != nil {
+		return err
+	}
+
+	for _, flag := range []string{chaincodeFlag, collectionFlag, indexNamespaceFlag, fileIndexIDFlag, readTokensFlag, writeTokensFlag} {
+		if cmd.Flags().Changed(flag) {
+			return nil
+		}
+	}
+
+	return errNothingToSet
+}
+
+func (c *setCommand) run(cmd *cobra.Command) error {
+	cfgMap, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	updated := make(map[string]*common.FileHandlerConfig)
+
+	for _, peerID := range c.peerIDs() {
+		cfg, ok := cfgMap[peerID]
+		if !ok {
+			return errors.Errorf("config not found for file handler [%s] on peer [%s]", c.basePath, peerID)
+		}
+
+		newCfg := c.apply(cmd, cfg)
+
+		if err := newCfg.Validate(); err != nil {
+			return errors.WithMessagef(err, "invalid file handler config for peer [%s]", peerID)
+		}
+
+		if reflect.DeepEqual(cfg, newCfg) {
+			// Nothing changed for this peer
+			continue
+		}
+
+		updated[peerID] = newCfg
+	}
+
+	if len(updated) == 0 {
+		return c.Fprintln(msgNoChanges)
+	}
+
+	configBytes, err := c.getConfigBytes(updated)
+	if err != nil {
+		return err
+	}
+
+	if !c.noPrompt {
+		confirmed, e := c.confirm("Updating the configuration with", configBytes)
+		if e != nil {
+			return e
+		}
+
+		if !confirmed {
+			return c.Fprintln(msgAborted)
+		}
+	}
+
+	if err := c.execute("save", configBytes); err != nil {
+		return err
+	}
+
+	return c.Fprintln(msgHandlerUpdated)
+}
+
+// apply returns a copy of the given config with the fields that were specified on the command-line
+func (c *setCommand) apply(cmd *cobra.Command, cfg *common.FileHandlerConfig) *common.FileHandlerConfig {
+	newCfg := *cfg
+
+	if cmd.Flags().Changed(chaincodeFlag) {
+		newCfg.ChaincodeName = c.chaincode
+	}
+
+	if cmd.Flags().Changed(collectionFlag) {
+		newCfg.Collection = c.collection
+	}
+
+	if cmd.Flags().Changed(indexNamespaceFlag) {
+		newCfg.IndexNamespace = c.indexNamespace
+	}
+
+	if cmd.Flags().Changed(fileIndexIDFlag) {
+		newCfg.IndexDocID = c.fileIndexID
+	}
+
+	if cmd.Flags().Changed(readTokensFlag) {
+		newCfg.Authorization.ReadTokens = splitTokens(c.readTokens)
+	}
+
+	if cmd.Flags().Changed(writeTokensFlag) {
+		newCfg.Authorization.WriteTokens = splitTokens(c.writeTokens)
+	}
+
+	return &newCfg
+}
@@ -0,0 +1,126 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package filehandlercmd
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+)
+
+const (
+	showUse      = "show"
+	showDesc     = "Show file handler configuration"
+	showLongDesc = `
+The show command displays the file handler configuration of an MSP. The results may be narrowed down to a set of peers (--peers) and/or a base path (--path).
+`
+	showExamples = `
+- Show all file handlers in Org1MSP:
+    $ ./fabric ledgerconfig filehandler show --msp Org1MSP
+
+- Show the '/content' file handler on a given peer:
+    $ ./fabric ledgerconfig filehandler show --msp Org1MSP --peers peer0.org1.example.com --path /content
+`
+
+	msgNoHandlers = "No file handlers found"
+)
+
+// handlerInfo is the displayed file handler configuration
+type handlerInfo struct {
+	PeerID string
+	Path   string
+	Config *common.FileHandlerConfig
+}
+
+func newShowCmd(settings *environment.Settings, p basecmd.FactoryProvider) *cobra.Command {
+	c := &showCommand{}
+
+	cmd := &cobra.Command{
+		Use:     showUse,
+		Short:   showDesc,
+		Long:    showLongDesc,
+		Example: showExamples,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return c.validate()
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	c.command = newCommand(settings, p, cmd)
+
+	return cmd
+}
+
+// showCommand implements the filehandler show command
+type showCommand struct {
+	*command
+}
+
+func (c *showCommand) validate() error {
+	if c.mspID == "" {
+		return errMSPRequired
+	}
+
+	return nil
+}
+
+func (c *showCommand) run() error {
+	ch, err := c.Channel()
+	if err != nil {
+		return err
+	}
+
+	peerIDs := c.peerIDs()
+	if len(peerIDs) == 0 {
+		// Query for all peers
+		peerIDs = []string{""}
+	}
+
+	var handlers []*handlerInfo
+
+	for _, peerID := range peerIDs {
+		kvs, err := common.QueryKeyValues(ch, common.NewFileHandlerCriteria(c.mspID, peerID, c.basePath))
+		if err != nil {
+			return err
+		}
+
+		for _, kv := range kvs {
+			cfg, err := common.UnmarshalFileHandlerConfig(kv)
+			if err != nil {
+				return err
+			}
+
+			handlers = append(handlers, &handlerInfo{
+				PeerID: kv.PeerID,
+				Path:   kv.ComponentName,
+				Config: cfg,
+			})
+		}
+	}
+
+	if len(handlers) == 0 {
+		return c.Fprintln(msgNoHandlers)
+	}
+
+	handlersBytes, err := json.Marshal(handlers)
+	if err != nil {
+		return err
+	}
+
+	displayedJSON, err := common.FormatJSON(handlersBytes)
+	if err != nil {
+		return err
+	}
+
+	return c.Fprintln(string(displayedJSON))
+}
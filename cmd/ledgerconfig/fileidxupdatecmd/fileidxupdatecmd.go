@@ -7,13 +7,10 @@ SPDX-License-Identifier: Apache-2.0
 package fileidxupdatecmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/hyperledger/fabric-cli/pkg/environment"
-	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
-	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
@@ -25,10 +22,15 @@ const (
 	use      = "fileidxupdate"
 	desc     = "Update the ID of the file index document for a given path"
 	longDesc = `
-The fileidxupdate command allows a client to update the file handler configuration of a peer with an ID of a Sidetree file index document`
+The fileidxupdate command allows a client to update the file handler configuration of a peer with an ID of a Sidetree file index document.
+The peers may be specified explicitly (--peers) or, using the --all-peers option, all peers in the MSP that have a file handler for the given path
+are updated. Once complete, the command reports which peers were updated, skipped (since the file index ID was already set) or failed.`
 	examples = `
 - Updates the ID of the file index Sidetree document in two peers in Org1MSP:
     $ ./fabric ledgerconfig fileidxupdate --msp Org1MSP --peers peer0.org1.example.com;peer1.org1.example.com --path /content --idxid file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --noprompt
+
+- Updates the ID of the file index Sidetree document in all peers in Org1MSP that have a file handler for '/content':
+    $ ./fabric ledgerconfig fileidxupdate --msp Org1MSP --all-peers --path /content --idxid file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA== --noprompt
 `
 )
 
@@ -39,6 +41,9 @@ const (
 	peersFlag  = "peers"
 	peersUsage = "A semi-colon-separated list of peers. Example: --peers peer0.org1.com;peer1.org1.com"
 
+	allPeersFlag  = "all-peers"
+	allPeersUsage = "If specified then all peers in the MSP that have a file handler for the given path are updated. Example: --all-peers"
+
 	basePathFlag  = "path"
 	basePathUsage = "The file handler path. Example: --path /schema"
 
@@ -53,17 +58,10 @@ const (
 	msgContinueOrAbort = "Enter Y to continue or N to abort "
 )
 
-const (
-	configSCC = "configscc"
-
-	fileHandlerAppName          = "file-handler"
-	fileHandlerAppVersion       = "1"
-	fileHandlerComponentVersion = "1"
-)
-
 var (
 	errMSPRequired         = errors.New("msp (--msp) is required")
-	errPeersRequired       = errors.New("peers (--peers) is required")
+	errPeersRequired       = errors.New("either peers (--peers) or all peers (--all-peers) is required")
+	errPeersAndAllPeers    = errors.New("only one of peers (--peers) or all peers (--all-peers) may be specified")
 	errFileIndexIDRequired = errors.New("file index ID (--idxid) is required")
 	errBasePathRequired    = errors.New("base path (--path) is required")
 )
@@ -99,6 +97,7 @@ func newCmd(settings *environment.Settings, p basecmd.FactoryProvider) *cobra.Co
 
 	cmd.Flags().StringVar(&c.mspID, mspIDFlag, "", mspIDUsage)
 	cmd.Flags().StringVar(&c.peerID, peersFlag, "", peersUsage)
+	cmd.Flags().BoolVar(&c.allPeers, allPeersFlag, false, allPeersUsage)
 	cmd.Flags().StringVar(&c.basePath, basePathFlag, "", basePathUsage)
 	cmd.Flags().StringVar(&c.fileIndexID, fileIndexIDFlag, "", fileIndexIDUsage)
 	cmd.Flags().BoolVar(&c.noPrompt, noPromptFlag, false, noPromptUsage)
@@ -113,6 +112,7 @@ type command struct {
 	// Flags
 	mspID       string
 	peerID      string
+	allPeers    bool
 	basePath    string
 	fileIndexID string
 	noPrompt    bool
@@ -123,10 +123,14 @@ func (c *command) validate() error {
 		return errMSPRequired
 	}
 
-	if c.peerID == "" {
+	if c.peerID == "" && !c.allPeers {
 		return errPeersRequired
 	}
 
+	if c.peerID != "" && c.allPeers {
+		return errPeersAndAllPeers
+	}
+
 	if c.basePath == "" {
 		return errBasePathRequired
 	}
@@ -139,178 +143,70 @@ func (c *command) validate() error {
 }
 
 func (c *command) run() error {
-	configBytes, err := c.getConfigBytes()
-	if err != nil {
-		return err
-	}
-
-	// Get confirmation from the user
-	if !c.noPrompt {
-		confirmed, e := c.confirmUpdate(configBytes)
-		if e != nil {
-			return e
-		}
-		if !confirmed {
-			return c.Fprintln(msgAborted)
-		}
-	}
-
-	req := channel.Request{
-		ChaincodeID: common.ConfigSCC,
-		Fcn:         "save",
-		Args:        [][]byte{configBytes},
-	}
-
 	ch, err := c.Channel()
 	if err != nil {
 		return err
 	}
 
-	_, err = ch.Execute(req, channel.WithRetry(retry.DefaultChannelOpts))
-	if err != nil {
-		return err
-	}
-
-	return c.Fprintln(msgConfigUpdated)
-}
-
-type authConfig struct {
-	// ReadTokens contains a set of names of tokens for authorizing read requests
-	ReadTokens []string
-	// WriteTokens contains a set of names of tokens for authorizing write requests
-	WriteTokens []string
-}
-
-type fileHandlerConfig struct {
-	Authorization authConfig
+	updater := common.NewFileIndexUpdater(ch, c.mspID, c.basePath)
 
-	BasePath       string
-	ChaincodeName  string
-	Collection     string
-	IndexNamespace string
-	IndexDocID     string
-}
-
-func (c *command) getConfigBytes() ([]byte, error) {
-	cfgMap, err := c.loadConfig()
+	results, err := c.loadConfig(updater)
 	if err != nil {
-		return nil, err
-	}
-
-	cfg := &common.Config{
-		MspID: c.mspID,
+		return err
 	}
 
-	for peerID, handlerCfg := range cfgMap {
-		handlerCfg.IndexDocID = c.fileIndexID
+	if len(results.Updated) > 0 {
+		var configBytes []byte
 
-		cfgBytes, err := json.Marshal(handlerCfg)
+		configBytes, err = updater.GetConfigBytes(results, c.fileIndexID)
 		if err != nil {
-			return nil, err
-		}
-
-		peerCfg := &common.Peer{
-			PeerID: peerID,
-			Apps: []*common.App{
-				{
-					AppName: fileHandlerAppName,
-					Version: fileHandlerAppVersion,
-					Components: []*common.Component{
-						{
-							Name:    c.basePath,
-							Version: fileHandlerComponentVersion,
-							Format:  "JSON",
-							Config:  string(cfgBytes),
-						},
-					},
-				},
-			},
+			return err
 		}
 
-		cfg.Peers = append(cfg.Peers, peerCfg)
-	}
-
-	return json.Marshal(cfg)
-}
-
-func (c *command) loadConfig() (map[string]*fileHandlerConfig, error) {
-	peers := strings.Split(c.peerID, ";")
-
-	cfgMap := make(map[string]*fileHandlerConfig)
-	for _, peerID := range peers {
-		cfg, err := c.loadPeerConfig(peerID)
-		if err != nil {
-			return nil, err
+		// Get confirmation from the user
+		if !c.noPrompt {
+			confirmed, e := c.confirmUpdate(configBytes)
+			if e != nil {
+				return e
+			}
+			if !confirmed {
+				return c.Fprintln(msgAborted)
+			}
 		}
 
-		if !strings.HasPrefix(c.fileIndexID, cfg.IndexNamespace) {
-			return nil, errors.Errorf("file index ID must begin with [%s]", cfg.IndexNamespace)
+		if err := updater.Save(configBytes); err != nil {
+			return err
 		}
 
-		if cfg.IndexDocID == c.fileIndexID {
-			// Index already set for peerID. Skip this peerID
-			continue
+		if err := c.Fprintln(msgConfigUpdated); err != nil {
+			return err
 		}
-
-		cfgMap[peerID] = cfg
-	}
-
-	if len(cfgMap) == 0 {
-		return nil, errors.Errorf("the file index ID for [%s] is already set to [%s]", c.basePath, c.fileIndexID)
-	}
-
-	return cfgMap, nil
-}
-
-func (c *command) loadPeerConfig(peerID string) (*fileHandlerConfig, error) {
-	criteria := &common.Criteria{
-		MspID:            c.mspID,
-		PeerID:           peerID,
-		AppName:          fileHandlerAppName,
-		AppVersion:       fileHandlerAppVersion,
-		ComponentName:    c.basePath,
-		ComponentVersion: fileHandlerComponentVersion,
-	}
-
-	criteriaBytes, err := json.Marshal(criteria)
-	if err != nil {
-		return nil, err
 	}
 
-	req := channel.Request{
-		ChaincodeID: configSCC,
-		Fcn:         "get",
-		Args:        [][]byte{criteriaBytes},
+	if err := c.Fprint(results.String()); err != nil {
+		return err
 	}
 
-	ch, err := c.Channel()
-	if err != nil {
-		return nil, err
+	if len(results.Failed) > 0 {
+		return errors.Errorf("failed to update the file index ID on %d peer(s): %s", len(results.Failed), results.FailedString())
 	}
 
-	resp, err := ch.Query(req)
-	if err != nil {
-		return nil, err
-	}
+	return nil
+}
 
-	var queryResults []*common.KeyValue
-	err = json.Unmarshal(resp.Payload, &queryResults)
+func (c *command) loadConfig(updater *common.FileIndexUpdater) (*common.FileIndexUpdateResults, error) {
+	peers, err := updater.GetPeers(c.peerID, c.allPeers)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(queryResults) == 0 {
-		return nil, errors.Errorf("config not found for file handler [%s]", c.basePath)
-	}
-
-	cfg := &fileHandlerConfig{}
+	results := common.NewFileIndexUpdateResults(peers, updater.LoadPeerConfigs(peers), c.fileIndexID)
 
-	err = json.Unmarshal([]byte(queryResults[0].Value.Config), cfg)
-	if err != nil {
-		return nil, err
+	if len(results.Updated) == 0 && len(results.Failed) == 0 {
+		return nil, errors.Errorf("the file index ID for [%s] is already set to [%s]", c.basePath, c.fileIndexID)
 	}
 
-	return cfg, nil
+	return results, nil
 }
 
 // confirmUpdate prompts the user for confirmation of the update
@@ -44,6 +44,10 @@ func TestFileIDXUpdateCmd_InvalidOptions(t *testing.T) {
 		require.EqualError(t, newMockCmd(t, nil, mspFlag, msp).Execute(), errPeersRequired.Error())
 	})
 
+	t.Run("Peers and all peers", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, mspFlag, msp, peersFlag, peers, "--all-peers").Execute(), errPeersAndAllPeers.Error())
+	})
+
 	t.Run("No path", func(t *testing.T) {
 		require.EqualError(t, newMockCmd(t, nil, mspFlag, msp, peersFlag, peers).Execute(), errBasePathRequired.Error())
 	})
@@ -73,6 +77,7 @@ func TestFileIDXUpdateCmd(t *testing.T) {
 
 		handlerCfg           = `{"BasePath":"/content","ChaincodeName":"files","Collection":"consortium","IndexNamespace":"file:idx"}`
 		mismatchedHandlerCfg = `{"BasePath":"/content","ChaincodeName":"files","Collection":"consortium","IndexNamespace":"file:xxx"}`
+		prefixHandlerCfg     = `{"BasePath":"/content","ChaincodeName":"files","Collection":"consortium","IndexNamespace":"file:id"}`
 		setHandlerCfg        = `{"BasePath":"/content","ChaincodeName":"files","Collection":"consortium","IndexNamespace":"file:idx","IndexDocID": "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="}`
 	)
 
@@ -88,8 +93,8 @@ func TestFileIDXUpdateCmd(t *testing.T) {
 	key := &common.Key{
 		MspID:            msp,
 		PeerID:           peer,
-		AppName:          fileHandlerAppName,
-		AppVersion:       fileHandlerAppVersion,
+		AppName:          common.FileHandlerAppName,
+		AppVersion:       common.FileHandlerAppVersion,
 		ComponentName:    path,
 		ComponentVersion: "1",
 	}
@@ -154,6 +159,24 @@ func TestFileIDXUpdateCmd(t *testing.T) {
 		require.Contains(t, err.Error(), "file index ID must begin with")
 	})
 
+	t.Run("Namespace without separator", func(t *testing.T) {
+		// The file index ID begins with "file:id" but not with "file:id:"
+		cfg := &common.KeyValue{
+			Key:   key,
+			Value: &common.Value{TxID: "tx1", Format: "json", Config: prefixHandlerCfg},
+		}
+
+		cfgBytes, err := json.Marshal([]*common.KeyValue{cfg})
+		require.NoError(t, err)
+
+		c.QueryReturns(channel.Response{Payload: cfgBytes}, nil)
+		c := newMockCmd(t, p, append(args, "--noprompt")...)
+
+		err = c.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "file index ID must begin with [file:id:]")
+	})
+
 	t.Run("File index ID already set", func(t *testing.T) {
 		cfg := &common.KeyValue{
 			Key:   key,
@@ -172,6 +195,128 @@ func TestFileIDXUpdateCmd(t *testing.T) {
 	})
 }
 
+func TestFileIDXUpdateCmd_MultiplePeers(t *testing.T) {
+	const (
+		msp   = "Org1MSP"
+		peer0 = "peer0.org1.example.com"
+		peer1 = "peer1.org1.example.com"
+		peer2 = "peer2.org1.example.com"
+		path  = "/content"
+		idxID = "file:idx:EiAuN66iEpuRt6IIu-2sO3bRM74sS_AIuY6jTbtFUsqAaA=="
+
+		handlerCfg    = `{"BasePath":"/content","ChaincodeName":"files","Collection":"consortium","IndexNamespace":"file:idx"}`
+		setHandlerCfg = `{"BasePath":"/content","ChaincodeName":"files","Collection":"consortium","IndexNamespace":"file:idx","IndexDocID":"` + idxID + `"}`
+	)
+
+	newKV := func(peerID, cfg string) *common.KeyValue {
+		return &common.KeyValue{
+			Key: &common.Key{
+				MspID:            msp,
+				PeerID:           peerID,
+				AppName:          common.FileHandlerAppName,
+				AppVersion:       common.FileHandlerAppVersion,
+				ComponentName:    path,
+				ComponentVersion: common.FileHandlerComponentVersion,
+			},
+			Value: &common.Value{TxID: "tx1", Format: common.JSONFormat, Config: cfg},
+		}
+	}
+
+	// peer0 needs to be updated, peer1 already has the index ID, and peer2 has no file handler
+	configs := map[string]*common.KeyValue{
+		peer0: newKV(peer0, handlerCfg),
+		peer1: newKV(peer1, setHandlerCfg),
+	}
+
+	newChannel := func() *mocks.Channel {
+		ch := &mocks.Channel{}
+		ch.QueryStub = func(req channel.Request, _ ...channel.RequestOption) (channel.Response, error) {
+			criteria := &common.Criteria{}
+			if err := json.Unmarshal(req.Args[0], criteria); err != nil {
+				return channel.Response{}, err
+			}
+
+			var kvs []*common.KeyValue
+			if criteria.PeerID == "" {
+				kvs = []*common.KeyValue{configs[peer0], configs[peer1]}
+			} else if kv, ok := configs[criteria.PeerID]; ok {
+				kvs = []*common.KeyValue{kv}
+			}
+
+			payload, err := json.Marshal(kvs)
+			if err != nil {
+				return channel.Response{}, err
+			}
+
+			return channel.Response{Payload: payload}, nil
+		}
+
+		return ch
+	}
+
+	newProvider := func(ch *mocks.Channel) basecmd.FactoryProvider {
+		factory := &mocks.Factory{}
+		factory.ChannelReturns(ch, nil)
+
+		return func(config *environment.Config) (fabric.Factory, error) { return factory, nil }
+	}
+
+	t.Run("With --all-peers", func(t *testing.T) {
+		ch := newChannel()
+		w := &mocks.Writer{}
+
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, newProvider(ch), "--msp", msp, "--all-peers", "--path", path, "--idxid", idxID, "--noprompt")
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgConfigUpdated)
+		require.Contains(t, w.Written(), "Updated peers: ["+peer0+"]")
+		require.Contains(t, w.Written(), "Skipped peers (file index ID already set): ["+peer1+"]")
+		require.Contains(t, w.Written(), "Failed peers: []")
+		require.Equal(t, 1, ch.ExecuteCallCount())
+
+		req, _ := ch.ExecuteArgsForCall(0)
+		cfg := &common.Config{}
+		require.NoError(t, json.Unmarshal(req.Args[0], cfg))
+		require.Len(t, cfg.Peers, 1)
+		require.Equal(t, peer0, cfg.Peers[0].PeerID)
+	})
+
+	t.Run("With --all-peers - no peers found", func(t *testing.T) {
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{Payload: []byte("null")}, nil)
+
+		err := newMockCmd(t, newProvider(ch), "--msp", msp, "--all-peers", "--path", path, "--idxid", idxID, "--noprompt").Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no peers in [Org1MSP] have a file handler for [/content]")
+	})
+
+	t.Run("With --all-peers - query error", func(t *testing.T) {
+		errExpected := errors.New("injected query error")
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{}, errExpected)
+
+		err := newMockCmd(t, newProvider(ch), "--msp", msp, "--all-peers", "--path", path, "--idxid", idxID, "--noprompt").Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error discovering peers")
+	})
+
+	t.Run("Partial failure", func(t *testing.T) {
+		ch := newChannel()
+		w := &mocks.Writer{}
+
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{}, w, newProvider(ch), "--msp", msp, "--peers", peer0+";"+peer1+";"+peer2, "--path", path, "--idxid", idxID, "--noprompt")
+		err := c.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to update the file index ID on 1 peer(s)")
+		require.Contains(t, err.Error(), peer2)
+
+		// The peers that could be updated should still be updated
+		require.Equal(t, 1, ch.ExecuteCallCount())
+		require.Contains(t, w.Written(), "Updated peers: ["+peer0+"]")
+		require.Contains(t, w.Written(), "Skipped peers (file index ID already set): ["+peer1+"]")
+		require.Contains(t, w.Written(), peer2+": config not found for file handler [/content]")
+	})
+}
+
 func newMockCmd(t *testing.T, p basecmd.FactoryProvider, args ...string) *cobra.Command {
 	return newMockCmdWithReaderWriter(t, &mocks.Reader{}, &mocks.Writer{}, p, args...)
 }
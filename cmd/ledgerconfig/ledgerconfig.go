@@ -9,8 +9,12 @@ package main
 import (
 	"github.com/hyperledger/fabric-cli/pkg/environment"
 	"github.com/spf13/cobra"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/auditcmd"
 	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/deletecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/editcmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/filehandlercmd"
 	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/fileidxupdatecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/promotecmd"
 	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/querycmd"
 	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/updatecmd"
 )
@@ -18,7 +22,7 @@ import (
 const (
 	use      = "ledgerconfig"
 	desc     = "Manages ledger configuration"
-	longDesc = "The ledgerconfig command allows you to update, delete, and query ledger configuration."
+	longDesc = "The ledgerconfig command allows you to update, edit, promote, delete, query and audit ledger configuration."
 )
 
 // New is the entry point to the ledgerconfig plugin
@@ -36,6 +40,10 @@ func New(settings *environment.Settings) *cobra.Command {
 		updatecmd.New(settings),
 		deletecmd.New(settings),
 		fileidxupdatecmd.New(settings),
+		editcmd.New(settings),
+		filehandlercmd.New(settings),
+		auditcmd.New(settings),
+		promotecmd.New(settings),
 	)
 	return cmd
 }
@@ -34,4 +34,12 @@ func TestNew(t *testing.T) {
 	require.Contains(t, w.Written(), "Delete ledger configuration")
 	// Make sure that the fileidxupdate command was added
 	require.Contains(t, w.Written(), "fileidxupdate")
+	// Make sure that the edit command was added
+	require.Contains(t, w.Written(), "Edit ledger configuration")
+	// Make sure that the filehandler command was added
+	require.Contains(t, w.Written(), "filehandler")
+	// Make sure that the audit command was added
+	require.Contains(t, w.Written(), "Audit peer-specific ledger configuration")
+	// Make sure that the promote command was added
+	require.Contains(t, w.Written(), "Promote application configuration")
 }
@@ -0,0 +1,144 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package promotecmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+)
+
+// patch is applied to JSON configuration as a JSON merge patch (RFC 7386) and to YAML configuration as
+// a YAML overlay. Both use the same merge semantics: objects are merged recursively, null values remove
+// the field and all other values replace the target value.
+type patch struct {
+	doc interface{}
+}
+
+// readPatch reads the patch from the given file. The file may be in JSON or YAML format.
+func readPatch(file string) (*patch, error) {
+	patchBytes, err := ioutil.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error reading patch file [%s]", file)
+	}
+
+	var doc interface{}
+	if err := yaml.Unmarshal(patchBytes, &doc); err != nil {
+		return nil, errors.WithMessagef(err, "invalid patch file [%s]", file)
+	}
+
+	return &patch{doc: normalize(doc)}, nil
+}
+
+// apply applies the patch to the given configuration and returns the patched configuration
+func (p *patch) apply(format common.Format, config string) (string, error) {
+	var target interface{}
+
+	switch common.Format(strings.ToUpper(string(format))) {
+	case common.JSONFormat:
+		if err := unmarshalJSON(config, &target); err != nil {
+			return "", errors.WithMessage(err, "invalid JSON configuration")
+		}
+
+		patched, err := json.Marshal(mergePatch(target, p.doc))
+		if err != nil {
+			return "", err
+		}
+
+		return string(patched), nil
+	case common.YAMLFormat:
+		if err := yaml.Unmarshal([]byte(config), &target); err != nil {
+			return "", errors.WithMessage(err, "invalid YAML configuration")
+		}
+
+		patched, err := yaml.Marshal(mergePatch(normalize(target), p.doc))
+		if err != nil {
+			return "", err
+		}
+
+		return string(patched), nil
+	default:
+		return "", errors.Errorf("a patch cannot be applied to configuration in [%s] format", format)
+	}
+}
+
+// unmarshalJSON decodes the given JSON document with numbers decoded as json.Number (instead of float64) so that
+// integers that can't be represented exactly by a float64 are written back unchanged. (The YAML decoder already
+// decodes integers as int, int64 or uint64.)
+func unmarshalJSON(doc string, v interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(doc))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("unexpected data after top-level value")
+	}
+
+	return nil
+}
+
+// mergePatch merges the patch into the target according to the rules of RFC 7386
+func mergePatch(target, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetMap, ok := target.(map[string]interface{})
+	if !ok {
+		targetMap = make(map[string]interface{})
+	}
+
+	for k, v := range patchMap {
+		if v == nil {
+			delete(targetMap, k)
+		} else {
+			targetMap[k] = mergePatch(targetMap[k], v)
+		}
+	}
+
+	return targetMap
+}
+
+// normalize converts the maps that are produced by the YAML decoder (with interface{} keys) into
+// maps with string keys so that YAML and JSON documents may be merged
+func normalize(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, fv := range value {
+			m[fmt.Sprint(k)] = normalize(fv)
+		}
+
+		return m
+	case map[string]interface{}:
+		for k, fv := range value {
+			value[k] = normalize(fv)
+		}
+
+		return value
+	case []interface{}:
+		for i, fv := range value {
+			value[i] = normalize(fv)
+		}
+
+		return value
+	default:
+		return v
+	}
+}
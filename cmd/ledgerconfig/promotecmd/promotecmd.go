@@ -0,0 +1,361 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package promotecmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+)
+
+const (
+	use      = "promote"
+	desc     = "Promote application configuration to a new version"
+	longDesc = `
+The promote command copies the configuration of an application from one version to another. All of the configuration
+of the application at the source version (including peer-specific configuration and application components) is
+retrieved, the application version is rewritten to the target version and the resulting configuration is saved.
+The promotion may be limited to a set of peers (using the --peers option) and/or a set of components (using the
+--components option). The versions of the components are not changed.
+
+A patch file may be specified (using the --patch option) which is applied to the application configuration (but not
+to the component configuration) after the version is rewritten. If the configuration is in JSON format then the patch
+is applied as a JSON merge patch (RFC 7386). If the configuration is in YAML format then the patch is applied as a
+YAML overlay, i.e. maps are merged recursively and all other values are replaced. In both cases a null value in the
+patch removes the field. Note that comments in YAML configuration are not preserved when a patch is applied.
+
+The command fails if any of the configuration already exists at the target version.
+`
+	examples = `
+- Promote all of the configuration of app1 from version v1 to version v2:
+
+    $ ./fabric ledgerconfig promote --mspid Org1MSP --appname app1 --from v1 --to v2
+
+- Promote the configuration of app1 on two peers and apply a patch:
+
+    $ ./fabric ledgerconfig promote --mspid Org1MSP --appname app1 --from v1 --to v2 --peers "peer0.org1.com;peer1.org1.com" --patch ./app1-v2-patch.yaml
+
+- Promote the configuration of component comp1 of app2:
+
+    $ ./fabric ledgerconfig promote --mspid Org1MSP --appname app2 --from v1 --to v2 --components comp1
+`
+)
+
+const (
+	mspIDFlag  = "mspid"
+	mspIDUsage = `The ID of the MSP. Example: --mspid Org1MSP`
+
+	appNameFlag  = "appname"
+	appNameUsage = "The name of the application. Example: --appname app1"
+
+	fromFlag  = "from"
+	fromUsage = "The application version to promote from. Example: --from v1"
+
+	toFlag  = "to"
+	toUsage = "The application version to promote to. Example: --to v2"
+
+	peersFlag  = "peers"
+	peersUsage = "An optional semi-colon-separated list of peers. If specified then only the configuration of the given peers is promoted. Example: --peers peer0.org1.com;peer1.org1.com"
+
+	componentsFlag  = "components"
+	componentsUsage = "An optional comma-separated list of component names. If specified then only the given components (along with the application configuration) are promoted. Example: --components comp1,comp2"
+
+	patchFlag  = "patch"
+	patchUsage = "The path to a patch file (JSON merge patch or YAML overlay) that is applied to the promoted application configuration. Example: --patch ./app1-v2-patch.yaml"
+
+	noPromptFlag  = "noprompt"
+	noPromptUsage = "If specified then the promote operation will not prompt for confirmation. Example: --noprompt"
+
+	msgConfigPromoted  = "Configuration successfully promoted!"
+	msgAborted         = "Operation aborted"
+	msgContinueOrAbort = "Enter Y to continue or N to abort "
+)
+
+var (
+	errMSPRequired     = errors.New("msp (--mspid) is required")
+	errAppNameRequired = errors.New("application name (--appname) is required")
+	errFromRequired    = errors.New("source version (--from) is required")
+	errToRequired      = errors.New("target version (--to) is required")
+	errSameVersion     = errors.New("source version (--from) and target version (--to) must be different")
+)
+
+// New returns the ledgerconfig promote sub-command
+func New(settings *environment.Settings) *cobra.Command {
+	return newCmd(settings, nil)
+}
+
+func newCmd(settings *environment.Settings, p basecmd.FactoryProvider) *cobra.Command {
+	c := &command{
+		Command: basecmd.New(settings, p),
+	}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   desc,
+		Long:    longDesc,
+		Example: examples,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return c.validate()
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.run()
+		},
+	}
+
+	c.Settings = settings
+	cmd.SetOutput(c.Settings.Streams.Out)
+	cmd.SilenceUsage = true
+
+	cmd.Flags().StringVar(&c.mspID, mspIDFlag, "", mspIDUsage)
+	cmd.Flags().StringVar(&c.appName, appNameFlag, "", appNameUsage)
+	cmd.Flags().StringVar(&c.from, fromFlag, "", fromUsage)
+	cmd.Flags().StringVar(&c.to, toFlag, "", toUsage)
+	cmd.Flags().StringVar(&c.peers, peersFlag, "", peersUsage)
+	cmd.Flags().StringVar(&c.components, componentsFlag, "", componentsUsage)
+	cmd.Flags().StringVar(&c.patchFile, patchFlag, "", patchUsage)
+	cmd.Flags().BoolVar(&c.noPrompt, noPromptFlag, false, noPromptUsage)
+
+	return cmd
+}
+
+// command implements the promote command
+type command struct {
+	*basecmd.Command
+
+	// Flags
+	mspID      string
+	appName    string
+	from       string
+	to         string
+	peers      string
+	components string
+	patchFile  string
+	noPrompt   bool
+}
+
+func (c *command) validate() error {
+	if c.mspID == "" {
+		return errMSPRequired
+	}
+
+	if c.appName == "" {
+		return errAppNameRequired
+	}
+
+	if c.from == "" {
+		return errFromRequired
+	}
+
+	if c.to == "" {
+		return errToRequired
+	}
+
+	if c.from == c.to {
+		return errSameVersion
+	}
+
+	return nil
+}
+
+func (c *command) run() error {
+	var p *patch
+	if c.patchFile != "" {
+		var err error
+		p, err = readPatch(c.patchFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	kvs, err := c.query(c.from)
+	if err != nil {
+		return err
+	}
+
+	if len(kvs) == 0 {
+		return errors.Errorf("no configuration found for version [%s] of app [%s]", c.from, c.appName)
+	}
+
+	if err = c.checkTarget(kvs); err != nil {
+		return err
+	}
+
+	promoted, err := promote(kvs, c.to, p)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := common.NewConfig(promoted)
+	if err != nil {
+		return err
+	}
+
+	configBytes, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	if !c.noPrompt {
+		confirmed, e := c.confirmUpdate(configBytes)
+		if e != nil {
+			return e
+		}
+
+		if !confirmed {
+			return c.Fprintln(msgAborted)
+		}
+	}
+
+	req := channel.Request{
+		ChaincodeID: common.ConfigSCC,
+		Fcn:         "save",
+		Args:        [][]byte{configBytes},
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		return err
+	}
+
+	_, err = ch.Execute(req, channel.WithRetry(retry.DefaultChannelOpts))
+	if err != nil {
+		return err
+	}
+
+	return c.Fprintln(msgConfigPromoted)
+}
+
+// query returns the key-values of the given application version that match the peers and components filters
+func (c *command) query(version string) ([]*common.KeyValue, error) {
+	ch, err := c.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	kvs, err := common.QueryKeyValues(ch, &common.Criteria{
+		MspID:      c.mspID,
+		AppName:    c.appName,
+		AppVersion: version,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	peers := toSet(c.peers, ";")
+	components := toSet(c.components, ",")
+
+	var filtered []*common.KeyValue
+
+	for _, kv := range kvs {
+		if len(peers) > 0 && !peers[kv.PeerID] {
+			continue
+		}
+
+		if len(components) > 0 && kv.ComponentName != "" && !components[kv.ComponentName] {
+			continue
+		}
+
+		filtered = append(filtered, kv)
+	}
+
+	return filtered, nil
+}
+
+// checkTarget ensures that none of the given key-values already exist at the target version
+func (c *command) checkTarget(kvs []*common.KeyValue) error {
+	existing, err := c.query(c.to)
+	if err != nil {
+		return err
+	}
+
+	var conflicts []string
+
+	for _, kv := range kvs {
+		for _, e := range existing {
+			if e.PeerID == kv.PeerID && e.ComponentName == kv.ComponentName && e.ComponentVersion == kv.ComponentVersion {
+				conflicts = append(conflicts, e.Key.String())
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return errors.Errorf("version [%s] of app [%s] already exists: %s", c.to, c.appName, strings.Join(conflicts, ", "))
+	}
+
+	return nil
+}
+
+// confirmUpdate prompts the user for confirmation of the update
+func (c *command) confirmUpdate(config []byte) (bool, error) {
+	displayedJSON, err := common.FormatJSON(config)
+	if err != nil {
+		return false, err
+	}
+
+	prompt := fmt.Sprintf("Promoting app [%s] from version [%s] to version [%s] with the following configuration:\n\n%s\n\n%s",
+		c.appName, c.from, c.to, displayedJSON, msgContinueOrAbort)
+
+	err = c.Fprintln(prompt)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.ToLower(c.Prompt()) == "y", nil
+}
+
+// promote returns copies of the given key-values with the application version set to the given version. The
+// patch (if any) is applied to the application configuration.
+func promote(kvs []*common.KeyValue, version string, p *patch) ([]*common.KeyValue, error) {
+	promoted := make([]*common.KeyValue, len(kvs))
+	patched := false
+
+	for i, kv := range kvs {
+		key := *kv.Key
+		key.AppVersion = version
+
+		value := *kv.Value
+
+		if p != nil && kv.ComponentName == "" {
+			config, err := p.apply(value.Format, value.Config)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "error applying patch to %s", kv.Key)
+			}
+
+			value.Config = config
+			patched = true
+		}
+
+		promoted[i] = &common.KeyValue{Key: &key, Value: &value}
+	}
+
+	if p != nil && !patched {
+		return nil, errors.New("the patch could not be applied since no application configuration was found (patches are not applied to components)")
+	}
+
+	return promoted, nil
+}
+
+func toSet(list, sep string) map[string]bool {
+	set := make(map[string]bool)
+
+	for _, item := range strings.Split(list, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			set[item] = true
+		}
+	}
+
+	return set
+}
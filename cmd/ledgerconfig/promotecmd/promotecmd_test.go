@@ -0,0 +1,320 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package promotecmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric-cli/pkg/environment"
+	"github.com/hyperledger/fabric-cli/pkg/fabric"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/fabric-cli-ext/cmd/basecmd"
+	"github.com/trustbloc/fabric-cli-ext/cmd/ledgerconfig/common"
+	"github.com/trustbloc/fabric-cli-ext/cmd/mocks"
+)
+
+const (
+	msp   = "Org1MSP"
+	peer0 = "peer0.org1.com"
+	peer1 = "peer1.org1.com"
+)
+
+func TestNew(t *testing.T) {
+	require.NotNil(t, New(environment.NewDefaultSettings()))
+}
+
+func TestPromoteCmd_InvalidOptions(t *testing.T) {
+	t.Run("No MSP", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil).Execute(), errMSPRequired.Error())
+	})
+
+	t.Run("No app name", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, "--mspid", msp).Execute(), errAppNameRequired.Error())
+	})
+
+	t.Run("No from", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, "--mspid", msp, "--appname", "app1").Execute(), errFromRequired.Error())
+	})
+
+	t.Run("No to", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, "--mspid", msp, "--appname", "app1", "--from", "v1").Execute(), errToRequired.Error())
+	})
+
+	t.Run("Same version", func(t *testing.T) {
+		require.EqualError(t, newMockCmd(t, nil, "--mspid", msp, "--appname", "app1", "--from", "v1", "--to", "v1").Execute(), errSameVersion.Error())
+	})
+}
+
+func TestPromoteCmd(t *testing.T) {
+	kvs := []*common.KeyValue{
+		newKV(peer0, "v1", "", common.YAMLFormat, "key1: value1\nkey2: value2\n"),
+		newKV(peer1, "v1", "", common.YAMLFormat, "key1: value1\nkey2: value2\n"),
+		newKV("", "v1", "", common.JSONFormat, `{"key1":"value1","key2":{"a":1,"b":2}}`),
+		newKV("", "v1", "comp1", common.OtherFormat, "comp1 config"),
+		newKV("", "v1", "comp2", common.OtherFormat, "comp2 config"),
+		newKV(peer0, "v3", "", common.YAMLFormat, "key1: value1\n"),
+	}
+
+	args := []string{"--mspid", msp, "--appname", "app1", "--from", "v1"}
+
+	t.Run("With prompt - Y", func(t *testing.T) {
+		ch := newChannel(t, kvs)
+		w := &mocks.Writer{}
+
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("Y\n")}, w, newProvider(ch), append(args, "--to", "v2")...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgContinueOrAbort)
+		require.Contains(t, w.Written(), msgConfigPromoted)
+		require.Equal(t, 1, ch.ExecuteCallCount())
+
+		cfg := getSavedConfig(t, ch)
+		require.Equal(t, msp, cfg.MspID)
+		require.Len(t, cfg.Peers, 2)
+		require.Len(t, cfg.Apps, 1)
+		require.Equal(t, "v2", cfg.Apps[0].Version)
+		require.Len(t, cfg.Apps[0].Components, 2)
+		require.Equal(t, "v1", cfg.Apps[0].Components[0].Version)
+
+		for _, p := range cfg.Peers {
+			require.Len(t, p.Apps, 1)
+			require.Equal(t, "v2", p.Apps[0].Version)
+			require.Equal(t, "key1: value1\nkey2: value2\n", p.Apps[0].Config)
+		}
+	})
+
+	t.Run("With prompt - N", func(t *testing.T) {
+		ch := newChannel(t, kvs)
+		w := &mocks.Writer{}
+
+		c := newMockCmdWithReaderWriter(t, &mocks.Reader{Bytes: []byte("N\n")}, w, newProvider(ch), append(args, "--to", "v2")...)
+		require.NoError(t, c.Execute())
+		require.Contains(t, w.Written(), msgAborted)
+		require.Equal(t, 0, ch.ExecuteCallCount())
+	})
+
+	t.Run("Peers and components", func(t *testing.T) {
+		ch := newChannel(t, kvs)
+
+		c := newMockCmd(t, newProvider(ch), append(args, "--to", "v2", "--peers", peer1, "--components", "comp1", "--noprompt")...)
+		require.NoError(t, c.Execute())
+
+		cfg := getSavedConfig(t, ch)
+		require.Empty(t, cfg.Apps)
+		require.Len(t, cfg.Peers, 1)
+		require.Equal(t, peer1, cfg.Peers[0].PeerID)
+
+		ch = newChannel(t, kvs)
+
+		c = newMockCmd(t, newProvider(ch), append(args, "--to", "v2", "--components", "comp2", "--noprompt")...)
+		require.NoError(t, c.Execute())
+
+		cfg = getSavedConfig(t, ch)
+		require.Len(t, cfg.Apps, 1)
+		require.Len(t, cfg.Apps[0].Components, 1)
+		require.Equal(t, "comp2", cfg.Apps[0].Components[0].Name)
+	})
+
+	t.Run("With patch", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "promotecmd")
+		require.NoError(t, err)
+		defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+		patchFile := filepath.Join(dir, "patch.yaml")
+		require.NoError(t, ioutil.WriteFile(patchFile, []byte("key2:\n  a: 10\n  b: null\nkey3: value3\n"), 0600))
+
+		ch := newChannel(t, kvs)
+
+		c := newMockCmd(t, newProvider(ch), append(args, "--to", "v2", "--patch", patchFile, "--noprompt")...)
+		require.NoError(t, c.Execute())
+
+		cfg := getSavedConfig(t, ch)
+		require.JSONEq(t, `{"key1":"value1","key2":{"a":10},"key3":"value3"}`, cfg.Apps[0].Config)
+		require.Equal(t, "comp1 config", cfg.Apps[0].Components[0].Config)
+		require.Equal(t, "key1: value1\nkey2:\n  a: 10\nkey3: value3\n", cfg.Peers[0].Apps[0].Config)
+	})
+
+	t.Run("Patch file not found", func(t *testing.T) {
+		err := newMockCmd(t, newProvider(newChannel(t, kvs)), append(args, "--to", "v2", "--patch", "./invalid.yaml")...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error reading patch file")
+	})
+
+	t.Run("Target version exists", func(t *testing.T) {
+		err := newMockCmd(t, newProvider(newChannel(t, kvs)), append(args, "--to", "v3")...).Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "version [v3] of app [app1] already exists")
+		require.Contains(t, err.Error(), peer0)
+	})
+
+	t.Run("No config", func(t *testing.T) {
+		err := newMockCmd(t, newProvider(newChannel(t, kvs)), "--mspid", msp, "--appname", "app1", "--from", "v5", "--to", "v6").Execute()
+		require.EqualError(t, err, "no configuration found for version [v5] of app [app1]")
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		errExpected := errors.New("injected query error")
+		ch := &mocks.Channel{}
+		ch.QueryReturns(channel.Response{}, errExpected)
+
+		require.EqualError(t, newMockCmd(t, newProvider(ch), append(args, "--to", "v2")...).Execute(), errExpected.Error())
+	})
+
+	t.Run("Execute error", func(t *testing.T) {
+		errExpected := errors.New("injected execute error")
+		ch := newChannel(t, kvs)
+		ch.ExecuteReturns(channel.Response{}, errExpected)
+
+		require.EqualError(t, newMockCmd(t, newProvider(ch), append(args, "--to", "v2", "--noprompt")...).Execute(), errExpected.Error())
+	})
+}
+
+func TestPatch(t *testing.T) {
+	p := &patch{doc: map[string]interface{}{"key1": "value2", "key2": nil}}
+
+	t.Run("JSON", func(t *testing.T) {
+		patched, err := p.apply(common.JSONFormat, `{"key1":"value1","key2":"value2","key3":"value3"}`)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"key1":"value2","key3":"value3"}`, patched)
+
+		_, err = p.apply(common.JSONFormat, `{"key1":`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid JSON configuration")
+
+		_, err = p.apply(common.JSONFormat, `{"key1":"value1"} {}`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid JSON configuration")
+	})
+
+	t.Run("Large integers", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "patch")
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+		patchFile := filepath.Join(dir, "patch.json")
+		require.NoError(t, ioutil.WriteFile(patchFile, []byte(`{"key2":9007199254740995}`), 0600))
+
+		lp, err := readPatch(patchFile)
+		require.NoError(t, err)
+
+		// 2^53+1 can't be represented exactly by a float64
+		patched, err := lp.apply(common.JSONFormat, `{"key1":9007199254740993,"key2":1,"key3":1.5}`)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"key1":9007199254740993,"key2":9007199254740995,"key3":1.5}`, patched)
+		require.Contains(t, patched, `"key1":9007199254740993`)
+		require.Contains(t, patched, `"key2":9007199254740995`)
+
+		patched, err = lp.apply(common.YAMLFormat, "key1: 9007199254740993\nkey2: 1\n")
+		require.NoError(t, err)
+		require.Equal(t, "key1: 9007199254740993\nkey2: 9007199254740995\n", patched)
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		patched, err := p.apply(common.YAMLFormat, "key1: value1\nkey2: value2\n")
+		require.NoError(t, err)
+		require.Equal(t, "key1: value2\n", patched)
+
+		_, err = p.apply(common.YAMLFormat, "key1: [value1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid YAML configuration")
+	})
+
+	t.Run("Other", func(t *testing.T) {
+		_, err := p.apply(common.OtherFormat, "some config")
+		require.EqualError(t, err, "a patch cannot be applied to configuration in [Other] format")
+	})
+
+	t.Run("Non-object patch", func(t *testing.T) {
+		patched, err := (&patch{doc: []interface{}{"a"}}).apply(common.JSONFormat, `{"key1":"value1"}`)
+		require.NoError(t, err)
+		require.Equal(t, `["a"]`, patched)
+	})
+}
+
+func newKV(peerID, version, componentName string, format common.Format, config string) *common.KeyValue {
+	key := &common.Key{MspID: msp, PeerID: peerID, AppName: "app1", AppVersion: version}
+	if componentName != "" {
+		key.ComponentName = componentName
+		key.ComponentVersion = "v1"
+	}
+
+	return &common.KeyValue{
+		Key:   key,
+		Value: &common.Value{TxID: "tx1", Format: format, Config: config},
+	}
+}
+
+// newChannel returns a mock channel that returns the key-values that match the app version in the query criteria
+func newChannel(t *testing.T, kvs []*common.KeyValue) *mocks.Channel {
+	ch := &mocks.Channel{}
+	ch.QueryStub = func(req channel.Request, _ ...channel.RequestOption) (channel.Response, error) {
+		criteria := &common.Criteria{}
+		require.NoError(t, json.Unmarshal(req.Args[0], criteria))
+
+		var matching []*common.KeyValue
+		for _, kv := range kvs {
+			if kv.AppVersion == criteria.AppVersion {
+				matching = append(matching, kv)
+			}
+		}
+
+		payload, err := json.Marshal(matching)
+		require.NoError(t, err)
+
+		return channel.Response{Payload: payload}, nil
+	}
+
+	return ch
+}
+
+func newProvider(ch *mocks.Channel) basecmd.FactoryProvider {
+	factory := &mocks.Factory{}
+	factory.ChannelReturns(ch, nil)
+
+	return func(config *environment.Config) (fabric.Factory, error) { return factory, nil }
+}
+
+func getSavedConfig(t *testing.T, ch *mocks.Channel) *common.Config {
+	require.Equal(t, 1, ch.ExecuteCallCount())
+
+	req, _ := ch.ExecuteArgsForCall(0)
+	require.Equal(t, "save", req.Fcn)
+
+	cfg := &common.Config{}
+	require.NoError(t, json.Unmarshal(req.Args[0], cfg))
+
+	return cfg
+}
+
+func newMockCmd(t *testing.T, p basecmd.FactoryProvider, args ...string) *cobra.Command {
+	return newMockCmdWithReaderWriter(t, &mocks.Reader{}, &mocks.Writer{}, p, args...)
+}
+
+func newMockCmdWithReaderWriter(t *testing.T, in io.Reader, w io.Writer, p basecmd.FactoryProvider, args ...string) *cobra.Command {
+	settings := environment.NewDefaultSettings()
+	settings.Streams.Out = w
+	settings.Streams.In = in
+
+	settings.Config.CurrentContext = "testctx"
+	settings.Config.Contexts[settings.Config.CurrentContext] = &environment.Context{}
+
+	c := newCmd(settings, p)
+	require.NotNil(t, c)
+
+	c.SetArgs(args)
+
+	return c
+}
@@ -8,7 +8,9 @@ package mocks
 
 import (
 	"io"
+	"io/ioutil"
 	"net/http"
+	"sync"
 )
 
 // MockTransport implements a mock HTTP transport
@@ -17,6 +19,33 @@ type MockTransport struct {
 	GetErr       error
 	PostResponse *http.Response
 	PostErr      error
+
+	// GetResponses contains responses for specific URLs. If a GET request is made for a URL that is
+	// not in the map then GetResponse is returned.
+	GetResponses map[string]*http.Response
+
+	// OrderedGetResponses contains responses that are returned (in order) for GET requests. Once all of
+	// the responses have been returned then GetResponses and GetResponse are used.
+	OrderedGetResponses []*http.Response
+
+	// GetRequests contains the URLs of all of the GET requests that were made
+	GetRequests []string
+
+	// HeadRequests contains the URLs of all of the HEAD requests that were made. A HEAD request is answered with
+	// the response (without the body) that would be returned for a GET request.
+	HeadRequests []string
+
+	// PostResponses contains responses that are returned (in order) for POST requests. Once all of
+	// the responses have been returned then PostResponse is returned.
+	PostResponses []*http.Response
+
+	// PostRequests contains the bodies of all of the POST requests that were made
+	PostRequests [][]byte
+
+	// PostContentTypes contains the content type of each of the POST requests that were made
+	PostContentTypes []string
+
+	mutex sync.Mutex
 }
 
 // NewTransport returns a mock transport
@@ -30,12 +59,35 @@ func (m *MockTransport) WithGetResponse(resp *http.Response) *MockTransport {
 	return m
 }
 
+// WithGetResponseForURL sets the mock response for a Get of the given URL
+func (m *MockTransport) WithGetResponseForURL(url string, resp *http.Response) *MockTransport {
+	if m.GetResponses == nil {
+		m.GetResponses = make(map[string]*http.Response)
+	}
+
+	m.GetResponses[url] = resp
+
+	return m
+}
+
+// WithOrderedGetResponses sets the mock responses that are returned (in order) for GET requests
+func (m *MockTransport) WithOrderedGetResponses(resps ...*http.Response) *MockTransport {
+	m.OrderedGetResponses = resps
+	return m
+}
+
 // WithPostResponse sets the mock response for a Post
 func (m *MockTransport) WithPostResponse(resp *http.Response) *MockTransport {
 	m.PostResponse = resp
 	return m
 }
 
+// WithPostResponses sets the mock responses that are returned (in order) for POST requests
+func (m *MockTransport) WithPostResponses(resps ...*http.Response) *MockTransport {
+	m.PostResponses = resps
+	return m
+}
+
 // WithGetError injects an error
 func (m *MockTransport) WithGetError(err error) *MockTransport {
 	m.GetErr = err
@@ -51,16 +103,96 @@ func (m *MockTransport) WithPostError(err error) *MockTransport {
 // RoundTrip implements http.RoundTripper
 func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	if req.Method == http.MethodPost {
-		return m.PostResponse, m.PostErr
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		if req.Body != nil {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+
+			m.PostRequests = append(m.PostRequests, body)
+			m.PostContentTypes = append(m.PostContentTypes, req.Header.Get("Content-Type"))
+		}
+
+		if len(m.PostResponses) > 0 {
+			resp := m.PostResponses[0]
+			m.PostResponses = m.PostResponses[1:]
+
+			return newResponse(resp), nil
+		}
+
+		return newResponse(m.PostResponse), m.PostErr
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if req.Method == http.MethodHead {
+		m.HeadRequests = append(m.HeadRequests, req.URL.String())
+
+		resp, err := m.getResponse(req)
+
+		return withoutBody(resp), err
+	}
+
+	m.GetRequests = append(m.GetRequests, req.URL.String())
+
+	return m.getResponse(req)
+}
+
+func (m *MockTransport) getResponse(req *http.Request) (*http.Response, error) {
+	if len(m.OrderedGetResponses) > 0 {
+		resp := m.OrderedGetResponses[0]
+		m.OrderedGetResponses = m.OrderedGetResponses[1:]
+
+		return newResponse(resp), nil
+	}
+
+	if resp, ok := m.GetResponses[req.URL.String()]; ok {
+		return newResponse(resp), nil
 	}
 
-	return m.GetResponse, m.GetErr
+	return newResponse(m.GetResponse), m.GetErr
+}
+
+// withoutBody returns a copy of the given response with an empty body
+func withoutBody(resp *http.Response) *http.Response {
+	if resp == nil {
+		return nil
+	}
+
+	r := *resp
+	r.Body = http.NoBody
+
+	return &r
+}
+
+// newResponse returns a copy of the given response with a new mock body so that the same
+// response may be returned for multiple (possibly concurrent) requests
+func newResponse(resp *http.Response) *http.Response {
+	if resp == nil {
+		return nil
+	}
+
+	body, ok := resp.Body.(*MockResponseBody)
+	if !ok {
+		return resp
+	}
+
+	r := *resp
+	r.Body = &MockResponseBody{Bytes: body.Bytes, Err: body.Err}
+
+	return &r
 }
 
 // MockResponseBody implements a mock io.ReadCloser
 type MockResponseBody struct {
 	Bytes []byte
 	Err   error
+
+	offset int
 }
 
 // NewResponseBody returns a new mock response body
@@ -76,7 +208,14 @@ func (m *MockResponseBody) Read(p []byte) (int, error) {
 		return 0, m.Err
 	}
 
-	return copy(p, m.Bytes), io.EOF
+	if m.offset >= len(m.Bytes) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, m.Bytes[m.offset:])
+	m.offset += n
+
+	return n, nil
 }
 
 // Close mocks out the Close func
@@ -5,6 +5,7 @@
 module github.com/trustbloc/fabric-cli-ext
 
 require (
+	github.com/btcsuite/btcd v0.20.1-beta
 	github.com/hyperledger/fabric-cli v0.0.0-20201005191300-d9e3966b20eb
 	github.com/hyperledger/fabric-protos-go v0.0.0-20200707132912-fee30f3ccd23
 	github.com/hyperledger/fabric-sdk-go v1.0.0-beta3.0.20201002210629-a64e1ef9f926
@@ -12,6 +13,7 @@ require (
 	github.com/spf13/cobra v0.0.6
 	github.com/stretchr/testify v1.5.1
 	github.com/trustbloc/sidetree-core-go v0.6.0
+	gopkg.in/yaml.v2 v2.3.0
 )
 
 go 1.14